@@ -0,0 +1,33 @@
+// Package admin guards the administrative endpoints
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"googlescrapper/apierror"
+)
+
+// RequireToken is middleware that only lets requests through when they carry
+// "Authorization: Bearer <ADMIN_TOKEN>". When ADMIN_TOKEN isn't set the admin
+// API is disabled entirely.
+func RequireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("ADMIN_TOKEN")
+		if token == "" {
+			apierror.Write(w, r, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Admin API is disabled: ADMIN_TOKEN is not set"))
+			return
+		}
+
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing or invalid admin token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
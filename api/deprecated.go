@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// deprecatedSince is when the pre-/v1 routes were deprecated
+var deprecatedSince = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+// Deprecated marks a legacy route as deprecated in favour of its /v1
+// successor with the Deprecation (RFC 9745) and Link headers. The response
+// itself is unchanged so existing clients keep working while they migrate.
+func Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedSince.Unix(), 10)
+	link := "<" + successor + `>; rel="successor-version"`
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Set("Link", link)
+		next(w, r)
+	}
+}
@@ -0,0 +1,267 @@
+// Package api holds the building blocks of the /v1 endpoints: parameters read
+// from the query string or a JSON body and validated against a declared list,
+// and the {"data", "meta"} response envelope with snake_case keys.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"googlescrapper/apierror"
+)
+
+// ParamType is the type a parameter value must parse as
+type ParamType string
+
+const (
+	TypeString  ParamType = "string"
+	TypeInteger ParamType = "integer"
+	TypeNumber  ParamType = "number"
+	TypeBoolean ParamType = "boolean"
+	TypeDate    ParamType = "date" // YYYY-MM-DD
+)
+
+// DateLayout is the format of TypeDate values
+const DateLayout = "2006-01-02"
+
+// maxBodyBytes caps the JSON body read for parameters
+const maxBodyBytes = 1 << 20
+
+// Param declares a parameter of a /v1 endpoint
+type Param struct {
+	Name        string
+	Type        ParamType
+	Description string
+	Required    bool
+	Repeated    bool     // May be given more than once, or as a JSON array
+	Default     string   // Used when the parameter is absent
+	Enum        []string // Allowed values, matched case-insensitively
+	Min, Max    *float64 // Bounds for integers and numbers
+}
+
+// Limit returns a pointer to n for Param.Min and Param.Max
+func Limit(n float64) *float64 {
+	return &n
+}
+
+// Values holds the parameters of a request after Parse has validated them and
+// filled in defaults
+type Values struct {
+	url.Values
+}
+
+// Int returns an integer parameter, or 0 when it is absent
+func (v Values) Int(name string) int {
+	n, _ := strconv.Atoi(v.Get(name))
+	return n
+}
+
+// Float returns a number parameter and whether it was given
+func (v Values) Float(name string) (float64, bool) {
+	if !v.Has(name) {
+		return 0, false
+	}
+	f, _ := strconv.ParseFloat(v.Get(name), 64)
+	return f, true
+}
+
+// Bool returns a boolean parameter, or false when it is absent
+func (v Values) Bool(name string) bool {
+	b, _ := strconv.ParseBool(v.Get(name))
+	return b
+}
+
+// Parse reads the request's parameters and validates them against params.
+// POST requests with a body take their parameters from a JSON object, which
+// override any in the query string. Every invalid or unknown parameter is
+// reported in the returned error.
+func Parse(r *http.Request, params []Param) (Values, *apierror.Error) {
+	values := r.URL.Query()
+
+	var fields []apierror.FieldError
+	invalid := make(map[string]bool) // Body values that failed to convert
+	if r.Method == http.MethodPost {
+		body, err := readBody(r)
+		if err != nil {
+			return Values{}, apierror.Invalid(apierror.FieldError{Field: "body", Message: err.Error()})
+		}
+		for name, value := range body {
+			strs, err := bodyStrings(value)
+			if err != nil {
+				fields = append(fields, apierror.FieldError{Field: name, Message: err.Error()})
+				invalid[name] = true
+				continue
+			}
+			values[name] = strs
+		}
+	}
+
+	known := make(map[string]bool, len(params))
+	for _, param := range params {
+		known[param.Name] = true
+		if invalid[param.Name] {
+			continue
+		}
+		if err := param.check(values); err != nil {
+			fields = append(fields, apierror.FieldError{Field: param.Name, Message: err.Error()})
+		}
+	}
+	var unknown []string
+	for name := range values {
+		if !known[name] && !invalid[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		fields = append(fields, apierror.FieldError{Field: name, Message: "unknown parameter"})
+	}
+
+	if len(fields) > 0 {
+		return Values{}, apierror.Invalid(fields...)
+	}
+	return Values{values}, nil
+}
+
+// Invalid writes a 400 for a parameter that failed a handler's own checks. A
+// *apierror.FieldError is reported against its parameter; other errors
+// against field.
+func Invalid(w http.ResponseWriter, r *http.Request, field string, err error) {
+	var fieldErr *apierror.FieldError
+	if !errors.As(err, &fieldErr) {
+		fieldErr = &apierror.FieldError{Field: field, Message: err.Error()}
+	}
+	apierror.Write(w, r, apierror.Invalid(*fieldErr))
+}
+
+// readBody decodes a JSON object body, returning nil for an empty body
+func readBody(r *http.Request) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes))
+	decoder.UseNumber()
+
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return body, nil
+}
+
+// bodyStrings converts a JSON body value to the strings a query string would
+// have carried
+func bodyStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case json.Number:
+		return []string{v.String()}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case []interface{}, map[string]interface{}:
+				return nil, fmt.Errorf("must be an array of scalars")
+			}
+			str, _ := bodyStrings(item)
+			strs = append(strs, str...)
+		}
+		return strs, nil
+	}
+	return nil, fmt.Errorf("must not be an object")
+}
+
+// check validates the parameter in values, normalising enum values to lower
+// case and filling in the default
+func (p Param) check(values url.Values) error {
+	strs := values[p.Name]
+	for i := range strs {
+		strs[i] = strings.TrimSpace(strs[i])
+	}
+	if len(strs) == 0 || (len(strs) == 1 && strs[0] == "") {
+		delete(values, p.Name)
+		if p.Required {
+			return fmt.Errorf("is required")
+		}
+		if p.Default != "" {
+			values.Set(p.Name, p.Default)
+		}
+		return nil
+	}
+	if !p.Repeated && len(strs) > 1 {
+		return fmt.Errorf("must be given once")
+	}
+
+	for i, str := range strs {
+		if len(p.Enum) > 0 {
+			str = strings.ToLower(str)
+			if !contains(p.Enum, str) {
+				return fmt.Errorf("must be one of %s", strings.Join(p.Enum, ", "))
+			}
+			strs[i] = str
+		}
+		if err := p.checkType(str); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Param) checkType(str string) error {
+	var n float64
+	switch p.Type {
+	case TypeInteger:
+		i, err := strconv.Atoi(str)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		n = float64(i)
+	case TypeNumber:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		n = f
+	case TypeBoolean:
+		if _, err := strconv.ParseBool(str); err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		return nil
+	case TypeDate:
+		if _, err := time.Parse(DateLayout, str); err != nil {
+			return fmt.Errorf("must be a date as YYYY-MM-DD")
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	switch {
+	case p.Min != nil && p.Max != nil && (n < *p.Min || n > *p.Max):
+		return fmt.Errorf("must be between %g and %g", *p.Min, *p.Max)
+	case p.Min != nil && n < *p.Min:
+		return fmt.Errorf("must be at least %g", *p.Min)
+	case p.Max != nil && n > *p.Max:
+		return fmt.Errorf("must be at most %g", *p.Max)
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
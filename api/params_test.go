@@ -0,0 +1,163 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"googlescrapper/apierror"
+)
+
+var testParams = []Param{
+	{Name: "q", Type: TypeString, Required: true},
+	{Name: "engine", Type: TypeString, Enum: []string{"bing", "google"}, Default: "google"},
+	{Name: "max_results", Type: TypeInteger, Default: "10", Min: Limit(1), Max: Limit(100)},
+	{Name: "lat", Type: TypeNumber, Min: Limit(-90), Max: Limit(90)},
+	{Name: "render", Type: TypeBoolean},
+	{Name: "date_from", Type: TypeDate},
+	{Name: "exclude", Type: TypeString, Repeated: true},
+}
+
+func get(query string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/v1/search?"+query, nil)
+}
+
+func post(query, body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/v1/search?"+query, strings.NewReader(body))
+}
+
+// fieldErrors returns the messages of err by field
+func fieldErrors(err *apierror.Error) map[string]string {
+	if err == nil {
+		return nil
+	}
+	fields := make(map[string]string, len(err.Fields))
+	for _, field := range err.Fields {
+		fields[field.Field] = field.Message
+	}
+	return fields
+}
+
+func TestParseDefaultsAndTypes(t *testing.T) {
+	values, err := Parse(get("q=golang&lat=12.5&render=true&date_from=2024-02-29"), testParams)
+	if err != nil {
+		t.Fatalf("Parse: %v", fieldErrors(err))
+	}
+	if values.Get("engine") != "google" || values.Int("max_results") != 10 {
+		t.Errorf("defaults not filled in: %v", values.Values)
+	}
+	if lat, ok := values.Float("lat"); !ok || lat != 12.5 {
+		t.Errorf("Float(lat) = %v, %v", lat, ok)
+	}
+	if !values.Bool("render") {
+		t.Error("Bool(render) = false")
+	}
+	if _, ok := values.Float("missing"); ok {
+		t.Error("Float reported an absent parameter as given")
+	}
+}
+
+func TestParseNormalisesEnums(t *testing.T) {
+	values, err := Parse(get("q=golang&engine=%20BiNg%20"), testParams)
+	if err != nil {
+		t.Fatalf("Parse: %v", fieldErrors(err))
+	}
+	if got := values.Get("engine"); got != "bing" {
+		t.Errorf("engine = %q, want \"bing\"", got)
+	}
+}
+
+func TestParseRepeated(t *testing.T) {
+	values, err := Parse(get("q=golang&exclude=rust&exclude=java"), testParams)
+	if err != nil {
+		t.Fatalf("Parse: %v", fieldErrors(err))
+	}
+	if got := values.Values["exclude"]; !reflect.DeepEqual(got, []string{"rust", "java"}) {
+		t.Errorf("exclude = %v", got)
+	}
+
+	_, err = Parse(get("q=golang&q=go"), testParams)
+	if msg := fieldErrors(err)["q"]; msg != "must be given once" {
+		t.Errorf("repeated q: %q, want \"must be given once\"", msg)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse(get("engine=yahoo&max_results=0&lat=abc&render=maybe&date_from=2024-02-30&page=2&apikey=x"), testParams)
+	want := map[string]string{
+		"q":           "is required",
+		"engine":      "must be one of bing, google",
+		"max_results": "must be between 1 and 100",
+		"lat":         "must be a number",
+		"render":      "must be true or false",
+		"date_from":   "must be a date as YYYY-MM-DD",
+		"page":        "unknown parameter",
+		"apikey":      "unknown parameter",
+	}
+	if got := fieldErrors(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse errors = %v, want %v", got, want)
+	}
+	if err.Status != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", err.Status)
+	}
+
+	// Unknown parameters are reported in a stable order
+	var unknown []string
+	for _, field := range err.Fields {
+		if field.Message == "unknown parameter" {
+			unknown = append(unknown, field.Field)
+		}
+	}
+	if !reflect.DeepEqual(unknown, []string{"apikey", "page"}) {
+		t.Errorf("unknown parameters reported as %v", unknown)
+	}
+}
+
+func TestParseJSONBody(t *testing.T) {
+	body := `{"q": "from body", "max_results": 25, "render": true, "exclude": ["rust", "java"], "lat": null}`
+	values, err := Parse(post("q=from+query&engine=bing", body), testParams)
+	if err != nil {
+		t.Fatalf("Parse: %v", fieldErrors(err))
+	}
+	if got := values.Get("q"); got != "from body" {
+		t.Errorf("q = %q, want the body to override the query string", got)
+	}
+	if values.Get("engine") != "bing" || values.Int("max_results") != 25 || !values.Bool("render") {
+		t.Errorf("values = %v", values.Values)
+	}
+	if got := values.Values["exclude"]; !reflect.DeepEqual(got, []string{"rust", "java"}) {
+		t.Errorf("exclude = %v", got)
+	}
+	if values.Has("lat") {
+		t.Error("a null body value was kept")
+	}
+}
+
+func TestParseInvalidJSONBody(t *testing.T) {
+	tests := map[string]struct {
+		body  string
+		field string
+	}{
+		"not an object":   {`["q"]`, "body"},
+		"malformed":       {`{"q": `, "body"},
+		"nested object":   {`{"q": "golang", "engine": {"name": "bing"}}`, "engine"},
+		"nested array":    {`{"q": "golang", "exclude": [["rust"]]}`, "exclude"},
+		"unknown in body": {`{"q": "golang", "page": 2}`, "page"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(post("", tc.body), testParams)
+			if _, ok := fieldErrors(err)[tc.field]; !ok {
+				t.Errorf("Parse errors = %v, want one on %s", fieldErrors(err), tc.field)
+			}
+		})
+	}
+
+	// An empty body leaves the query string parameters
+	values, err := Parse(post("q=golang", ""), testParams)
+	if err != nil || values.Get("q") != "golang" {
+		t.Errorf("empty body: %v, %v", values.Values, fieldErrors(err))
+	}
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"googlescrapper/apierror"
+)
+
+// Meta describes how a /v1 response was produced
+type Meta struct {
+	RequestID  string `json:"request_id"`
+	RenderPath string `json:"render_path,omitempty"` // "http" or "browser", Google only
+}
+
+// Envelope is the body of every successful /v1 response
+type Envelope struct {
+	Data interface{} `json:"data"`
+	Meta Meta        `json:"meta"`
+}
+
+// Write sends data in the /v1 envelope. Struct fields of data are renamed to
+// snake_case, so responses that pass upstream JSON through (camelCase stock
+// data, untagged fields) share one naming convention. Map keys are data and
+// are left alone.
+func Write(w http.ResponseWriter, r *http.Request, data interface{}, meta Meta) {
+	meta.RequestID = apierror.RequestIDFrom(r.Context())
+
+	jsonData, err := json.MarshalIndent(Envelope{Data: snakeValue(reflect.ValueOf(data)), Meta: meta}, "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// SnakeCase converts a Go or JSON field name to snake_case: "TickerId",
+// "tickerId" and "ticker_id" all become "ticker_id", and "imageURL" becomes
+// "image_url"
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			acronymEnd := i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || acronymEnd {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// FieldName returns the snake_case key a struct field is written under, and
+// false when the field is not written
+func FieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		name = field.Name
+	}
+	return SnakeCase(name), true
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+)
+
+// snakeValue rebuilds v with its struct fields as ordered snake_case objects
+func snakeValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Implements(jsonMarshaler) {
+			return v.Interface()
+		}
+		return snakeValue(v.Elem())
+	}
+
+	// Values that encode themselves are kept as they are
+	if v.Type() == timeType || v.Type().Implements(jsonMarshaler) || v.Type().Implements(textMarshaler) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var obj object
+		obj.addFields(v)
+		return obj
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = snakeValue(iter.Value())
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = snakeValue(v.Index(i))
+		}
+		return items
+	}
+	return v.Interface()
+}
+
+// object is a JSON object that keeps the order of the struct fields it was
+// built from
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+// addFields appends the fields of struct v, flattening embedded structs the
+// way encoding/json does
+func (o *object) addFields(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				o.addFields(fv)
+				continue
+			}
+		}
+
+		name, ok := FieldName(field)
+		if !ok || !field.IsExported() {
+			continue
+		}
+		if strings.Contains(field.Tag.Get("json"), ",omitempty") && isEmpty(fv) {
+			continue
+		}
+		*o = append(*o, member{key: name, value: snakeValue(fv)})
+	}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// isEmpty matches encoding/json's definition of an empty value for omitempty
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"TickerId":   "ticker_id",
+		"tickerId":   "ticker_id",
+		"ticker_id":  "ticker_id",
+		"imageURL":   "image_url",
+		"URLPath":    "url_path",
+		"ID":         "id",
+		"Price52Low": "price52_low",
+		"name":       "name",
+	}
+	for in, want := range tests {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+type inner struct {
+	ThumbnailURL string
+}
+
+type embedded struct {
+	Source string `json:"source"`
+}
+
+type result struct {
+	embedded
+	Title     string            `json:"title"`
+	ImageURL  string            `json:"imageURL"`
+	TickerId  string            // Untagged
+	Skipped   string            `json:"-"`
+	Empty     string            `json:"empty,omitempty"`
+	Published time.Time         `json:"published"`
+	Inner     *inner            `json:"inner"`
+	Extra     map[string]string `json:"extra"`
+	hidden    string
+}
+
+func TestWriteEnvelope(t *testing.T) {
+	data := []result{{
+		embedded:  embedded{Source: "web"},
+		Title:     "Go",
+		ImageURL:  "https://go.dev/logo.png",
+		TickerId:  "GOOG",
+		Skipped:   "x",
+		Published: time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC),
+		Inner:     &inner{ThumbnailURL: "https://go.dev/thumb.png"},
+		Extra:     map[string]string{"keepCamel": "yes"},
+		hidden:    "x",
+	}}
+
+	w := httptest.NewRecorder()
+	Write(w, httptest.NewRequest(http.MethodGet, "/v1/search", nil), data, Meta{RenderPath: "http"})
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var body struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta map[string]interface{}   `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %s: %v", w.Body.Bytes(), err)
+	}
+	if body.Meta["render_path"] != "http" {
+		t.Errorf("meta = %v", body.Meta)
+	}
+	if len(body.Data) != 1 {
+		t.Fatalf("data = %v", body.Data)
+	}
+
+	got := body.Data[0]
+	want := map[string]interface{}{
+		"source":    "web",
+		"title":     "Go",
+		"image_url": "https://go.dev/logo.png",
+		"ticker_id": "GOOG",
+		"published": "2024-01-02T03:04:05Z",
+		"inner":     map[string]interface{}{"thumbnail_url": "https://go.dev/thumb.png"},
+		"extra":     map[string]interface{}{"keepCamel": "yes"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("data has keys %v, want %v", keys(got), keys(want))
+	}
+	for key, value := range want {
+		gotJSON, _ := json.Marshal(got[key])
+		wantJSON, _ := json.Marshal(value)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("data[%q] = %s, want %s", key, gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestWriteKeepsFieldOrder(t *testing.T) {
+	w := httptest.NewRecorder()
+	Write(w, httptest.NewRequest(http.MethodGet, "/v1/search", nil), struct {
+		Zebra string
+		Alpha string
+	}{"z", "a"}, Meta{})
+
+	var envelope map[string]json.RawMessage
+	json.Unmarshal(w.Body.Bytes(), &envelope)
+	var compact bytes.Buffer
+	json.Compact(&compact, envelope["data"])
+	if got := compact.String(); got != `{"zebra":"z","alpha":"a"}` {
+		t.Errorf("data = %s, want the struct's field order", got)
+	}
+}
+
+func keys(m map[string]interface{}) []string {
+	out := make([]string, 0, len(m))
+	for key := range m {
+		out = append(out, key)
+	}
+	return out
+}
@@ -0,0 +1,228 @@
+// Package apierror is the JSON error model shared by every HTTP handler.
+// Clients decide whether to retry from the code and the retryable flag
+// instead of parsing messages.
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"googlescrapper/fetch"
+)
+
+// Error codes
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeConflict            = "conflict"
+	CodeQuotaExceeded       = "quota_exceeded"
+	CodeRateLimited         = "upstream_rate_limited"
+	CodeCaptcha             = "upstream_captcha"
+	CodeBlocked             = "upstream_blocked"
+	CodeConsent             = "upstream_consent"
+	CodeThrottled           = "throttled"
+	CodeCircuitOpen         = "circuit_open"
+	CodeNoProxy             = "no_proxy"
+	CodeUpstreamError       = "upstream_error"
+	CodeUpstreamTimeout     = "upstream_timeout"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeCanceled            = "canceled"
+	CodeInternal            = "internal_error"
+)
+
+// statusClientClosedRequest is answered when the client went away; nobody
+// reads it, but it keeps access logs apart from server errors
+const statusClientClosedRequest = 499
+
+// Error is the body of every error response, wrapped as {"error": {...}}
+type Error struct {
+	Status     int           `json:"status"`
+	Code       string        `json:"code"`
+	Message    string        `json:"message"`
+	Upstream   string        `json:"upstream,omitempty"`
+	Retryable  bool          `json:"retryable"`
+	RequestID  string        `json:"request_id,omitempty"`
+	Fields     []FieldError  `json:"fields,omitempty"` // Parameters that failed validation
+	RetryAfter time.Duration `json:"-"`                // Sent as the Retry-After header
+}
+
+// FieldError describes a request parameter that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// New creates a non-retryable error
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Write sends e as a JSON response, with the request's ID and a Retry-After
+// header when one is set
+func Write(w http.ResponseWriter, r *http.Request, e *Error) {
+	e.RequestID = RequestIDFrom(r.Context())
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(e.RetryAfter.Round(time.Second).Seconds())))
+	}
+
+	jsonData, err := json.MarshalIndent(struct {
+		Error *Error `json:"error"`
+	}{e}, "", "    ")
+	if err != nil {
+		http.Error(w, e.Message, e.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	w.Write(jsonData)
+}
+
+// Invalid creates a 400 invalid_request error listing the parameters that
+// failed validation
+func Invalid(fields ...FieldError) *Error {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Field
+	}
+	e := New(http.StatusBadRequest, CodeInvalidRequest, "Invalid parameters: "+strings.Join(names, ", "))
+	e.Fields = fields
+	return e
+}
+
+// BadRequest writes a 400 invalid_request error
+func BadRequest(w http.ResponseWriter, r *http.Request, message string) {
+	Write(w, r, New(http.StatusBadRequest, CodeInvalidRequest, message))
+}
+
+// NotFound writes a 404 not_found error
+func NotFound(w http.ResponseWriter, r *http.Request, message string) {
+	Write(w, r, New(http.StatusNotFound, CodeNotFound, message))
+}
+
+// Internal writes a 500 internal_error with a generic message. The cause is
+// logged with the request ID rather than sent to the client.
+func Internal(w http.ResponseWriter, r *http.Request, message string, err error) {
+	if err != nil {
+		log.Printf("[%s] %s: %v", RequestIDFrom(r.Context()), message, err)
+	}
+	Write(w, r, New(http.StatusInternalServerError, CodeInternal, message))
+}
+
+// Handle writes the response for an error returned by a scraper or fetcher.
+// Blocks, rate limits, timeouts and unreachable upstreams get their own codes;
+// anything else is an internal error described by message.
+func Handle(w http.ResponseWriter, r *http.Request, err error, message string) {
+	e := FromError(err)
+	if e == nil {
+		Internal(w, r, message, err)
+		return
+	}
+	log.Printf("[%s] %s: %v", RequestIDFrom(r.Context()), message, err)
+	Write(w, r, e)
+}
+
+// FromError maps upstream and transport errors to an Error, or returns nil
+// when err isn't one of them. An *Error in err's chain is returned as is.
+func FromError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	if status, retryAfter, ok := fetch.ErrorStatus(err); ok {
+		e := &Error{
+			Status:     status,
+			Code:       upstreamCode(err),
+			Message:    err.Error(),
+			Retryable:  true,
+			RetryAfter: retryAfter,
+		}
+		var upstreamErr *fetch.UpstreamError
+		if errors.As(err, &upstreamErr) {
+			e.Upstream = upstreamErr.Upstream
+		}
+		return e
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &Error{Status: statusClientClosedRequest, Code: CodeCanceled, Message: "The request was canceled"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Status: http.StatusGatewayTimeout, Code: CodeUpstreamTimeout, Message: "The upstream did not answer in time", Retryable: true}
+	}
+
+	var statusErr *fetch.StatusError
+	if errors.As(err, &statusErr) {
+		return &Error{
+			Status:     http.StatusBadGateway,
+			Code:       CodeUpstreamError,
+			Message:    "The upstream answered with status " + strconv.Itoa(statusErr.StatusCode),
+			Retryable:  statusErr.StatusCode >= 500,
+			RetryAfter: statusErr.RetryAfter,
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		code, status := CodeUpstreamUnavailable, http.StatusBadGateway
+		if netErr.Timeout() {
+			code, status = CodeUpstreamTimeout, http.StatusGatewayTimeout
+		}
+		return &Error{Status: status, Code: code, Message: "The upstream could not be reached", Retryable: true}
+	}
+
+	return nil
+}
+
+// upstreamCode returns the code for an error fetch.ErrorStatus recognised
+func upstreamCode(err error) string {
+	switch {
+	case errors.Is(err, fetch.ErrRateLimited):
+		return CodeRateLimited
+	case errors.Is(err, fetch.ErrCaptcha):
+		return CodeCaptcha
+	case errors.Is(err, fetch.ErrConsent):
+		return CodeConsent
+	case errors.Is(err, fetch.ErrThrottled):
+		return CodeThrottled
+	case errors.Is(err, fetch.ErrCircuitOpen):
+		return CodeCircuitOpen
+	case errors.Is(err, fetch.ErrNoProxy):
+		return CodeNoProxy
+	}
+	return CodeBlocked
+}
+
+// NotFoundHandler answers requests that match no route
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NotFound(w, r, "No endpoint matches "+r.URL.Path)
+	})
+}
+
+// MethodNotAllowedHandler answers requests whose route exists for other
+// methods only
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method "+r.Method+" is not allowed"))
+	})
+}
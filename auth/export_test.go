@@ -0,0 +1,4 @@
+package auth
+
+// Classify exposes classify to the tests outside the package
+var Classify = classify
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"net/http"
+
+	"googlescrapper/api"
+	"googlescrapper/apierror"
+)
+
+// V1UsageHandler handles GET /v1/usage, the caller's usage and quotas
+func V1UsageHandler(w http.ResponseWriter, r *http.Request) {
+	if _, apiErr := api.Parse(r, nil); apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	key, ok := KeyFrom(r.Context())
+	if !ok {
+		apierror.NotFound(w, r, "API key authentication is disabled, there is no usage to report")
+		return
+	}
+	api.Write(w, r, Report(key), api.Meta{})
+}
@@ -0,0 +1,112 @@
+// Package auth authenticates requests by API key and meters them against
+// per-key quotas. Requests are counted per endpoint class in a per-minute and
+// a per-day window; counters are kept in Redis so every instance shares them,
+// with an in-process fallback while Redis is unreachable.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Class groups endpoints that share a quota
+type Class string
+
+const (
+	ClassSearch Class = "search" // Google and Bing search
+	ClassScrape Class = "scrape" // Pages rendered or scraped in the browser
+	ClassStock  Class = "stock"  // Stock data
+)
+
+// Classes lists every metered class
+var Classes = []Class{ClassSearch, ClassScrape, ClassStock}
+
+// Quota is the number of requests a key may make in a class
+type Quota struct {
+	PerMinute int `json:"per_minute"`
+	PerDay    int `json:"per_day"`
+}
+
+// DefaultQuotas apply to keys that don't set their own
+var DefaultQuotas = map[Class]Quota{
+	ClassSearch: {PerMinute: 30, PerDay: 1000},
+	ClassScrape: {PerMinute: 10, PerDay: 200},
+	ClassStock:  {PerMinute: 60, PerDay: 5000},
+}
+
+// QuotaLimits are the limits a key sets for a class. A limit left out falls
+// back to DefaultQuotas, and a limit of 0 blocks the class.
+type QuotaLimits struct {
+	PerMinute *int `json:"per_minute,omitempty"`
+	PerDay    *int `json:"per_day,omitempty"`
+}
+
+// Key is an API key. Only the SHA-256 of the key is stored, so the key file
+// or Redis hash doesn't hold usable secrets.
+type Key struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name,omitempty"`
+	KeySHA256 string                `json:"key_sha256"` // Hex SHA-256 of the key clients send
+	Quotas    map[Class]QuotaLimits `json:"quotas,omitempty"`
+	Disabled  bool                  `json:"disabled,omitempty"`
+}
+
+// Quota returns the key's quota for a class
+func (k Key) Quota(class Class) Quota {
+	quota, limits := DefaultQuotas[class], k.Quotas[class]
+	if limits.PerMinute != nil {
+		quota.PerMinute = *limits.PerMinute
+	}
+	if limits.PerDay != nil {
+		quota.PerDay = *limits.PerDay
+	}
+	return quota
+}
+
+// Blocked reports whether the quota allows no requests at all
+func (q Quota) Blocked() bool {
+	return q.PerMinute == 0 || q.PerDay == 0
+}
+
+// validate checks a key loaded from the backend
+func (k Key) validate() error {
+	if k.ID == "" {
+		return fmt.Errorf("key without id")
+	}
+	if hash, err := hex.DecodeString(k.KeySHA256); err != nil || len(hash) != sha256.Size {
+		return fmt.Errorf("key %q: key_sha256 must be a hex SHA-256", k.ID)
+	}
+	for class, limits := range k.Quotas {
+		if _, ok := DefaultQuotas[class]; !ok {
+			return fmt.Errorf("key %q: unknown quota class %q", k.ID, class)
+		}
+		if (limits.PerMinute != nil && *limits.PerMinute < 0) || (limits.PerDay != nil && *limits.PerDay < 0) {
+			return fmt.Errorf("key %q: %s quota must not be negative", k.ID, class)
+		}
+	}
+	return nil
+}
+
+// hashKey returns the hex SHA-256 a key is stored under
+func hashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateKey creates a key with a random secret. The secret is returned to
+// hand to the client; the key only holds its hash.
+func GenerateKey(id, name string) (string, Key, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", Key{}, fmt.Errorf("failed to generate API key: %v", err)
+	}
+	secret := hex.EncodeToString(random)
+
+	key := Key{ID: id, Name: name, KeySHA256: hashKey(secret)}
+	if err := key.validate(); err != nil {
+		return "", Key{}, err
+	}
+	return secret, key, nil
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"googlescrapper/apierror"
+)
+
+// APIKeyHeader carries the API key of a request
+const APIKeyHeader = "X-API-Key"
+
+// Quota headers, set on every metered response for the window closest to
+// running out
+const (
+	LimitHeader     = "X-RateLimit-Limit"
+	RemainingHeader = "X-RateLimit-Remaining"
+	ResetHeader     = "X-RateLimit-Reset" // Unix time the window resets at
+)
+
+type contextKey struct{}
+
+// KeyFrom returns the API key a request was authenticated with
+func KeyFrom(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(Key)
+	return key, ok
+}
+
+// Disabled reports whether AUTH_DISABLED turns authentication off, for
+// local development
+func Disabled() bool {
+	disabled, _ := strconv.ParseBool(os.Getenv("AUTH_DISABLED"))
+	return disabled
+}
+
+// Require is middleware that only lets requests with a valid API key through
+// and meters them against the key's quotas. The documentation and the admin
+// endpoints, which have their own token, are public.
+func Require(next http.Handler) http.Handler {
+	if Disabled() {
+		log.Printf("API key authentication is disabled by AUTH_DISABLED")
+	} else if Default.Len() == 0 {
+		log.Printf("WARNING: no API keys are loaded, every request will be rejected. " +
+			"Add keys with -generate-key, or set AUTH_DISABLED=true for local development")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Disabled() || public(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		secret := r.Header.Get(APIKeyHeader)
+		if secret == "" {
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing API key, send it in the "+APIKeyHeader+" header"))
+			return
+		}
+		key, ok := Default.Lookup(secret)
+		if !ok {
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid API key"))
+			return
+		}
+		if key.Disabled {
+			apierror.Write(w, r, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "API key is disabled"))
+			return
+		}
+
+		if class, metered := classify(r.URL.Path); metered {
+			if key.Quota(class).Blocked() {
+				apierror.Write(w, r, apierror.New(http.StatusForbidden, apierror.CodeForbidden, fmt.Sprintf("API key has no %s quota", class)))
+				return
+			}
+
+			usage, allowed := Take(key, class)
+			window, period := closest(usage)
+			w.Header().Set(LimitHeader, strconv.Itoa(window.Limit))
+			w.Header().Set(RemainingHeader, strconv.Itoa(window.Remaining))
+			w.Header().Set(ResetHeader, strconv.FormatInt(window.ResetsAt.Unix(), 10))
+			if !allowed {
+				apierror.Write(w, r, &apierror.Error{
+					Status: http.StatusTooManyRequests,
+					Code:   apierror.CodeQuotaExceeded,
+					Message: fmt.Sprintf("%s %s quota of %d requests exceeded, resets at %s",
+						period, class, window.Limit, window.ResetsAt.Format(time.RFC3339)),
+					Retryable:  true,
+					RetryAfter: time.Until(window.ResetsAt),
+				})
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, key)))
+	})
+}
+
+// closest returns the window with the fewest requests left, the day window
+// when both are exhausted since it resets last
+func closest(usage ClassUsage) (Window, string) {
+	if usage.Day.Remaining <= usage.Minute.Remaining {
+		return usage.Day, "Daily"
+	}
+	return usage.Minute, "Per-minute"
+}
+
+// public reports whether a path is served without an API key
+func public(path string) bool {
+	return path == "/openapi.json" || path == "/docs" || strings.HasPrefix(path, "/admin/")
+}
+
+// classify returns the quota class of a path, and false for paths that only
+// need a key. The paths mirror the routes in main.go; routes_test.go checks
+// them against the OpenAPI routes.
+func classify(path string) (Class, bool) {
+	switch {
+	case strings.HasPrefix(path, "/v1/stock/"), strings.HasPrefix(path, "/stock/"), strings.HasPrefix(path, "/scrape/"):
+		return ClassStock, true
+	}
+
+	switch path {
+	case "/v1/html", "/v1/scrape", "/v1/clean-html", "/html", "/scrape-url", "/clean-html":
+		return ClassScrape, true
+	case "/v1/search", "/v1/web", "/v1/images", "/v1/shopping", "/v1/news", "/v1/local", "/v1/finance":
+		return ClassSearch, true
+	}
+
+	for _, prefix := range []string{"/v1/bing/", "/search/", "/finance/", "/image/", "/shopping/", "/news/", "/local/", "/bing/"} {
+		if strings.HasPrefix(path, prefix) {
+			return ClassSearch, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,37 @@
+package auth_test
+
+import (
+	"regexp"
+	"testing"
+
+	"googlescrapper/auth"
+	"googlescrapper/openapi"
+)
+
+// tagClasses is the quota class of the routes under each OpenAPI tag. Routes
+// under other tags aren't metered.
+var tagClasses = map[string]auth.Class{
+	"Search":   auth.ClassSearch,
+	"Bing":     auth.ClassSearch,
+	"Scraping": auth.ClassScrape,
+	"Stock":    auth.ClassStock,
+}
+
+var pathVariable = regexp.MustCompile(`\{[^}]+\}`)
+
+// TestClassifyRoutes checks the quota class of every route main.go registers,
+// which openapi.Verify keeps in step with openapi.Routes
+func TestClassifyRoutes(t *testing.T) {
+	for _, route := range openapi.Routes {
+		if route.Admin || route.Tag == "Documentation" {
+			continue
+		}
+		path := pathVariable.ReplaceAllString(route.Path, "x")
+		want := tagClasses[route.Tag]
+
+		class, metered := auth.Classify(path)
+		if class != want || metered != (want != "") {
+			t.Errorf("classify(%q) = %q, %v, want %q", path, class, metered, want)
+		}
+	}
+}
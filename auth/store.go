@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"googlescrapper/cache"
+)
+
+// reloadInterval is how often the store re-reads its backend, so added or
+// revoked keys take effect without a restart
+const reloadInterval = time.Minute
+
+// loadTimeout bounds a backend load so a hung Redis can't hold up a reload
+const loadTimeout = 5 * time.Second
+
+// Backend loads the API keys
+type Backend interface {
+	Load(ctx context.Context) ([]Key, error)
+}
+
+// FileBackend reads keys from a JSON array in a local file
+type FileBackend struct {
+	Path string
+}
+
+func (b FileBackend) Load(context.Context) ([]Key, error) {
+	data, err := os.ReadFile(b.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file: %v", err)
+	}
+
+	var keys []Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid API key file %s: %v", b.Path, err)
+	}
+	return keys, nil
+}
+
+// redisKeysKey is the hash holding one JSON-encoded key per ID
+const redisKeysKey = "auth:keys"
+
+// RedisBackend reads keys from a Redis hash on the shared cache client
+type RedisBackend struct{}
+
+func (RedisBackend) Load(ctx context.Context) ([]Key, error) {
+	values, err := cache.RedisClient.HGetAll(ctx, redisKeysKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API keys from Redis: %v", err)
+	}
+
+	keys := make([]Key, 0, len(values))
+	for id, value := range values {
+		var key Key
+		if err := json.Unmarshal([]byte(value), &key); err != nil {
+			return nil, fmt.Errorf("invalid API key %s in Redis: %v", id, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Store resolves API keys, keeping the keys of its backend in memory
+type Store struct {
+	backend Backend
+
+	mu       sync.Mutex
+	byHash   map[string]Key // Replaced, never modified, by a reload
+	loadedAt time.Time
+	loading  chan struct{} // Closed when the reload in progress ends, nil when none is
+}
+
+// NewStore creates a store on top of the given backend. Keys are loaded
+// lazily on first use.
+func NewStore(backend Backend) *Store {
+	return &Store{backend: backend, byHash: make(map[string]Key)}
+}
+
+// Default is the store used by Require. API_KEY_STORE=redis keeps the keys in
+// Redis; otherwise they're read from API_KEYS_FILE (api_keys.json by default).
+var Default = NewStore(defaultBackend())
+
+func defaultBackend() Backend {
+	if os.Getenv("API_KEY_STORE") == "redis" {
+		return RedisBackend{}
+	}
+
+	path := os.Getenv("API_KEYS_FILE")
+	if path == "" {
+		path = "api_keys.json"
+	}
+	return FileBackend{Path: path}
+}
+
+// Len returns the number of usable keys
+func (s *Store) Len() int {
+	return len(s.keys())
+}
+
+// Lookup returns the key matching the secret a client sent
+func (s *Store) Lookup(secret string) (Key, bool) {
+	key, ok := s.keys()[hashKey(secret)]
+	return key, ok
+}
+
+// keys returns the keys by hash. Stale keys are reloaded in the background
+// while requests keep using them; only the first load is waited for.
+func (s *Store) keys() map[string]Key {
+	s.mu.Lock()
+	byHash, loading := s.byHash, s.loading
+	first := s.loadedAt.IsZero()
+	if loading == nil && time.Since(s.loadedAt) >= reloadInterval {
+		loading = make(chan struct{})
+		s.loading = loading
+		go s.load(loading)
+	}
+	s.mu.Unlock()
+
+	if first && loading != nil {
+		<-loading
+		s.mu.Lock()
+		byHash = s.byHash
+		s.mu.Unlock()
+	}
+	return byHash
+}
+
+// load refreshes the keys from the backend and closes done. Invalid keys are
+// skipped, and the previous keys are kept when the backend fails.
+func (s *Store) load(done chan struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	keys, err := s.backend.Load(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Now()
+	s.loading = nil
+	if err != nil {
+		log.Printf("Failed to load API keys: %v", err)
+		return
+	}
+
+	byHash := make(map[string]Key, len(keys))
+	for _, key := range keys {
+		if err := key.validate(); err != nil {
+			log.Printf("Skipping API key: %v", err)
+			continue
+		}
+		byHash[strings.ToLower(key.KeySHA256)] = key
+	}
+	if len(byHash) == 0 && len(s.byHash) > 0 {
+		log.Printf("WARNING: the API key store no longer has any usable keys, every request will be rejected")
+	}
+	s.byHash = byHash
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend serves keys from memory. While release is set, loads wait on it.
+type fakeBackend struct {
+	mu      sync.Mutex
+	keys    []Key
+	err     error
+	loads   int
+	release chan struct{}
+}
+
+func (b *fakeBackend) Load(ctx context.Context) ([]Key, error) {
+	b.mu.Lock()
+	release := b.release
+	b.loads++
+	b.mu.Unlock()
+
+	if release != nil {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.keys, b.err
+}
+
+func (b *fakeBackend) set(keys []Key, err error, release chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.keys, b.err, b.release = keys, err, release
+}
+
+func testKey(id, secret string) Key {
+	return Key{ID: id, KeySHA256: hashKey(secret)}
+}
+
+// waitReload waits for the reload in progress, if any, to end
+func waitReload(s *Store) {
+	s.mu.Lock()
+	loading := s.loading
+	s.mu.Unlock()
+	if loading != nil {
+		<-loading
+	}
+}
+
+func TestStoreLookup(t *testing.T) {
+	backend := &fakeBackend{keys: []Key{testKey("acme", "secret"), {ID: "broken", KeySHA256: "nothex"}}}
+	s := NewStore(backend)
+
+	// The first lookup waits for the keys, invalid ones are skipped
+	if key, ok := s.Lookup("secret"); !ok || key.ID != "acme" {
+		t.Errorf("Lookup(secret) = %+v, %v", key, ok)
+	}
+	if _, ok := s.Lookup("other"); ok {
+		t.Error("Lookup found a key for an unknown secret")
+	}
+	if s.Len() != 1 {
+		t.Errorf("Len = %d, want 1", s.Len())
+	}
+	if backend.loads != 1 {
+		t.Errorf("backend loaded %d times, want once", backend.loads)
+	}
+}
+
+func TestStoreReloadDoesNotBlock(t *testing.T) {
+	backend := &fakeBackend{keys: []Key{testKey("acme", "secret")}}
+	s := NewStore(backend)
+	s.Lookup("secret")
+
+	// A stale store keeps answering from the old keys while a slow reload runs
+	release := make(chan struct{})
+	backend.set([]Key{testKey("other", "new secret")}, nil, release)
+	s.mu.Lock()
+	s.loadedAt = time.Now().Add(-reloadInterval)
+	s.mu.Unlock()
+
+	done := make(chan bool)
+	go func() {
+		_, ok := s.Lookup("secret")
+		done <- ok
+	}()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Error("old key not found during the reload")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Lookup blocked on the reload")
+	}
+
+	close(release)
+	waitReload(s)
+	if _, ok := s.Lookup("new secret"); !ok {
+		t.Error("reloaded key not found")
+	}
+	if _, ok := s.Lookup("secret"); ok {
+		t.Error("removed key still found")
+	}
+}
+
+func TestStoreKeepsKeysWhenLoadFails(t *testing.T) {
+	backend := &fakeBackend{keys: []Key{testKey("acme", "secret")}}
+	s := NewStore(backend)
+	s.Lookup("secret")
+
+	backend.set(nil, errors.New("redis down"), nil)
+	s.mu.Lock()
+	s.loadedAt = time.Now().Add(-reloadInterval)
+	s.mu.Unlock()
+	s.Lookup("secret")
+	waitReload(s)
+
+	if _, ok := s.Lookup("secret"); !ok {
+		t.Error("keys dropped after a failed reload")
+	}
+}
+
+func TestKeyQuota(t *testing.T) {
+	n := func(v int) *int { return &v }
+	key := Key{Quotas: map[Class]QuotaLimits{
+		ClassSearch: {PerMinute: n(5)},
+		ClassStock:  {PerMinute: n(0), PerDay: n(0)},
+	}}
+
+	if got, want := key.Quota(ClassSearch), (Quota{PerMinute: 5, PerDay: DefaultQuotas[ClassSearch].PerDay}); got != want {
+		t.Errorf("search quota = %+v, want %+v", got, want)
+	}
+	if got := key.Quota(ClassScrape); got != DefaultQuotas[ClassScrape] {
+		t.Errorf("scrape quota = %+v, want the default", got)
+	}
+	if !key.Quota(ClassStock).Blocked() || key.Quota(ClassSearch).Blocked() {
+		t.Error("only the stock class should be blocked")
+	}
+
+	key.ID, key.KeySHA256 = "acme", hashKey("secret")
+	key.Quotas[ClassScrape] = QuotaLimits{PerDay: n(-1)}
+	if err := key.validate(); err == nil {
+		t.Error("validate accepted a negative quota")
+	}
+}
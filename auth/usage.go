@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"googlescrapper/cache"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Window is the usage of a key in one quota window
+type Window struct {
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// ClassUsage is the usage of a key in a class
+type ClassUsage struct {
+	Class  Class  `json:"class"`
+	Minute Window `json:"minute"`
+	Day    Window `json:"day"`
+}
+
+// Usage is the usage of a key across every class
+type Usage struct {
+	KeyID   string       `json:"key_id"`
+	Name    string       `json:"name,omitempty"`
+	Classes []ClassUsage `json:"classes"`
+}
+
+// windows are the counter keys of a key's class at a point in time. Minutes
+// and days are fixed UTC windows.
+type windows struct {
+	minuteKey, dayKey     string
+	minuteReset, dayReset time.Time
+}
+
+func windowsAt(key Key, class Class, now time.Time) windows {
+	now = now.UTC()
+	minute := now.Truncate(time.Minute)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	prefix := "auth:usage:" + key.ID + ":" + string(class)
+	return windows{
+		minuteKey:   prefix + ":minute:" + minute.Format("200601021504"),
+		dayKey:      prefix + ":day:" + day.Format("20060102"),
+		minuteReset: minute.Add(time.Minute),
+		dayReset:    day.AddDate(0, 0, 1),
+	}
+}
+
+func (w windows) usage(class Class, quota Quota, minute, day int) ClassUsage {
+	return ClassUsage{
+		Class:  class,
+		Minute: Window{Limit: quota.PerMinute, Used: minute, Remaining: max(quota.PerMinute-minute, 0), ResetsAt: w.minuteReset},
+		Day:    Window{Limit: quota.PerDay, Used: day, Remaining: max(quota.PerDay-day, 0), ResetsAt: w.dayReset},
+	}
+}
+
+// Take counts a request of the key in the class unless it would exceed a
+// quota. It reports the usage after the request and whether it was allowed;
+// rejected requests aren't counted.
+func Take(key Key, class Class) (ClassUsage, bool) {
+	now := time.Now()
+	w := windowsAt(key, class, now)
+	quota := key.Quota(class)
+
+	var minute, day int
+	var allowed bool
+	if redisAvailable() {
+		var err error
+		minute, day, allowed, err = shared.take(w, quota, now)
+		if fallback(err) {
+			minute, day, allowed, _ = local.take(w, quota, now)
+		}
+	} else {
+		minute, day, allowed, _ = local.take(w, quota, now)
+	}
+	return w.usage(class, quota, minute, day), allowed
+}
+
+// Report returns the key's usage in every class
+func Report(key Key) Usage {
+	now := time.Now()
+	usage := Usage{KeyID: key.ID, Name: key.Name}
+	for _, class := range Classes {
+		w := windowsAt(key, class, now)
+
+		var minute, day int
+		if redisAvailable() {
+			var err error
+			minute, day, err = shared.get(w)
+			if fallback(err) {
+				minute, day, _ = local.get(w)
+			}
+		} else {
+			minute, day, _ = local.get(w)
+		}
+		usage.Classes = append(usage.Classes, w.usage(class, key.Quota(class), minute, day))
+	}
+	return usage
+}
+
+// counterStore holds the usage counters
+type counterStore interface {
+	take(w windows, quota Quota, now time.Time) (minute, day int, allowed bool, err error)
+	get(w windows) (minute, day int, err error)
+}
+
+// redisRetryInterval is how long the local fallback is used after a Redis
+// error before Redis is tried again
+const redisRetryInterval = 10 * time.Second
+
+var (
+	shared counterStore = redisCounters{}
+	local               = newLocalCounters()
+
+	redisDownUntil time.Time
+	redisDownMu    sync.Mutex
+)
+
+// redisAvailable reports whether Redis should be tried for the next call
+func redisAvailable() bool {
+	redisDownMu.Lock()
+	defer redisDownMu.Unlock()
+	return time.Now().After(redisDownUntil)
+}
+
+// fallback records the outcome of a Redis call and reports whether to use the
+// local counters instead. Switches to and from the fallback are logged once.
+func fallback(err error) bool {
+	redisDownMu.Lock()
+	defer redisDownMu.Unlock()
+
+	wasDown := !redisDownUntil.IsZero()
+	switch {
+	case err != nil:
+		if !wasDown {
+			log.Printf("Usage counters falling back to local state, Redis unavailable: %v", err)
+		}
+		redisDownUntil = time.Now().Add(redisRetryInterval)
+		return true
+	case wasDown:
+		log.Printf("Usage counters using Redis again")
+		redisDownUntil = time.Time{}
+	}
+	return false
+}
+
+// redisTimeout bounds every counter call so a slow Redis can't stall requests
+const redisTimeout = 200 * time.Millisecond
+
+// takeScript increments the minute and day counters unless either is at its
+// limit. It returns both counts and 1 when the request was counted.
+// KEYS: minute, day. ARGV: minute limit, day limit, minute ttl ms, day ttl ms.
+var takeScript = redis.NewScript(`
+local minute = tonumber(redis.call('GET', KEYS[1]) or '0')
+local day = tonumber(redis.call('GET', KEYS[2]) or '0')
+if minute >= tonumber(ARGV[1]) or day >= tonumber(ARGV[2]) then
+	return {minute, day, 0}
+end
+
+minute = redis.call('INCR', KEYS[1])
+if minute == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+day = redis.call('INCR', KEYS[2])
+if day == 1 then
+	redis.call('PEXPIRE', KEYS[2], ARGV[4])
+end
+return {minute, day, 1}
+`)
+
+// counterGrace keeps counters a little past their window so clocks that
+// disagree slightly between instances don't reset them early
+const counterGrace = time.Minute
+
+// redisCounters keeps the counters in Redis on the shared cache client
+type redisCounters struct{}
+
+func (redisCounters) take(w windows, quota Quota, now time.Time) (int, int, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	minuteTTL := w.minuteReset.Sub(now) + counterGrace
+	dayTTL := w.dayReset.Sub(now) + counterGrace
+	result, err := takeScript.Run(ctx, cache.RedisClient, []string{w.minuteKey, w.dayKey},
+		quota.PerMinute, quota.PerDay, minuteTTL.Milliseconds(), dayTTL.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return int(result[0]), int(result[1]), result[2] == 1, nil
+}
+
+func (redisCounters) get(w windows) (int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	values, err := cache.RedisClient.MGet(ctx, w.minuteKey, w.dayKey).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	counts := make([]int, len(values))
+	for i, value := range values {
+		// Missing counters are nil
+		if s, ok := value.(string); ok {
+			counts[i], _ = strconv.Atoi(s)
+		}
+	}
+	return counts[0], counts[1], nil
+}
+
+// expireInterval is how often the local counters are swept. Counter keys name
+// their window, so a counter past its window is never read again and only
+// needs dropping to free memory.
+const expireInterval = time.Minute
+
+// localCounters is the in-process fallback used while Redis is unreachable.
+// It follows the same rules as the Redis script, but per instance.
+type localCounters struct {
+	mu         sync.Mutex
+	counts     map[string]int
+	expireAt   map[string]time.Time
+	nextExpire time.Time
+}
+
+func newLocalCounters() *localCounters {
+	return &localCounters{counts: make(map[string]int), expireAt: make(map[string]time.Time)}
+}
+
+func (c *localCounters) take(w windows, quota Quota, now time.Time) (int, int, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(now)
+
+	minute, day := c.counts[w.minuteKey], c.counts[w.dayKey]
+	if minute >= quota.PerMinute || day >= quota.PerDay {
+		return minute, day, false, nil
+	}
+
+	c.counts[w.minuteKey]++
+	c.counts[w.dayKey]++
+	c.expireAt[w.minuteKey] = w.minuteReset.Add(counterGrace)
+	c.expireAt[w.dayKey] = w.dayReset.Add(counterGrace)
+	return minute + 1, day + 1, true, nil
+}
+
+func (c *localCounters) get(w windows) (int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(time.Now())
+
+	return c.counts[w.minuteKey], c.counts[w.dayKey], nil
+}
+
+// expire drops counters past their window, at most once per expireInterval.
+// Must be called with c.mu held.
+func (c *localCounters) expire(now time.Time) {
+	if now.Before(c.nextExpire) {
+		return
+	}
+	c.nextExpire = now.Add(expireInterval)
+
+	for key, at := range c.expireAt {
+		if now.After(at) {
+			delete(c.counts, key)
+			delete(c.expireAt, key)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalCountersQuota(t *testing.T) {
+	c := newLocalCounters()
+	key := Key{ID: "acme"}
+	quota := Quota{PerMinute: 2, PerDay: 3}
+	now := time.Date(2024, time.March, 15, 12, 30, 10, 0, time.UTC)
+
+	take := func(now time.Time) (int, int, bool) {
+		minute, day, allowed, _ := c.take(windowsAt(key, ClassSearch, now), quota, now)
+		return minute, day, allowed
+	}
+
+	for i := 1; i <= 2; i++ {
+		if minute, day, allowed := take(now); !allowed || minute != i || day != i {
+			t.Fatalf("request %d: minute %d, day %d, allowed %v", i, minute, day, allowed)
+		}
+	}
+	if minute, day, allowed := take(now); allowed || minute != 2 || day != 2 {
+		t.Errorf("over the minute quota: minute %d, day %d, allowed %v", minute, day, allowed)
+	}
+
+	// The next minute starts a new window, the day keeps counting
+	now = now.Add(time.Minute)
+	if minute, day, allowed := take(now); !allowed || minute != 1 || day != 3 {
+		t.Errorf("next minute: minute %d, day %d, allowed %v", minute, day, allowed)
+	}
+	if _, day, allowed := take(now); allowed || day != 3 {
+		t.Errorf("over the day quota: day %d, allowed %v", day, allowed)
+	}
+
+	// Other classes and keys have their own counters
+	if minute, _, allowed, _ := c.take(windowsAt(key, ClassStock, now), quota, now); !allowed || minute != 1 {
+		t.Errorf("stock class: minute %d, allowed %v", minute, allowed)
+	}
+	other := Key{ID: "other"}
+	if minute, _, allowed, _ := c.take(windowsAt(other, ClassSearch, now), quota, now); !allowed || minute != 1 {
+		t.Errorf("other key: minute %d, allowed %v", minute, allowed)
+	}
+}
+
+func TestLocalCountersExpire(t *testing.T) {
+	c := newLocalCounters()
+	now := time.Date(2024, time.March, 15, 12, 30, 30, 0, time.UTC)
+	w := windowsAt(Key{ID: "acme"}, ClassSearch, now)
+	c.take(w, Quota{PerMinute: 10, PerDay: 10}, now)
+
+	// Counters past their window stay until the next sweep is due
+	for key := range c.expireAt {
+		c.expireAt[key] = now.Add(-time.Second)
+	}
+	c.expire(now.Add(expireInterval / 2))
+	if len(c.counts) != 2 {
+		t.Errorf("counters swept early: %v", c.counts)
+	}
+
+	c.expire(now.Add(expireInterval))
+	if len(c.counts) != 0 || len(c.expireAt) != 0 {
+		t.Errorf("counters not expired: %v", c.counts)
+	}
+
+	// Counters still in their window are kept
+	c.take(w, Quota{PerMinute: 10, PerDay: 10}, now)
+	c.expire(now.Add(3 * expireInterval))
+	if c.counts[w.dayKey] != 1 {
+		t.Errorf("day counter expired within its window: %v", c.counts)
+	}
+}
+
+func TestWindowsAt(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 23, 59, 30, 0, time.FixedZone("IST", 5*3600+1800))
+	w := windowsAt(Key{ID: "acme"}, ClassStock, now)
+
+	if w.minuteKey != "auth:usage:acme:stock:minute:202403151829" || w.dayKey != "auth:usage:acme:stock:day:20240315" {
+		t.Errorf("windowsAt keys = %s, %s", w.minuteKey, w.dayKey)
+	}
+	if want := time.Date(2024, time.March, 15, 18, 30, 0, 0, time.UTC); !w.minuteReset.Equal(want) {
+		t.Errorf("minute resets at %v, want %v", w.minuteReset, want)
+	}
+	if want := time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC); !w.dayReset.Equal(want) {
+		t.Errorf("day resets at %v, want %v", w.dayReset, want)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := map[string]Class{
+		"/v1/search":                        ClassSearch,
+		"/v1/web":                           ClassSearch,
+		"/v1/images":                        ClassSearch,
+		"/v1/shopping":                      ClassSearch,
+		"/v1/news":                          ClassSearch,
+		"/v1/local":                         ClassSearch,
+		"/v1/finance":                       ClassSearch,
+		"/v1/bing/search":                   ClassSearch,
+		"/v1/bing/images":                   ClassSearch,
+		"/v1/bing/news":                     ClassSearch,
+		"/search/golang/us/10/0/0/false":    ClassSearch,
+		"/finance/GOOG:NASDAQ":              ClassSearch,
+		"/image/golang":                     ClassSearch,
+		"/shopping/golang":                  ClassSearch,
+		"/news/golang":                      ClassSearch,
+		"/local/golang":                     ClassSearch,
+		"/bing/golang":                      ClassSearch,
+		"/bing/images/golang":               ClassSearch,
+		"/bing/news/golang":                 ClassSearch,
+		"/v1/html":                          ClassScrape,
+		"/v1/scrape":                        ClassScrape,
+		"/v1/clean-html":                    ClassScrape,
+		"/html":                             ClassScrape,
+		"/scrape-url":                       ClassScrape,
+		"/clean-html":                       ClassScrape,
+		"/v1/stock/chart":                   ClassStock,
+		"/v1/stock/overview":                ClassStock,
+		"/stock/charts":                     ClassStock,
+		"/stock/live/S0003018":              ClassStock,
+		"/stock/shareholdings/S0003018/fii": ClassStock,
+		"/scrape/tata-motors":               ClassStock,
+		"/v1/usage":                         "",
+		"/v1/regions":                       "",
+		"/regions":                          "",
+		"/v1/htmlx":                         "",
+		"/searches":                         "",
+	}
+	for path, want := range tests {
+		class, metered := classify(path)
+		if class != want || metered != (want != "") {
+			t.Errorf("classify(%q) = %q, %v, want %q", path, class, metered, want)
+		}
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	secret, key, err := GenerateKey("acme", "Acme Corp")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if len(secret) != 64 || key.ID != "acme" || key.Name != "Acme Corp" || key.KeySHA256 != hashKey(secret) {
+		t.Errorf("GenerateKey = %q, %+v", secret, key)
+	}
+
+	other, _, _ := GenerateKey("acme", "")
+	if other == secret {
+		t.Error("GenerateKey returned the same secret twice")
+	}
+	if _, _, err := GenerateKey("", ""); err == nil {
+		t.Error("GenerateKey accepted an empty ID")
+	}
+}
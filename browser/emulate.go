@@ -0,0 +1,51 @@
+package browser
+
+import (
+	"context"
+	"strings"
+
+	"googlescrapper/profile"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// navigatorPlatforms maps client hint platforms to navigator.platform
+var navigatorPlatforms = map[string]string{
+	"Windows": "Win32",
+	"macOS":   "MacIntel",
+	"Linux":   "Linux x86_64",
+}
+
+// Emulate makes the tab present itself as the given Chromium profile: the
+// User-Agent, client hints, navigator.platform and Accept-Language all match.
+// Chrome formats Accept-Language itself from the languages in acceptLanguage.
+func Emulate(p profile.Profile, acceptLanguage string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		languages := profile.Languages(acceptLanguage)
+		if len(languages) == 0 {
+			languages = []string{"en-US", "en"}
+		}
+
+		brands := make([]*emulation.UserAgentBrandVersion, len(p.Brands))
+		fullVersions := make([]*emulation.UserAgentBrandVersion, len(p.Brands))
+		for i, brand := range p.Brands {
+			brands[i] = &emulation.UserAgentBrandVersion{Brand: brand.Brand, Version: brand.Version}
+			fullVersions[i] = &emulation.UserAgentBrandVersion{Brand: brand.Brand, Version: brand.FullVersion}
+		}
+
+		return emulation.SetUserAgentOverride(p.UserAgent).
+			WithAcceptLanguage(strings.Join(languages, ",")).
+			WithPlatform(navigatorPlatforms[p.Platform]).
+			WithUserAgentMetadata(&emulation.UserAgentMetadata{
+				Brands:          brands,
+				FullVersionList: fullVersions,
+				Platform:        p.Platform,
+				PlatformVersion: p.PlatformVersion,
+				Architecture:    "x86",
+				Bitness:         "64",
+				Mobile:          false,
+			}).
+			Do(ctx)
+	})
+}
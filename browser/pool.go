@@ -3,10 +3,17 @@ package browser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"sort"
 	"sync"
 	"time"
 
+	"googlescrapper/fetch"
+	"googlescrapper/profile"
+
+	cdpfetch "github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
@@ -16,6 +23,7 @@ import (
 type Pool struct {
 	contexts      chan context.Context
 	cancelFuncs   map[context.Context]context.CancelFunc
+	profiles      map[context.Context]profile.Profile // Header profile applied to each checked-out context
 	initOnce      sync.Once
 	minSize       int
 	maxSize       int
@@ -27,8 +35,15 @@ type Pool struct {
 	scaleUpCount  int       // Tracks consecutive scale up events
 	scaleDownTime time.Time // Last time we scaled down
 	waitQueue     int       // Count of waiting requests
+	proxyURL      *url.URL  // Proxy every browser in the pool routes through, nil for direct
+	lastUsed      time.Time // Last checkout, used to evict idle proxy pools
+	closed        bool      // Set by Shutdown, checkouts fail afterwards
+	stop          chan struct{}
 }
 
+// ErrPoolClosed is returned when checking out from a pool that was shut down
+var ErrPoolClosed = errors.New("browser pool is shut down")
+
 // New creates a new browser pool with the specified minimum and maximum sizes
 func New(minSize, maxSize int) *Pool {
 	return &Pool{
@@ -37,18 +52,139 @@ func New(minSize, maxSize int) *Pool {
 		currentSize: 0,
 		contexts:    make(chan context.Context, maxSize),
 		cancelFuncs: make(map[context.Context]context.CancelFunc),
+		profiles:    make(map[context.Context]profile.Profile),
 	}
 }
 
+// NewWithProxy creates a browser pool whose browsers route all traffic
+// through the given proxy
+func NewWithProxy(minSize, maxSize int, proxyURL *url.URL) *Pool {
+	pool := New(minSize, maxSize)
+	pool.proxyURL = proxyURL
+	return pool
+}
+
 // DefaultPool is a global browser pool with auto-scaling
 var DefaultPool = New(10, 30)
 
+// Sizes of the pools created for proxies, which see less traffic each. At
+// most maxProxyPools run at once, so proxies never hold more than
+// maxProxyPools*proxyPoolMaxSize browsers.
+const (
+	proxyPoolMinSize = 1
+	proxyPoolMaxSize = 5
+	maxProxyPools    = 8
+	// proxyPoolIdleTimeout is how long an unused proxy pool is kept
+	proxyPoolIdleTimeout = 10 * time.Minute
+	// proxyPoolMinIdle is how long a proxy pool must be unused before it can
+	// be evicted to make room for another proxy
+	proxyPoolMinIdle = 30 * time.Second
+)
+
+// ErrPoolLimit is returned by ForProxy when maxProxyPools pools are busy. It
+// wraps fetch.ErrNoProxy since the proxy can't be used until one frees up.
+var ErrPoolLimit = fmt.Errorf("%w: every proxy browser pool is busy", fetch.ErrNoProxy)
+
+var (
+	proxyPools   = make(map[string]*Pool)
+	proxyPoolsMu sync.Mutex
+	evictorOnce  sync.Once
+)
+
+// ForProxy returns the pool whose browsers route through proxyURL, creating
+// it on first use. A nil proxyURL returns DefaultPool. Pools left idle are
+// shut down in the background; when maxProxyPools are running, the least
+// recently used idle one is shut down to make room, or ErrPoolLimit returned.
+func ForProxy(proxyURL *url.URL) (*Pool, error) {
+	if proxyURL == nil {
+		return DefaultPool, nil
+	}
+	evictorOnce.Do(func() { go evictIdleProxyPools() })
+
+	proxyPoolsMu.Lock()
+	defer proxyPoolsMu.Unlock()
+
+	key := proxyURL.String()
+	if pool, ok := proxyPools[key]; ok {
+		return pool, nil
+	}
+
+	if len(proxyPools) >= maxProxyPools && !evictLeastRecentlyUsed(time.Now()) {
+		return nil, ErrPoolLimit
+	}
+	pool := NewWithProxy(proxyPoolMinSize, proxyPoolMaxSize, proxyURL)
+	// Count creation as a use so the pool isn't evicted before its first checkout
+	pool.lastUsed = time.Now()
+	proxyPools[key] = pool
+	return pool, nil
+}
+
+// evictIdleProxyPools shuts down the proxy pools that haven't been used for
+// proxyPoolIdleTimeout
+func evictIdleProxyPools() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		proxyPoolsMu.Lock()
+		for key, pool := range proxyPools {
+			if pool.closeIfIdle(time.Now().Add(-proxyPoolIdleTimeout)) {
+				delete(proxyPools, key)
+				fmt.Printf("Closed idle browser pool for proxy %s\n", pool.proxyURL.Host)
+			}
+		}
+		proxyPoolsMu.Unlock()
+	}
+}
+
+// evictLeastRecentlyUsed shuts down the least recently used proxy pool that
+// has been idle for proxyPoolMinIdle, and reports whether there was one. Must
+// be called with proxyPoolsMu held.
+func evictLeastRecentlyUsed(now time.Time) bool {
+	keys := make([]string, 0, len(proxyPools))
+	lastUsed := make(map[string]time.Time, len(proxyPools))
+	for key, pool := range proxyPools {
+		pool.mu.Lock()
+		lastUsed[key] = pool.lastUsed
+		pool.mu.Unlock()
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lastUsed[keys[i]].Before(lastUsed[keys[j]])
+	})
+
+	for _, key := range keys {
+		if pool := proxyPools[key]; pool.closeIfIdle(now.Add(-proxyPoolMinIdle)) {
+			delete(proxyPools, key)
+			return true
+		}
+	}
+	return false
+}
+
+// closeIfIdle shuts the pool down when no browser is checked out or waited
+// for and it hasn't been used since before, and reports whether it did
+func (pool *Pool) closeIfIdle(before time.Time) bool {
+	pool.mu.Lock()
+	idle := !pool.closed && pool.waitQueue == 0 && len(pool.contexts) == pool.currentSize && pool.lastUsed.Before(before)
+	if idle {
+		// Fail checkouts that race the shutdown
+		pool.closed = true
+	}
+	pool.mu.Unlock()
+
+	if idle {
+		pool.Shutdown()
+	}
+	return idle
+}
+
 // Initialize creates the browser pool
 func (pool *Pool) Initialize() {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
-	if pool.initialized {
+	if pool.initialized || pool.closed {
 		return
 	}
 
@@ -63,8 +199,13 @@ func (pool *Pool) Initialize() {
 		chromedp.Flag("ignore-certificate-errors", true),
 		chromedp.Flag("enable-javascript", true), // Allow JavaScript execution
 		chromedp.WindowSize(1920, 1080),
-		chromedp.UserAgent("Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/135.0.0.0 Safari/537.36"),
+		// Fallback only; every checked-out context is given its own profile
+		chromedp.UserAgent(profile.RandomChromium().UserAgent),
 	)
+	if pool.proxyURL != nil {
+		// Chrome takes credentials through the auth challenge, not the flag
+		opts = append(opts, chromedp.ProxyServer(pool.proxyURL.Scheme+"://"+pool.proxyURL.Host))
+	}
 
 	pool.allocCtx, pool.allocCancel = chromedp.NewExecAllocator(context.Background(), opts...)
 
@@ -72,9 +213,15 @@ func (pool *Pool) Initialize() {
 	pool.scaleUp(pool.minSize)
 
 	// Start the auto-scaler
-	go pool.autoScaler()
+	pool.stop = make(chan struct{})
+	go pool.autoScaler(pool.stop)
 
 	pool.initialized = true
+	if pool.proxyURL != nil {
+		fmt.Printf("Browser pool for proxy %s initialized with %d browsers (min: %d, max: %d)\n",
+			pool.proxyURL.Host, pool.currentSize, pool.minSize, pool.maxSize)
+		return
+	}
 	fmt.Printf("Browser pool initialized with %d browsers (min: %d, max: %d)\n",
 		pool.currentSize, pool.minSize, pool.maxSize)
 }
@@ -96,7 +243,7 @@ func (pool *Pool) scaleUp(n int) {
 		})
 
 		// Initialize the browser in advance
-		if err := chromedp.Run(ctx, chromedp.Navigate("about:blank")); err != nil {
+		if err := chromedp.Run(ctx, pool.proxyAuth(ctx), chromedp.Navigate("about:blank")); err != nil {
 			fmt.Printf("Error initializing browser: %v\n", err)
 			cancel()
 			continue
@@ -146,13 +293,18 @@ func (pool *Pool) scaleDown(n int) {
 	fmt.Printf("Scaled down pool by %d browsers to %d total\n", n, pool.currentSize)
 }
 
-// autoScaler periodically checks if the pool needs to be resized
-func (pool *Pool) autoScaler() {
+// autoScaler periodically checks if the pool needs to be resized, until stop
+// is closed
+func (pool *Pool) autoScaler(stop <-chan struct{}) {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
 
 		pool.mu.Lock()
 
@@ -203,65 +355,134 @@ func (pool *Pool) autoScaler() {
 	}
 }
 
-// GetContext gets a browser context from the pool
-func (pool *Pool) GetContext() (context.Context, context.CancelFunc, error) {
+// GetContext gets a browser context from the pool, waiting for one until ctx
+// is done or, when ctx has no deadline, for up to defaultCheckoutWait. The
+// returned context is cancelled when ctx is, so a caller that goes away stops
+// the chromedp actions running on it; the browser itself is only given back
+// to the pool by the release func. Each checkout presents as a freshly picked
+// Chromium header profile, available through Profile.
+func (pool *Pool) GetContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	waitCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, defaultCheckoutWait)
+		defer cancel()
+	}
+
+	tab, returnCtx, err := pool.checkout(waitCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	runCtx, cancelRun := bind(tab, ctx)
+
+	p := profile.RandomChromium()
+	emulateCtx, cancel := context.WithTimeout(runCtx, 3*time.Second)
+	err = chromedp.Run(emulateCtx, Emulate(p, ""))
+	cancel()
+	if err != nil {
+		cancelRun()
+		returnCtx()
+		return nil, nil, fmt.Errorf("failed to apply header profile: %v", err)
+	}
+
+	pool.mu.Lock()
+	pool.profiles[runCtx] = p
+	pool.mu.Unlock()
+
+	return runCtx, func() {
+		pool.mu.Lock()
+		delete(pool.profiles, runCtx)
+		pool.mu.Unlock()
+		cancelRun()
+		returnCtx()
+	}, nil
+}
+
+// Profile returns the header profile of a context checked out with GetContext
+func (pool *Pool) Profile(ctx context.Context) profile.Profile {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.profiles[ctx]
+}
+
+// bind derives a context from a browser tab that is also cancelled when
+// caller is done and carries the caller's deadline. Cancelling it stops the
+// actions running on the tab without closing the tab.
+func bind(tab, caller context.Context) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if deadline, ok := caller.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(tab, deadline)
+	} else {
+		ctx, cancel = context.WithCancel(tab)
+	}
+
+	// Leave deadlines to the context's own timer so it reports
+	// context.DeadlineExceeded rather than context.Canceled
+	stop := context.AfterFunc(caller, func() {
+		if !errors.Is(caller.Err(), context.DeadlineExceeded) {
+			cancel()
+		}
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+const (
+	// scaleUpAfter is how long a checkout waits for a free browser before
+	// starting a new one
+	scaleUpAfter = 500 * time.Millisecond
+	// defaultCheckoutWait bounds the wait for a browser when the caller's
+	// context has no deadline
+	defaultCheckoutWait = 5 * time.Second
+)
+
+// checkout takes a browser context from the pool, creating one if needed. It
+// waits until ctx is done for a context to free up.
+func (pool *Pool) checkout(ctx context.Context) (context.Context, context.CancelFunc, error) {
 	pool.initOnce.Do(func() {
 		pool.Initialize()
 	})
 
 	pool.mu.Lock()
+	if pool.closed {
+		pool.mu.Unlock()
+		return nil, nil, ErrPoolClosed
+	}
 	pool.waitQueue++
+	pool.lastUsed = time.Now()
 	pool.mu.Unlock()
 
-	// Try to get a context immediately or wait up to 500ms
+	// Try to get a context immediately or wait up to scaleUpAfter
 	select {
-	case ctx := <-pool.contexts:
+	case tab := <-pool.contexts:
 		pool.mu.Lock()
 		pool.waitQueue--
 		pool.mu.Unlock()
 
-		// Create a return function that puts the context back in the pool
-		returnCtx := func() {
-			// Refresh the browser before returning to pool
-			refreshCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-			defer cancel()
-
-			// Navigate to blank page to clear state and reduce memory
-			_ = chromedp.Run(refreshCtx,
-				network.ClearBrowserCookies(),
-				chromedp.Navigate("about:blank"),
-			)
-
-			select {
-			case pool.contexts <- ctx:
-			default:
-				// Pool channel is full, context will be GC'd
-				if cancel, exists := pool.cancelFuncs[ctx]; exists {
-					pool.mu.Lock()
-					cancel()
-					delete(pool.cancelFuncs, ctx)
-					pool.currentSize--
-					pool.mu.Unlock()
-				}
-			}
-		}
+		return tab, pool.returnFunc(tab), nil
 
-		return ctx, returnCtx, nil
+	case <-ctx.Done():
+		pool.mu.Lock()
+		pool.waitQueue--
+		pool.mu.Unlock()
+		return nil, nil, fmt.Errorf("waiting for browser context: %w", ctx.Err())
 
-	case <-time.After(500 * time.Millisecond):
-		// If we waited more than 500ms, try to create a new browser instance
+	case <-time.After(scaleUpAfter):
+		// If we waited more than scaleUpAfter, try to create a new browser instance
 		pool.mu.Lock()
 
 		// Only create a new instance if we're below max capacity
 		if pool.currentSize < pool.maxSize {
-			ctx, cancel := chromedp.NewContext(pool.allocCtx, chromedp.WithLogf(func(format string, args ...interface{}) {
+			tab, cancel := chromedp.NewContext(pool.allocCtx, chromedp.WithLogf(func(format string, args ...interface{}) {
 				// Silent logging
 			}))
 
-			// Try to initialize the browser quickly
-			initCtx, initCancel := context.WithTimeout(context.Background(), 5*time.Second)
-			err := chromedp.Run(initCtx, chromedp.Navigate("about:blank"))
-			initCancel()
+			// The first run starts the browser and must use the tab's own
+			// context, cancelling a derived one would close the tab
+			err := chromedp.Run(tab, pool.proxyAuth(tab), chromedp.Navigate("about:blank"))
 
 			if err != nil {
 				cancel()
@@ -271,80 +492,104 @@ func (pool *Pool) GetContext() (context.Context, context.CancelFunc, error) {
 			}
 
 			// Add to pool management
-			pool.cancelFuncs[ctx] = cancel
+			pool.cancelFuncs[tab] = cancel
 			pool.currentSize++
 			pool.waitQueue--
 			pool.mu.Unlock()
 
-			// Create return function
-			returnCtx := func() {
-				// Refresh before returning to pool
-				refreshCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-				defer cancel()
-
-				_ = chromedp.Run(refreshCtx,
-					network.ClearBrowserCookies(),
-					chromedp.Navigate("about:blank"),
-				)
-
-				// Put context back in the pool
-				select {
-				case pool.contexts <- ctx:
-					// Successfully returned to pool
-				default:
-					// Pool is full, this extra instance will be closed
-					if cancel, exists := pool.cancelFuncs[ctx]; exists {
-						pool.mu.Lock()
-						cancel()
-						delete(pool.cancelFuncs, ctx)
-						pool.currentSize--
-						pool.mu.Unlock()
-					}
-				}
-			}
-
-			return ctx, returnCtx, nil
+			return tab, pool.returnFunc(tab), nil
 		}
 
-		// If we couldn't create a new instance, wait longer for an existing one
+		// If we couldn't create a new instance, wait for an existing one
 		pool.mu.Unlock()
 
-		// Wait up to 3 more seconds for a context to become available
 		select {
-		case ctx := <-pool.contexts:
+		case tab := <-pool.contexts:
 			pool.mu.Lock()
 			pool.waitQueue--
 			pool.mu.Unlock()
 
-			returnCtx := func() {
-				select {
-				case pool.contexts <- ctx:
-					// Successfully returned to pool
-				default:
-					// Pool is full
-					fmt.Println("Browser pool is full, cannot return context")
-				}
-			}
-
-			return ctx, returnCtx, nil
-		case <-time.After(3 * time.Second):
+			return tab, pool.returnFunc(tab), nil
+		case <-ctx.Done():
 			pool.mu.Lock()
 			pool.waitQueue--
 			pool.mu.Unlock()
-			return nil, nil, fmt.Errorf("timeout getting browser context from pool")
+			return nil, nil, fmt.Errorf("timeout getting browser context from pool: %w", ctx.Err())
+		}
+	}
+}
+
+// returnFunc returns the func that refreshes a checked-out context and puts it
+// back in the pool, closing it when the pool is already full
+func (pool *Pool) returnFunc(tab context.Context) context.CancelFunc {
+	return func() {
+		// Refresh the browser before returning to pool
+		refreshCtx, cancel := context.WithTimeout(tab, 3*time.Second)
+		defer cancel()
+
+		// Navigate to blank page to clear state and reduce memory
+		_ = chromedp.Run(refreshCtx,
+			network.ClearBrowserCookies(),
+			chromedp.Navigate("about:blank"),
+		)
+
+		select {
+		case pool.contexts <- tab:
+		default:
+			// Pool channel is full, this extra instance will be closed
+			pool.mu.Lock()
+			if cancel, exists := pool.cancelFuncs[tab]; exists {
+				cancel()
+				delete(pool.cancelFuncs, tab)
+				pool.currentSize--
+			}
+			pool.mu.Unlock()
 		}
 	}
 }
 
+// proxyAuth answers the proxy's auth challenges with the credentials from the
+// proxy URL. It does nothing for pools without an authenticated proxy.
+func (pool *Pool) proxyAuth(ctx context.Context) chromedp.Action {
+	return chromedp.ActionFunc(func(runCtx context.Context) error {
+		if pool.proxyURL == nil || pool.proxyURL.User == nil {
+			return nil
+		}
+
+		username := pool.proxyURL.User.Username()
+		password, _ := pool.proxyURL.User.Password()
+		chromedp.ListenTarget(ctx, func(ev interface{}) {
+			switch ev := ev.(type) {
+			case *cdpfetch.EventAuthRequired:
+				go chromedp.Run(ctx, cdpfetch.ContinueWithAuth(ev.RequestID, &cdpfetch.AuthChallengeResponse{
+					Response: cdpfetch.AuthChallengeResponseResponseProvideCredentials,
+					Username: username,
+					Password: password,
+				}))
+			case *cdpfetch.EventRequestPaused:
+				// Enabling auth handling pauses every request; let them through
+				go chromedp.Run(ctx, cdpfetch.ContinueRequest(ev.RequestID))
+			}
+		})
+		return cdpfetch.Enable().WithHandleAuthRequests(true).Do(runCtx)
+	})
+}
+
 // Shutdown closes all browser instances
 func (pool *Pool) Shutdown() {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
+	pool.closed = true
 	if !pool.initialized {
 		return
 	}
 
+	if pool.stop != nil {
+		close(pool.stop)
+		pool.stop = nil
+	}
+
 	// Cancel all contexts
 	for ctx, cancel := range pool.cancelFuncs {
 		cancel()
@@ -366,9 +611,10 @@ func (pool *Pool) Shutdown() {
 	fmt.Println("Browser pool shut down")
 }
 
-// FetchURL navigates to a URL and returns the HTML content
-func (pool *Pool) FetchURL(url string, timeout time.Duration) (string, error) {
-	ctx, returnCtx, err := pool.GetContext()
+// FetchURL navigates to a URL and returns the HTML content. ctx bounds both
+// the wait for a browser and the page load.
+func (pool *Pool) FetchURL(ctx context.Context, url string) (string, error) {
+	tabCtx, returnCtx, err := pool.GetContext(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get browser context: %v", err)
 	}
@@ -376,12 +622,8 @@ func (pool *Pool) FetchURL(url string, timeout time.Duration) (string, error) {
 
 	var htmlContent string
 
-	// Add a timeout for this specific operation
-	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
 	// Navigate to the URL and scrape the content
-	err = chromedp.Run(timeoutCtx,
+	err = chromedp.Run(tabCtx,
 		// Navigate to the search URL
 		chromedp.Navigate(url),
 		// Wait for a moment
@@ -390,7 +632,7 @@ func (pool *Pool) FetchURL(url string, timeout time.Duration) (string, error) {
 		chromedp.OuterHTML(`html`, &htmlContent, chromedp.ByQuery),
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch URL content: %v", err)
+		return "", fmt.Errorf("failed to fetch URL content: %w", err)
 	}
 
 	return htmlContent, nil
@@ -3,22 +3,55 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
-// RedisClient is a global Redis client instance
-var RedisClient = redis.NewClient(&redis.Options{
-	Addr:     "localhost:6379", // Change if needed
-	Password: "",               // No password by default
-	DB:       0,                // Default DB
-})
+// RedisClient is the shared Redis client, configured by REDIS_ADDR
+// (localhost:6379 by default), REDIS_PASSWORD and REDIS_DB
+var RedisClient = newRedisClient()
 
-// Memoize function for caching any function result in Redis
-func Memoize[T any](key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+func newRedisClient() *redis.Client {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	db := 0
+	if value := os.Getenv("REDIS_DB"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid REDIS_DB %q, expected a database number", value)
+		}
+		db = n
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv("REDIS_PASSWORD"),
+		DB:       db,
+	})
+}
+
+// StaleTTL is how long a result is kept after its TTL to be served when the
+// upstream can't be reached
+const StaleTTL = 24 * time.Hour
+
+// staleServer is implemented by errors after which an expired cached result
+// is better than failing, such as an open circuit breaker
+type staleServer interface {
+	ServeStale() bool
+}
+
+// Memoize function for caching any function result in Redis. ctx bounds the
+// cache lookups and is passed on to fn.
+func Memoize[T any](ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
 	var result T
-	ctx := context.Background()
 
 	// Try fetching from cache
 	cachedData, err := RedisClient.Get(ctx, key).Bytes()
@@ -29,14 +62,28 @@ func Memoize[T any](key string, ttl time.Duration, fn func() (T, error)) (T, err
 	}
 
 	// Call the actual function
-	result, err = fn()
+	result, err = fn(ctx)
 	if err != nil {
+		// Fall back to the stale copy when the error allows it
+		var stale staleServer
+		if errors.As(err, &stale) && stale.ServeStale() {
+			var staleResult T
+			staleData, staleErr := RedisClient.Get(ctx, staleKey(key)).Bytes()
+			if staleErr == nil && json.Unmarshal(staleData, &staleResult) == nil {
+				return staleResult, nil
+			}
+		}
 		return result, err
 	}
 
-	// Store result in cache
+	// Store result in cache, and a longer-lived stale copy
 	cacheData, _ := json.Marshal(result)
 	RedisClient.Set(ctx, key, cacheData, ttl)
+	RedisClient.Set(ctx, staleKey(key), cacheData, ttl+StaleTTL)
 
 	return result, nil
 }
+
+func staleKey(key string) string {
+	return "stale:" + key
+}
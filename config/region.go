@@ -1,19 +1,85 @@
 package config
 
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
 // RegionConfig holds region-specific parameters
 type RegionConfig struct {
-	Gl string // Country code
-	Lr string // Language region
-	Hl string // Host language
+	Name           string `json:"name"`
+	Gl             string `json:"gl"`                    // Country code
+	Lr             string `json:"lr,omitempty"`          // Language region, omitted from the URL when empty
+	Hl             string `json:"hl"`                    // Host language
+	GoogleDomain   string `json:"google_domain"`         // Country Google host, e.g. "google.co.in"
+	AcceptLanguage string `json:"accept_language"`       // Accept-Language header matching Hl
+	BingMarket     string `json:"bing_market,omitempty"` // Empty where Bing has no market, Gl and Hl are sent instead
+}
+
+// GoogleHost returns the hostname to send Google requests for this region to
+func (r RegionConfig) GoogleHost() string {
+	if r.GoogleDomain == "" {
+		return "www.google.com"
+	}
+	return "www." + r.GoogleDomain
+}
+
+//go:embed regions.json
+var defaultRegions []byte
+
+// RegionConfigs maps region codes to their configurations. It is loaded from
+// the embedded regions.json, or from the file named by REGIONS_FILE.
+var RegionConfigs = mustLoadRegions()
+
+func mustLoadRegions() map[string]RegionConfig {
+	data := defaultRegions
+	if path := os.Getenv("REGIONS_FILE"); path != "" {
+		custom, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read REGIONS_FILE %s: %v", path, err)
+		}
+		data = custom
+	}
+
+	regions, err := parseRegions(data)
+	if err != nil {
+		log.Fatalf("Failed to load regions: %v", err)
+	}
+	return regions
+}
+
+func parseRegions(data []byte) (map[string]RegionConfig, error) {
+	var regions map[string]RegionConfig
+	if err := json.Unmarshal(data, &regions); err != nil {
+		return nil, fmt.Errorf("invalid regions JSON: %v", err)
+	}
+
+	for code, region := range regions {
+		if region.Gl == "" || region.Hl == "" {
+			return nil, fmt.Errorf("region %q must set gl and hl", code)
+		}
+	}
+	return regions, nil
+}
+
+// Region is a region code with its parameters
+type Region struct {
+	Code string `json:"code"`
+	RegionConfig
 }
 
-// RegionConfigs maps region codes to their configurations
-var RegionConfigs = map[string]RegionConfig{
-	"in-en": {"in", "lang_en", "en-IN"}, // India (English
-	"in-hi": {"in", "lang_hi", "hi-IN"}, // India (Hindi
-	"in-bn": {"in", "lang_bn", "bn-IN"}, // India (Bengali
-	"in-te": {"in", "lang_te", "te-IN"}, // India (Telugu
-	"in-ta": {"in", "lang_ta", "ta-IN"}, // India (Tamil
-	"us":    {"us", "lang_en", "en-US"}, // United
-	"uk":    {"gb", "lang_en", "en-GB"}, // United
+// Regions returns every supported region, sorted by code
+func Regions() []Region {
+	regions := make([]Region, 0, len(RegionConfigs))
+	for code, config := range RegionConfigs {
+		regions = append(regions, Region{Code: code, RegionConfig: config})
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].Code < regions[j].Code
+	})
+	return regions
 }
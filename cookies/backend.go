@@ -0,0 +1,156 @@
+package cookies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"googlescrapper/cache"
+)
+
+// Backend persists cookies. Several server instances can share a Redis
+// backend; each reloads it every reloadInterval.
+type Backend interface {
+	Load() ([]Cookie, error)
+	Put(cookies ...Cookie) error
+	Delete(id string) error
+}
+
+// FileBackend stores cookies as a JSON array in a local file
+type FileBackend struct {
+	path    string
+	mu      sync.Mutex
+	cookies map[string]Cookie
+}
+
+// NewFileBackend creates a backend for the given path. A missing file is
+// treated as an empty store.
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path, cookies: make(map[string]Cookie)}
+}
+
+func (b *FileBackend) Load() ([]Cookie, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cookie file: %v", err)
+	}
+
+	var cookies []Cookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil, fmt.Errorf("invalid cookie file %s: %v", b.path, err)
+	}
+
+	b.cookies = make(map[string]Cookie, len(cookies))
+	for _, cookie := range cookies {
+		b.cookies[cookie.ID] = cookie
+	}
+	return cookies, nil
+}
+
+func (b *FileBackend) Put(cookies ...Cookie) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, cookie := range cookies {
+		b.cookies[cookie.ID] = cookie
+	}
+	return b.write()
+}
+
+func (b *FileBackend) Delete(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.cookies, id)
+	return b.write()
+}
+
+// write replaces the file atomically; the file holds secrets, so it is only
+// readable by the owner
+func (b *FileBackend) write() error {
+	cookies := make([]Cookie, 0, len(b.cookies))
+	for _, cookie := range b.cookies {
+		cookies = append(cookies, cookie)
+	}
+	sort.Slice(cookies, func(i, j int) bool {
+		return cookies[i].AddedAt.Before(cookies[j].AddedAt)
+	})
+
+	data, err := json.MarshalIndent(cookies, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), ".cookies-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write cookie file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cookie file: %v", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cookie file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cookie file: %v", err)
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
+
+// redisCookiesKey is the hash holding one JSON-encoded cookie per field
+const redisCookiesKey = "cookies:store"
+
+// RedisBackend stores cookies in a Redis hash on the shared cache client
+type RedisBackend struct{}
+
+func (RedisBackend) Load() ([]Cookie, error) {
+	values, err := cache.RedisClient.HGetAll(context.Background(), redisCookiesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cookies from Redis: %v", err)
+	}
+
+	cookies := make([]Cookie, 0, len(values))
+	for id, value := range values {
+		var cookie Cookie
+		if err := json.Unmarshal([]byte(value), &cookie); err != nil {
+			return nil, fmt.Errorf("invalid cookie %s in Redis: %v", id, err)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, nil
+}
+
+func (RedisBackend) Put(cookies ...Cookie) error {
+	if len(cookies) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(cookies))
+	for _, cookie := range cookies {
+		data, err := json.Marshal(cookie)
+		if err != nil {
+			return err
+		}
+		fields[cookie.ID] = data
+	}
+	return cache.RedisClient.HSet(context.Background(), redisCookiesKey, fields).Err()
+}
+
+func (RedisBackend) Delete(id string) error {
+	return cache.RedisClient.HDel(context.Background(), redisCookiesKey, id).Err()
+}
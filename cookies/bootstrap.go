@@ -0,0 +1,155 @@
+package cookies
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"googlescrapper/browser"
+	"googlescrapper/config"
+	"googlescrapper/proxy"
+	"googlescrapper/throttle"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// bootstrapTimeout bounds a whole bootstrap, including the consent click
+const bootstrapTimeout = 45 * time.Second
+
+// acceptConsentJS clicks "Accept all" on either the in-page consent dialog or
+// the consent.google.com interstitial and reports whether it found the button
+const acceptConsentJS = `(() => {
+	let button = document.querySelector('#L2AGLb');
+	if (!button) {
+		const form = [...document.querySelectorAll('form[action*="consent"]')]
+			.find(f => f.querySelector('input[name="set_eom"][value="false"]'));
+		button = form && form.querySelector('button');
+	}
+	if (!button) return false;
+	button.click();
+	return true;
+})()`
+
+// Bootstrap opens the region's Google homepage in a pooled browser, accepts
+// the consent dialog if one is shown and stores the resulting anonymous
+// cookies for the region, together with the header profile the browser
+// presented so later requests with the cookie look the same
+func (s *Store) Bootstrap(ctx context.Context, region config.RegionConfig) (cookie Cookie, err error) {
+	done, err := throttle.Acquire(ctx, throttle.EngineGoogle, "bootstrap")
+	if err != nil {
+		return Cookie{}, err
+	}
+	defer func() { done(err) }()
+
+	// Go through a proxy in the region so the consent matches its country
+	p, err := proxy.Default.Pick(region.Gl, "")
+	if err != nil {
+		return Cookie{}, err
+	}
+	pool, err := browser.ForProxy(p.ProxyURL())
+	if err != nil {
+		return Cookie{}, err
+	}
+	tabCtx, returnCtx, err := pool.GetContext(ctx)
+	if err != nil {
+		return Cookie{}, fmt.Errorf("failed to get browser context: %v", err)
+	}
+	defer returnCtx() // Return the context to the pool when done
+
+	timeoutCtx, cancel := context.WithTimeout(tabCtx, bootstrapTimeout)
+	defer cancel()
+
+	params := url.Values{}
+	if region.Hl != "" {
+		params.Set("hl", region.Hl)
+	}
+	if region.Gl != "" {
+		params.Set("gl", region.Gl)
+	}
+	homeURL := "https://" + region.GoogleHost() + "/?" + params.Encode()
+
+	headerProfile := pool.Profile(tabCtx)
+
+	var accepted bool
+	err = chromedp.Run(timeoutCtx,
+		browser.Emulate(headerProfile, region.AcceptLanguage),
+		network.ClearBrowserCookies(),
+		chromedp.Navigate(homeURL),
+		chromedp.Sleep(1500*time.Millisecond),
+		chromedp.Evaluate(acceptConsentJS, &accepted),
+	)
+	if err != nil {
+		return Cookie{}, fmt.Errorf("failed to load %s: %v", homeURL, err)
+	}
+	if accepted {
+		// Give the consent form time to submit and set its cookies
+		if err := chromedp.Run(timeoutCtx, chromedp.Sleep(2*time.Second)); err != nil {
+			return Cookie{}, fmt.Errorf("failed to accept consent: %v", err)
+		}
+	}
+
+	var browserCookies []*network.Cookie
+	err = chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		browserCookies, err = network.GetCookies().WithURLs([]string{"https://" + region.GoogleHost() + "/"}).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return Cookie{}, fmt.Errorf("failed to read cookies: %v", err)
+	}
+
+	value, expiresAt := cookieHeader(browserCookies)
+	if value == "" {
+		return Cookie{}, fmt.Errorf("no cookies were set by %s", homeURL)
+	}
+
+	return s.Add(Cookie{
+		Value:     value,
+		Region:    region.Gl,
+		Source:    SourceBootstrap,
+		Profile:   headerProfile.Name,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// cookieHeader joins browser cookies into a Cookie header and returns the
+// earliest expiry among the persistent ones
+func cookieHeader(browserCookies []*network.Cookie) (string, *time.Time) {
+	parts := make([]string, 0, len(browserCookies))
+	var expiresAt *time.Time
+	for _, c := range browserCookies {
+		parts = append(parts, c.Name+"="+c.Value)
+
+		// Session cookies report an expiry of -1
+		if c.Expires > 0 {
+			expiry := time.Unix(int64(c.Expires), 0)
+			if expiresAt == nil || expiry.Before(*expiresAt) {
+				expiresAt = &expiry
+			}
+		}
+	}
+	return strings.Join(parts, "; "), expiresAt
+}
+
+// bootstrapAsync starts a background bootstrap for the region unless one was
+// attempted within bootstrapInterval. Must be called with s.mu held.
+func (s *Store) bootstrapAsync(region config.RegionConfig) {
+	if last, ok := s.lastBootstrap[region.Gl]; ok && time.Since(last) < bootstrapInterval {
+		return
+	}
+	s.lastBootstrap[region.Gl] = time.Now()
+
+	// Not tied to the request that found no cookie, it outlives it
+	go func() {
+		cookie, err := s.Bootstrap(context.Background(), region)
+		if err != nil {
+			log.Printf("Failed to bootstrap cookies for %s: %v", region.GoogleHost(), err)
+			return
+		}
+		log.Printf("Bootstrapped cookie %s for %s", cookie.ID, region.GoogleHost())
+	}()
+}
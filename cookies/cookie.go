@@ -0,0 +1,89 @@
+// Package cookies manages the Google session cookies sent with upstream
+// requests: storage, health tracking, quarantine and browser bootstrapping
+package cookies
+
+import (
+	"strings"
+	"time"
+
+	"googlescrapper/profile"
+)
+
+// Sources a cookie can come from
+const (
+	SourceManual    = "manual"
+	SourceBootstrap = "bootstrap"
+)
+
+// Cookie is a stored Cookie header value together with its health
+type Cookie struct {
+	ID        string     `json:"id"`
+	Value     string     `json:"value"`            // Full Cookie header, e.g. "AEC=...; NID=..."
+	Region    string     `json:"region,omitempty"` // Country code (gl) the cookie is valid for, empty for any
+	Source    string     `json:"source"`
+	Profile   string     `json:"profile,omitempty"` // Header profile the cookie was created with
+	AddedAt   time.Time  `json:"added_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	Successes           int        `json:"successes"`
+	Failures            int        `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Quarantines         int        `json:"quarantines"`
+	LastUsed            *time.Time `json:"last_used,omitempty"`
+	LastBlock           *time.Time `json:"last_block,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	QuarantinedUntil    *time.Time `json:"quarantined_until,omitempty"`
+}
+
+// SuccessRate returns the share of successful requests, or 1 for an unused cookie
+func (c *Cookie) SuccessRate() float64 {
+	total := c.Successes + c.Failures
+	if total == 0 {
+		return 1
+	}
+	return float64(c.Successes) / float64(total)
+}
+
+// Expired reports whether the cookie is past its expiry
+func (c *Cookie) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && now.After(*c.ExpiresAt)
+}
+
+// Quarantined reports whether the cookie is currently out of rotation
+func (c *Cookie) Quarantined(now time.Time) bool {
+	return c.QuarantinedUntil != nil && now.Before(*c.QuarantinedUntil)
+}
+
+// Status summarizes the cookie's health as "active", "quarantined" or "expired"
+func (c *Cookie) Status(now time.Time) string {
+	switch {
+	case c.Expired(now):
+		return "expired"
+	case c.Quarantined(now):
+		return "quarantined"
+	default:
+		return "active"
+	}
+}
+
+// HeaderProfile returns the header profile to send the cookie with: the one
+// it was created with, or a profile fixed by its ID so the cookie always
+// presents as the same browser
+func (c *Cookie) HeaderProfile() profile.Profile {
+	if p, ok := profile.Get(c.Profile); ok {
+		return p
+	}
+	return profile.ForSession(c.ID)
+}
+
+// Names returns the cookie names in the header value without their values
+func (c *Cookie) Names() []string {
+	names := []string{}
+	for _, part := range strings.Split(c.Value, ";") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,158 @@
+package cookies
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"googlescrapper/apierror"
+	"googlescrapper/config"
+	"googlescrapper/fetch"
+
+	"github.com/gorilla/mux"
+)
+
+// CookieView is the admin representation of a cookie. The value itself is
+// never returned, only the names of the cookies it contains.
+type CookieView struct {
+	Cookie
+	Value       string   `json:"value,omitempty"`
+	Names       []string `json:"names"`
+	Status      string   `json:"status"`
+	SuccessRate float64  `json:"success_rate"`
+}
+
+func newCookieView(cookie Cookie, now time.Time) CookieView {
+	return CookieView{
+		Cookie:      cookie,
+		Names:       cookie.Names(),
+		Status:      cookie.Status(now),
+		SuccessRate: cookie.SuccessRate(),
+	}
+}
+
+// AddCookieRequest is the body accepted by AddHandler
+type AddCookieRequest struct {
+	Value     string     `json:"value"`
+	Region    string     `json:"region,omitempty"` // Region code from /regions, empty for every region
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListHandler lists every stored cookie with its health
+func ListHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	cookies := Default.List()
+	views := make([]CookieView, 0, len(cookies))
+	for _, cookie := range cookies {
+		views = append(views, newCookieView(cookie, now))
+	}
+	writeJSON(w, r, http.StatusOK, views)
+}
+
+// AddHandler stores a cookie from a JSON body
+func AddHandler(w http.ResponseWriter, r *http.Request) {
+	var req AddCookieRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+
+	country, err := regionCountry(req.Region)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+
+	cookie, err := Default.Add(Cookie{
+		Value:     req.Value,
+		Region:    country,
+		Source:    SourceManual,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, newCookieView(cookie, time.Now()))
+}
+
+// DeleteHandler removes a cookie by ID
+func DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	err := Default.Remove(mux.Vars(r)["id"])
+	if errors.Is(err, ErrNotFound) {
+		apierror.NotFound(w, r, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Internal(w, r, "Error deleting cookie", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreHandler takes a cookie out of quarantine
+func RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := Default.Restore(mux.Vars(r)["id"])
+	if errors.Is(err, ErrNotFound) {
+		apierror.NotFound(w, r, err.Error())
+		return
+	}
+	writeJSON(w, r, http.StatusOK, newCookieView(cookie, time.Now()))
+}
+
+// BootstrapHandler bootstraps an anonymous consent cookie for the region
+// given by the "region" query parameter
+func BootstrapHandler(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("region")
+	region := config.RegionConfig{GoogleDomain: "google.com"}
+	if code != "" {
+		var ok bool
+		if region, ok = config.RegionConfigs[code]; !ok {
+			apierror.BadRequest(w, r, "Unknown region: "+code)
+			return
+		}
+	}
+
+	cookie, err := Default.Bootstrap(r.Context(), region)
+	if err != nil {
+		e := apierror.FromError(err)
+		if e == nil {
+			// Admin only, so the cause is worth more than hiding it
+			e = &apierror.Error{
+				Status:    http.StatusBadGateway,
+				Code:      apierror.CodeUpstreamError,
+				Message:   "Error bootstrapping cookie: " + err.Error(),
+				Upstream:  fetch.UpstreamGoogle,
+				Retryable: true,
+			}
+		}
+		apierror.Write(w, r, e)
+		return
+	}
+	writeJSON(w, r, http.StatusCreated, newCookieView(cookie, time.Now()))
+}
+
+// regionCountry resolves a region code to the country code cookies are bound to
+func regionCountry(code string) (string, error) {
+	if code == "" {
+		return "", nil
+	}
+	region, ok := config.RegionConfigs[code]
+	if !ok {
+		return "", errors.New("unknown region: " + code)
+	}
+	return region.Gl, nil
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	jsonData, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(jsonData)
+}
@@ -0,0 +1,369 @@
+package cookies
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	mathrand "math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"googlescrapper/config"
+	"googlescrapper/fetch"
+)
+
+const (
+	// quarantineThreshold is how many blocks in a row take a cookie out of rotation
+	quarantineThreshold = 3
+	// baseQuarantine is the first quarantine period; it doubles with every
+	// further quarantine up to maxQuarantine
+	baseQuarantine = 30 * time.Minute
+	maxQuarantine  = 24 * time.Hour
+	// reloadInterval is how often the store re-reads a shared backend
+	reloadInterval = time.Minute
+	// bootstrapInterval limits automatic bootstraps per region
+	bootstrapInterval = 10 * time.Minute
+	// flushInterval is how long reported outcomes are batched before they're
+	// written to the backend
+	flushInterval = 5 * time.Second
+)
+
+// ErrNotFound is returned for operations on an unknown cookie ID
+var ErrNotFound = errors.New("cookie not found")
+
+// Store keeps the cookies in memory, persists changes to its backend and
+// picks a healthy cookie for each request. Admin changes are written straight
+// away; the outcomes recorded by Report are batched and written every
+// flushInterval, so a crash loses at most that much of the health stats.
+type Store struct {
+	backend Backend
+
+	mu             sync.Mutex
+	cookies        map[string]*Cookie
+	dirty          map[string]bool // Cookies with reported outcomes not yet written
+	flushScheduled bool
+	loadedAt       time.Time
+	lastBootstrap  map[string]time.Time
+}
+
+// NewStore creates a store on top of the given backend. Cookies are loaded
+// lazily on first use.
+func NewStore(backend Backend) *Store {
+	return &Store{
+		backend:       backend,
+		cookies:       make(map[string]*Cookie),
+		dirty:         make(map[string]bool),
+		lastBootstrap: make(map[string]time.Time),
+	}
+}
+
+// Default is the store used by the scrapers. COOKIE_STORE=redis keeps the
+// cookies in Redis; otherwise they're read from COOKIE_FILE (cookies.json by
+// default), which must stay out of version control.
+var Default = NewStore(defaultBackend())
+
+func defaultBackend() Backend {
+	if os.Getenv("COOKIE_STORE") == "redis" {
+		return RedisBackend{}
+	}
+
+	path := os.Getenv("COOKIE_FILE")
+	if path == "" {
+		path = "cookies.json"
+	}
+	return NewFileBackend(path)
+}
+
+// load refreshes the in-memory cookies from the backend when they're stale.
+// Must be called with s.mu held.
+func (s *Store) load() {
+	if time.Since(s.loadedAt) < reloadInterval {
+		return
+	}
+	s.loadedAt = time.Now()
+
+	cookies, err := s.backend.Load()
+	if err != nil {
+		log.Printf("Failed to load cookies: %v", err)
+		return
+	}
+
+	loaded := make(map[string]*Cookie, len(cookies))
+	for i := range cookies {
+		loaded[cookies[i].ID] = &cookies[i]
+	}
+	// Keep outcomes that haven't been flushed yet
+	for id := range s.dirty {
+		if cookie, ok := s.cookies[id]; ok {
+			loaded[id] = cookie
+		}
+	}
+	s.cookies = loaded
+}
+
+// persist writes a cookie to the backend. Must be called with s.mu held.
+func (s *Store) persist(cookie *Cookie) {
+	delete(s.dirty, cookie.ID)
+	if err := s.backend.Put(*cookie); err != nil {
+		log.Printf("Failed to save cookie %s: %v", cookie.ID, err)
+	}
+}
+
+// persistLater queues a cookie for the next flush. Must be called with s.mu
+// held.
+func (s *Store) persistLater(cookie *Cookie) {
+	s.dirty[cookie.ID] = true
+	if !s.flushScheduled {
+		s.flushScheduled = true
+		time.AfterFunc(flushInterval, s.flush)
+	}
+}
+
+// flush writes every queued cookie to the backend in one call. The lock is
+// held during the write so it can't race an admin change to the same cookie.
+func (s *Store) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushScheduled = false
+
+	batch := make([]Cookie, 0, len(s.dirty))
+	for id := range s.dirty {
+		if cookie, ok := s.cookies[id]; ok {
+			batch = append(batch, *cookie)
+		}
+	}
+	s.dirty = make(map[string]bool)
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := s.backend.Put(batch...); err != nil {
+		log.Printf("Failed to save %d cookies: %v", len(batch), err)
+	}
+}
+
+// Pick returns a healthy cookie for the region, preferring cookies bound to
+// the region's country over unbound ones. Cookies with a better success rate
+// are picked more often. When no cookie is usable, a consent cookie is
+// bootstrapped in the background and Pick returns false.
+func (s *Store) Pick(region config.RegionConfig) (Cookie, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	now := time.Now()
+	var regional, global []*Cookie
+	for _, cookie := range s.cookies {
+		if cookie.Expired(now) || cookie.Quarantined(now) {
+			continue
+		}
+		switch cookie.Region {
+		case region.Gl:
+			regional = append(regional, cookie)
+		case "":
+			global = append(global, cookie)
+		}
+	}
+
+	candidates := regional
+	if len(candidates) == 0 {
+		candidates = global
+	}
+	if len(candidates) == 0 {
+		s.bootstrapAsync(region)
+		return Cookie{}, false
+	}
+
+	cookie := weightedPick(candidates)
+	cookie.LastUsed = &now
+	return *cookie, true
+}
+
+// weightedPick chooses a cookie at random, weighted by its smoothed success rate
+func weightedPick(candidates []*Cookie) *Cookie {
+	weights := make([]float64, len(candidates))
+	var total float64
+	for i, cookie := range candidates {
+		weights[i] = float64(cookie.Successes+1) / float64(cookie.Successes+cookie.Failures+2)
+		total += weights[i]
+	}
+
+	r := mathrand.Float64() * total
+	for i, weight := range weights {
+		if r < weight {
+			return candidates[i]
+		}
+		r -= weight
+	}
+	return candidates[len(candidates)-1]
+}
+
+// Report records the outcome of a request made with the cookie. Blocks,
+// captchas and rate limits count against the cookie; after
+// quarantineThreshold of them in a row, or a single consent page, the cookie
+// is quarantined. Other errors such as timeouts are not the cookie's fault
+// and are ignored.
+func (s *Store) Report(id string, err error) {
+	var blocked bool
+	if fetch.Unsent(err) {
+		return
+	}
+	if err != nil {
+		if _, _, blocked = fetch.ErrorStatus(err); !blocked {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cookie, ok := s.cookies[id]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if !blocked {
+		cookie.Successes++
+		cookie.ConsecutiveFailures = 0
+		cookie.LastError = ""
+		s.persistLater(cookie)
+		return
+	}
+
+	cookie.Failures++
+	cookie.ConsecutiveFailures++
+	cookie.LastBlock = &now
+	cookie.LastError = err.Error()
+
+	if cookie.ConsecutiveFailures >= quarantineThreshold || errors.Is(err, fetch.ErrConsent) {
+		until := now.Add(quarantineDuration(cookie.Quarantines))
+		cookie.QuarantinedUntil = &until
+		cookie.Quarantines++
+		log.Printf("Quarantined cookie %s until %s: %v", cookie.ID, until.Format(time.RFC3339), err)
+		// Other instances sharing the backend should stop using it now
+		s.persist(cookie)
+		return
+	}
+	s.persistLater(cookie)
+}
+
+func quarantineDuration(previous int) time.Duration {
+	d := time.Duration(float64(baseQuarantine) * math.Pow(2, float64(previous)))
+	if d <= 0 || d > maxQuarantine {
+		return maxQuarantine
+	}
+	return d
+}
+
+// Add stores a new cookie and returns it with its ID. Value, Region (a
+// country code, empty for every region), Source, Profile and ExpiresAt are
+// taken from the given cookie; health fields start from zero.
+func (s *Store) Add(c Cookie) (Cookie, error) {
+	value := strings.TrimSpace(c.Value)
+	if value == "" || !strings.Contains(value, "=") {
+		return Cookie{}, fmt.Errorf("cookie value must be a Cookie header such as \"NID=...; AEC=...\"")
+	}
+	if c.Region != "" && !knownCountry(c.Region) {
+		return Cookie{}, fmt.Errorf("unknown country code: %s", c.Region)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Cookie{}, err
+	}
+
+	cookie := &Cookie{
+		ID:        id,
+		Value:     value,
+		Region:    c.Region,
+		Source:    c.Source,
+		Profile:   c.Profile,
+		AddedAt:   time.Now(),
+		ExpiresAt: c.ExpiresAt,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	s.cookies[id] = cookie
+	if err := s.backend.Put(*cookie); err != nil {
+		delete(s.cookies, id)
+		return Cookie{}, fmt.Errorf("failed to save cookie: %v", err)
+	}
+	return *cookie, nil
+}
+
+// Remove deletes a cookie from the store
+func (s *Store) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	if _, ok := s.cookies[id]; !ok {
+		return ErrNotFound
+	}
+	if err := s.backend.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete cookie: %v", err)
+	}
+	delete(s.cookies, id)
+	delete(s.dirty, id)
+	return nil
+}
+
+// Restore takes a cookie out of quarantine and clears its failure streak
+func (s *Store) Restore(id string) (Cookie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	cookie, ok := s.cookies[id]
+	if !ok {
+		return Cookie{}, ErrNotFound
+	}
+
+	cookie.QuarantinedUntil = nil
+	cookie.ConsecutiveFailures = 0
+	s.persist(cookie)
+	return *cookie, nil
+}
+
+// List returns every stored cookie, oldest first
+func (s *Store) List() []Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	cookies := make([]Cookie, 0, len(s.cookies))
+	for _, cookie := range s.cookies {
+		cookies = append(cookies, *cookie)
+	}
+	sort.Slice(cookies, func(i, j int) bool {
+		return cookies[i].AddedAt.Before(cookies[j].AddedAt)
+	})
+	return cookies
+}
+
+func knownCountry(gl string) bool {
+	for _, region := range config.RegionConfigs {
+		if region.Gl == gl {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate cookie ID: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
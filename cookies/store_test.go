@@ -0,0 +1,112 @@
+package cookies
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"googlescrapper/fetch"
+)
+
+// memoryBackend records the writes made to it
+type memoryBackend struct {
+	mu      sync.Mutex
+	cookies []Cookie
+	puts    [][]Cookie
+}
+
+func (b *memoryBackend) Load() ([]Cookie, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Cookie(nil), b.cookies...), nil
+}
+
+func (b *memoryBackend) Put(cookies ...Cookie) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.puts = append(b.puts, cookies)
+	return nil
+}
+
+func (b *memoryBackend) Delete(id string) error { return nil }
+
+func (b *memoryBackend) putCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.puts)
+}
+
+func newTestStore(ids ...string) (*Store, *memoryBackend) {
+	backend := &memoryBackend{}
+	for _, id := range ids {
+		backend.cookies = append(backend.cookies, Cookie{ID: id, Value: "NID=1", AddedAt: time.Now()})
+	}
+	store := NewStore(backend)
+	store.mu.Lock()
+	store.load()
+	store.mu.Unlock()
+	return store, backend
+}
+
+func TestReportBatchesWrites(t *testing.T) {
+	store, backend := newTestStore("a", "b")
+
+	for i := 0; i < 50; i++ {
+		store.Report("a", nil)
+		store.Report("b", nil)
+	}
+	if n := backend.putCount(); n != 0 {
+		t.Fatalf("Report wrote to the backend %d times before the flush", n)
+	}
+
+	store.flush()
+	if n := backend.putCount(); n != 1 {
+		t.Fatalf("flush made %d writes, want 1", n)
+	}
+	if batch := backend.puts[0]; len(batch) != 2 || batch[0].Successes != 50 || batch[1].Successes != 50 {
+		t.Errorf("flush wrote %+v, want both cookies with 50 successes", batch)
+	}
+
+	store.flush()
+	if n := backend.putCount(); n != 1 {
+		t.Errorf("flush with nothing queued wrote to the backend")
+	}
+}
+
+func TestReportPersistsQuarantineImmediately(t *testing.T) {
+	store, backend := newTestStore("a")
+	blocked := &fetch.UpstreamError{Upstream: fetch.UpstreamGoogle, Err: fetch.ErrConsent}
+
+	store.Report("a", blocked)
+	if n := backend.putCount(); n != 1 {
+		t.Fatalf("quarantine made %d writes, want 1", n)
+	}
+	if cookie := backend.puts[0][0]; cookie.QuarantinedUntil == nil || cookie.Failures != 1 {
+		t.Errorf("wrote %+v, want a quarantined cookie with one failure", cookie)
+	}
+}
+
+func TestReportIgnoresOtherErrors(t *testing.T) {
+	store, _ := newTestStore("a")
+	store.Report("a", errors.New("timeout"))
+
+	if len(store.dirty) != 0 {
+		t.Errorf("a timeout queued the cookie for writing")
+	}
+}
+
+func TestReloadKeepsUnflushedOutcomes(t *testing.T) {
+	store, _ := newTestStore("a")
+	store.Report("a", nil)
+
+	store.mu.Lock()
+	store.loadedAt = time.Time{}
+	store.load()
+	successes := store.cookies["a"].Successes
+	store.mu.Unlock()
+
+	if successes != 1 {
+		t.Errorf("reload reset the unflushed successes to %d", successes)
+	}
+}
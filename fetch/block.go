@@ -0,0 +1,168 @@
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Upstreams with dedicated block detection
+const (
+	UpstreamGoogle    = "google"
+	UpstreamBing      = "bing"
+	UpstreamMintGenie = "mintgenie"
+)
+
+// Sentinel errors for responses that didn't contain the requested content
+var (
+	ErrBlocked     = errors.New("upstream blocked the request")
+	ErrCaptcha     = errors.New("upstream served a captcha")
+	ErrConsent     = errors.New("upstream served a consent interstitial")
+	ErrRateLimited = errors.New("upstream rate limited the request")
+)
+
+// Sentinel errors for requests stopped before reaching the upstream
+var (
+	ErrThrottled   = errors.New("request throttled to stay within the upstream's rate limit")
+	ErrCircuitOpen = errors.New("circuit breaker is open after repeated upstream failures")
+	ErrNoProxy     = errors.New("no proxy is available")
+)
+
+// Unsent reports whether err stopped a request before it reached the
+// upstream, so it says nothing about the upstream, proxy or cookie
+func Unsent(err error) bool {
+	return errors.Is(err, ErrThrottled) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrNoProxy)
+}
+
+// Default Retry-After values when the upstream doesn't send one
+const (
+	rateLimitedRetryAfter = 60 * time.Second
+	blockedRetryAfter     = 5 * time.Minute
+	noProxyRetryAfter     = time.Minute
+)
+
+// UpstreamError describes a blocked, captcha, consent or rate-limited
+// response, or a request that was throttled or stopped by an open circuit
+// breaker. It unwraps to one of the sentinel errors.
+type UpstreamError struct {
+	Upstream   string
+	Err        error
+	StatusCode int
+	URL        string
+	RetryAfter time.Duration
+}
+
+func (e *UpstreamError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s: %v", e.Upstream, e.Err)
+	}
+	return fmt.Sprintf("%s: %v (status %d)", e.Upstream, e.Err, e.StatusCode)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// ServeStale reports whether an expired cached result should be served
+// instead of the error. It is true for requests that never reached the
+// upstream because of throttling or an open circuit.
+func (e *UpstreamError) ServeStale() bool {
+	return Unsent(e.Err)
+}
+
+// Body markers of each upstream's interstitial pages
+var (
+	googleCaptchaMarkers = [][]byte{
+		[]byte("detected unusual traffic"),
+		[]byte(`id="captcha-form"`),
+		[]byte("g-recaptcha"),
+	}
+	googleConsentMarkers = [][]byte{
+		[]byte(`action="https://consent.google.`),
+		[]byte("consent.google.com/save"),
+	}
+	bingCaptchaMarkers = [][]byte{
+		[]byte(`id="b_captcha"`),
+		[]byte("/challenge/verify"),
+		[]byte("cf-challenge"),
+	}
+	accessDeniedMarkers = [][]byte{
+		[]byte("<title>Access Denied</title>"),
+		[]byte("Request blocked."),
+	}
+)
+
+// DetectBlock inspects a response from the given upstream and returns an
+// *UpstreamError when it is a block, captcha, consent or rate-limit page.
+// It is exported so browser-rendered pages can be checked too.
+func DetectBlock(upstream string, statusCode int, pageURL string, body []byte) error {
+	newErr := func(err error) error {
+		return &UpstreamError{Upstream: upstream, Err: err, StatusCode: statusCode, URL: pageURL}
+	}
+
+	switch upstream {
+	case UpstreamGoogle:
+		switch {
+		case strings.Contains(pageURL, "/sorry/") || containsAny(body, googleCaptchaMarkers):
+			return newErr(ErrCaptcha)
+		case strings.Contains(pageURL, "consent.google.") || containsAny(body, googleConsentMarkers):
+			return newErr(ErrConsent)
+		}
+	case UpstreamBing:
+		if containsAny(body, bingCaptchaMarkers) {
+			return newErr(ErrCaptcha)
+		}
+	case UpstreamMintGenie:
+		if containsAny(body, accessDeniedMarkers) {
+			return newErr(ErrBlocked)
+		}
+	}
+
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return newErr(ErrRateLimited)
+	case http.StatusForbidden:
+		return newErr(ErrBlocked)
+	case http.StatusUnauthorized:
+		if upstream == UpstreamMintGenie {
+			return newErr(ErrBlocked)
+		}
+	}
+	return nil
+}
+
+func containsAny(body []byte, markers [][]byte) bool {
+	for _, marker := range markers {
+		if bytes.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorStatus maps an upstream error to the HTTP status and Retry-After
+// delay to answer with
+func ErrorStatus(err error) (int, time.Duration, bool) {
+	var status int
+	var retryAfter time.Duration
+
+	switch {
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrThrottled):
+		status, retryAfter = http.StatusTooManyRequests, rateLimitedRetryAfter
+	case errors.Is(err, ErrNoProxy):
+		status, retryAfter = http.StatusServiceUnavailable, noProxyRetryAfter
+	case errors.Is(err, ErrCaptcha), errors.Is(err, ErrBlocked), errors.Is(err, ErrConsent), errors.Is(err, ErrCircuitOpen):
+		status, retryAfter = http.StatusServiceUnavailable, blockedRetryAfter
+	default:
+		return 0, 0, false
+	}
+
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) && upstreamErr.RetryAfter > 0 {
+		retryAfter = upstreamErr.RetryAfter
+	}
+	return status, retryAfter, true
+}
@@ -0,0 +1,86 @@
+package fetch
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestDetectBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		upstream string
+		status   int
+		url      string
+		fixture  string
+		want     error // nil when the page isn't a block
+	}{
+		{"google results", UpstreamGoogle, http.StatusOK, "https://www.google.com/search?q=golang", "google_serp.html", nil},
+		{"google captcha page", UpstreamGoogle, http.StatusOK, "https://www.google.com/search?q=golang", "google_sorry.html", ErrCaptcha},
+		{"google sorry redirect", UpstreamGoogle, http.StatusTooManyRequests, "https://www.google.com/sorry/index?continue=x", "google_serp.html", ErrCaptcha},
+		{"google consent page", UpstreamGoogle, http.StatusOK, "https://www.google.com/search?q=golang", "google_consent.html", ErrConsent},
+		{"google consent redirect", UpstreamGoogle, http.StatusOK, "https://consent.google.com/ml?continue=x", "google_serp.html", ErrConsent},
+		{"google rate limited", UpstreamGoogle, http.StatusTooManyRequests, "https://www.google.com/search?q=golang", "google_serp.html", ErrRateLimited},
+		{"google forbidden", UpstreamGoogle, http.StatusForbidden, "https://www.google.com/search?q=golang", "google_serp.html", ErrBlocked},
+		{"bing captcha", UpstreamBing, http.StatusOK, "https://www.bing.com/search?q=golang", "bing_captcha.html", ErrCaptcha},
+		{"bing ignores google markers", UpstreamBing, http.StatusOK, "https://www.bing.com/search?q=golang", "google_consent.html", nil},
+		{"mintgenie access denied", UpstreamMintGenie, http.StatusOK, "https://api.mintgenie.com/x", "access_denied.html", ErrBlocked},
+		{"mintgenie unauthorized", UpstreamMintGenie, http.StatusUnauthorized, "https://api.mintgenie.com/x", "google_serp.html", ErrBlocked},
+		{"google unauthorized isn't a block", UpstreamGoogle, http.StatusUnauthorized, "https://www.google.com/search?q=golang", "google_serp.html", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := DetectBlock(tc.upstream, tc.status, tc.url, fixture(t, tc.fixture))
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("DetectBlock = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("DetectBlock = %v, want %v", err, tc.want)
+			}
+			var upstreamErr *UpstreamError
+			if !errors.As(err, &upstreamErr) || upstreamErr.Upstream != tc.upstream || upstreamErr.StatusCode != tc.status {
+				t.Errorf("DetectBlock = %#v, want an *UpstreamError for %s with status %d", err, tc.upstream, tc.status)
+			}
+		})
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{&UpstreamError{Upstream: UpstreamGoogle, Err: ErrRateLimited}, http.StatusTooManyRequests},
+		{&UpstreamError{Upstream: UpstreamGoogle, Err: ErrThrottled}, http.StatusTooManyRequests},
+		{&UpstreamError{Upstream: UpstreamGoogle, Err: ErrCaptcha}, http.StatusServiceUnavailable},
+		{&UpstreamError{Upstream: UpstreamGoogle, Err: ErrCircuitOpen}, http.StatusServiceUnavailable},
+		{errors.New("something else"), 0},
+	}
+	for _, tc := range tests {
+		status, _, ok := ErrorStatus(tc.err)
+		if status != tc.status || ok != (tc.status != 0) {
+			t.Errorf("ErrorStatus(%v) = %d, %v, want %d", tc.err, status, ok, tc.status)
+		}
+	}
+
+	err := &UpstreamError{Upstream: UpstreamGoogle, Err: ErrRateLimited, RetryAfter: 7 * time.Second}
+	if _, retryAfter, _ := ErrorStatus(err); retryAfter != err.RetryAfter {
+		t.Errorf("ErrorStatus Retry-After = %v, want the upstream's %v", retryAfter, err.RetryAfter)
+	}
+}
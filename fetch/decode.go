@@ -0,0 +1,112 @@
+package fetch
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/net/html/charset"
+)
+
+// errDecode marks failures to decompress or read a response body
+var errDecode = errors.New("failed to decode response body")
+
+// decodeBody undoes every Content-Encoding in reverse order, enforces the
+// size limit on the decoded bytes and converts the result to UTF-8
+func decodeBody(resp *http.Response, maxBodySize int64) ([]byte, error) {
+	reader := io.Reader(resp.Body)
+
+	// Decoders such as zstd hold resources until they're closed
+	var closers []io.Closer
+	defer func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}()
+
+	encodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		reader, err = decoder(strings.TrimSpace(strings.ToLower(encodings[i])), reader)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errDecode, err)
+		}
+		if c, ok := reader.(io.Closer); ok {
+			closers = append(closers, c)
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errDecode, err)
+	}
+	if int64(len(body)) > maxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+
+	return toUTF8(body, resp.Header.Get("Content-Type")), nil
+}
+
+// decoder wraps r in a reader for a single content coding
+func decoder(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "", "identity":
+		return r, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return deflateReader(r)
+	case "br":
+		return brotli.NewReader(r), nil
+	case "zstd":
+		// A single goroutine is plenty for one response body
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// deflateReader decodes the deflate coding, which RFC 9110 defines as zlib
+// wrapped data. Some servers send raw deflate streams instead, so those are
+// accepted too.
+func deflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && len(header) < 2 {
+		return nil, err
+	}
+	// A zlib header names the deflate method and is a multiple of 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// toUTF8 converts body to UTF-8 using the charset from the Content-Type
+// header or a <meta> tag. Bodies that are already valid UTF-8 are left alone
+// unless a different charset was declared.
+func toUTF8(body []byte, contentType string) []byte {
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	if name == "utf-8" || (!certain && utf8.Valid(body)) {
+		return body
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body
+	}
+	return decoded
+}
@@ -0,0 +1,75 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const page = "<html><body>hello</body></html>"
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "raw":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "zstd":
+		w, _ = zstd.NewWriter(&buf)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func response(contentEncoding string, body []byte) *http.Response {
+	header := http.Header{"Content-Type": {"text/html; charset=utf-8"}}
+	header.Set("Content-Encoding", contentEncoding)
+	return &http.Response{Header: header, Body: io.NopCloser(bytes.NewReader(body))}
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := map[string]*http.Response{
+		"identity":    response("", []byte(page)),
+		"gzip":        response("gzip", compress(t, "gzip", []byte(page))),
+		"zlib":        response("deflate", compress(t, "zlib", []byte(page))),
+		"raw deflate": response("deflate", compress(t, "raw", []byte(page))),
+		"zstd":        response("zstd", compress(t, "zstd", []byte(page))),
+		"stacked":     response("deflate, gzip", compress(t, "gzip", compress(t, "zlib", []byte(page)))),
+	}
+	for name, resp := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, err := decodeBody(resp, 1<<20)
+			if err != nil {
+				t.Fatalf("decodeBody: %v", err)
+			}
+			if string(body) != page {
+				t.Errorf("decodeBody = %q, want %q", body, page)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyLimits(t *testing.T) {
+	if _, err := decodeBody(response("gzip", compress(t, "gzip", []byte(page))), 10); err != ErrBodyTooLarge {
+		t.Errorf("oversized body: err = %v, want ErrBodyTooLarge", err)
+	}
+	if _, err := decodeBody(response("compress", []byte(page)), 1<<20); err == nil {
+		t.Error("unsupported encoding decoded without an error")
+	}
+}
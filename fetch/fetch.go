@@ -0,0 +1,272 @@
+// Package fetch provides the shared HTTP client used for every upstream
+// request: pooled connections, response decoding, retries and size limits
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds a whole call, including retries, when the request
+	// doesn't set its own timeout
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxBodySize is the largest decoded body accepted by default
+	DefaultMaxBodySize = 10 << 20
+	// DefaultMaxRetries is how many times a retryable failure is retried
+	DefaultMaxRetries = 2
+
+	baseBackoff = 300 * time.Millisecond
+	maxBackoff  = 5 * time.Second
+)
+
+// ErrBodyTooLarge is returned when a decoded response exceeds MaxBodySize
+var ErrBodyTooLarge = errors.New("response body exceeds size limit")
+
+// Request describes a single upstream call
+type Request struct {
+	Method      string // Defaults to GET
+	URL         string
+	Header      http.Header
+	Body        []byte
+	Timeout     time.Duration // Deadline for the whole call, defaults to DefaultTimeout
+	MaxBodySize int64         // Defaults to DefaultMaxBodySize
+	MaxRetries  int           // Defaults to DefaultMaxRetries, negative disables retries
+	Upstream    string        // Enables block detection, e.g. UpstreamGoogle
+}
+
+// Response is a fully read, decoded upstream response
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	URL        string // Final URL after redirects
+	Body       []byte // Decompressed and converted to UTF-8
+}
+
+// StatusError is returned when the upstream answers with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	URL        string
+	RetryAfter time.Duration // Parsed from the Retry-After header, if any
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-200 status code: %d", e.StatusCode)
+}
+
+// Client performs upstream requests over a shared connection pool
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a client with its own pooled transport
+func NewClient() *Client {
+	transport := &http.Transport{
+		Proxy: proxyFromContext,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// Accept-Encoding is set by callers and decoded in decodeBody
+		DisableCompression: true,
+	}
+
+	return &Client{
+		httpClient: &http.Client{Transport: transport},
+	}
+}
+
+type proxyKey struct{}
+
+// WithProxy returns a context that sends requests made with it through the
+// given http, https or socks5 proxy
+func WithProxy(ctx context.Context, proxyURL *url.URL) context.Context {
+	return context.WithValue(ctx, proxyKey{}, proxyURL)
+}
+
+// proxyFromContext uses the proxy attached with WithProxy, falling back to
+// the HTTP_PROXY/HTTPS_PROXY environment variables
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	if proxyURL, ok := req.Context().Value(proxyKey{}).(*url.URL); ok && proxyURL != nil {
+		return proxyURL, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// DefaultClient is shared by all scrapers
+var DefaultClient = NewClient()
+
+// Do performs the request with DefaultClient
+func Do(ctx context.Context, req Request) (*Response, error) {
+	return DefaultClient.Do(ctx, req)
+}
+
+// Get performs a GET request with DefaultClient
+func Get(ctx context.Context, rawURL string, header http.Header) (*Response, error) {
+	return DefaultClient.Do(ctx, Request{URL: rawURL, Header: header})
+}
+
+// Do performs the request, retrying network errors and retryable statuses
+// with jittered exponential backoff until the call's deadline. A non-2xx
+// final response is returned together with a *StatusError.
+func (c *Client) Do(ctx context.Context, req Request) (*Response, error) {
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	if req.Timeout <= 0 {
+		req.Timeout = DefaultTimeout
+	}
+	if req.MaxBodySize <= 0 {
+		req.MaxBodySize = DefaultMaxBodySize
+	}
+	if req.MaxRetries == 0 {
+		req.MaxRetries = DefaultMaxRetries
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, req)
+		if !shouldRetry(err) || attempt >= req.MaxRetries {
+			return resp, err
+		}
+
+		wait := backoff(attempt)
+		var statusErr *StatusError
+		var upstreamErr *UpstreamError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			wait = min(statusErr.RetryAfter, maxBackoff)
+		} else if errors.As(err, &upstreamErr) && upstreamErr.RetryAfter > 0 {
+			wait = min(upstreamErr.RetryAfter, maxBackoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return resp, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// attempt performs a single round trip and reads the decoded body
+func (c *Client) attempt(ctx context.Context, req Request) (*Response, error) {
+	var body io.Reader
+	if req.Body != nil {
+		body = bytes.NewReader(req.Body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	for key, values := range req.Header {
+		httpReq.Header[key] = values
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	decoded, err := decodeBody(resp, req.MaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		URL:        resp.Request.URL.String(),
+		Body:       decoded,
+	}
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+
+	if req.Upstream != "" {
+		if err := DetectBlock(req.Upstream, resp.StatusCode, response.URL, decoded); err != nil {
+			err.(*UpstreamError).RetryAfter = retryAfter
+			return response, err
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return response, &StatusError{
+			StatusCode: resp.StatusCode,
+			URL:        response.URL,
+			RetryAfter: retryAfter,
+		}
+	}
+	return response, nil
+}
+
+// shouldRetry reports whether a failed attempt is worth repeating
+func shouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrBodyTooLarge) {
+		return false
+	}
+
+	// Only rate limiting is worth retrying; blocks and captchas persist
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		return errors.Is(err, ErrRateLimited)
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// Network errors are retried; request building and decoding errors are not
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// backoff returns a full-jitter exponential delay for the given attempt
+func backoff(attempt int) time.Duration {
+	ceiling := baseBackoff << attempt
+	if ceiling > maxBackoff || ceiling <= 0 {
+		ceiling = maxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as a date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
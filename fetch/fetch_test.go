@@ -0,0 +1,88 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// flakyServer answers with the given statuses in turn, then 200
+func flakyServer(t *testing.T, body []byte, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if int(n) <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestDoRetriesServerErrors(t *testing.T) {
+	server, calls := flakyServer(t, []byte("ok"), http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	resp, err := NewClient().Do(context.Background(), Request{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if string(resp.Body) != "ok" || *calls != 3 {
+		t.Errorf("Do = %q after %d calls, want \"ok\" after 3", resp.Body, *calls)
+	}
+}
+
+func TestDoGivesUpAfterMaxRetries(t *testing.T) {
+	server, calls := flakyServer(t, nil, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+
+	_, err := NewClient().Do(context.Background(), Request{URL: server.URL, MaxRetries: 1})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Do = %v, want a 500 *StatusError", err)
+	}
+	if *calls != 2 {
+		t.Errorf("made %d calls, want 2", *calls)
+	}
+}
+
+func TestDoDoesNotRetry(t *testing.T) {
+	tests := map[string]struct {
+		status   int
+		body     []byte
+		upstream string
+		retries  int
+	}{
+		"client error":     {http.StatusNotFound, nil, "", 0},
+		"captcha":          {http.StatusOK, []byte(`<form id="captcha-form">`), UpstreamGoogle, 0},
+		"retries disabled": {http.StatusServiceUnavailable, nil, "", -1},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, calls := flakyServer(t, tc.body, tc.status, tc.status)
+
+			_, err := NewClient().Do(context.Background(), Request{URL: server.URL, Upstream: tc.upstream, MaxRetries: tc.retries})
+			if err == nil {
+				t.Fatal("Do succeeded, want an error")
+			}
+			if *calls != 1 {
+				t.Errorf("made %d calls, want 1", *calls)
+			}
+		})
+	}
+}
+
+func TestDoRetriesRateLimits(t *testing.T) {
+	server, calls := flakyServer(t, []byte("ok"), http.StatusTooManyRequests)
+
+	resp, err := NewClient().Do(context.Background(), Request{URL: server.URL, Upstream: UpstreamGoogle})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if string(resp.Body) != "ok" || *calls != 2 {
+		t.Errorf("Do = %q after %d calls, want \"ok\" after 2", resp.Body, *calls)
+	}
+}
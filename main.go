@@ -1,7 +1,16 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"googlescrapper/admin"
+	"googlescrapper/api"
+	"googlescrapper/apierror"
+	"googlescrapper/auth"
+	"googlescrapper/cookies"
+	"googlescrapper/openapi"
+	"googlescrapper/proxy"
 	"googlescrapper/scraper"
 	"googlescrapper/search"
 	"googlescrapper/stock"
@@ -13,32 +22,104 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func main() {
-	search.ReadUserAgents()
+// newRouter registers every route the server handles
+func newRouter() *mux.Router {
 	router := mux.NewRouter()
+	router.NotFoundHandler = apierror.NotFoundHandler()
+	router.MethodNotAllowedHandler = apierror.MethodNotAllowedHandler()
+
+	// Versioned API, parameters in the query string or a JSON body
+	v1 := router.PathPrefix("/v1").Subrouter()
+	v1.HandleFunc("/search", search.V1SearchHandler).Methods("GET", "POST")
+	v1.HandleFunc("/web", search.V1WebHandler).Methods("GET", "POST")
+	v1.HandleFunc("/images", search.V1ImagesHandler).Methods("GET", "POST")
+	v1.HandleFunc("/shopping", search.V1ShoppingHandler).Methods("GET", "POST")
+	v1.HandleFunc("/news", search.V1NewsHandler).Methods("GET", "POST")
+	v1.HandleFunc("/local", search.V1LocalHandler).Methods("GET", "POST")
+	v1.HandleFunc("/finance", search.V1FinanceHandler).Methods("GET", "POST")
+	v1.HandleFunc("/bing/search", search.V1BingHandler).Methods("GET", "POST")
+	v1.HandleFunc("/bing/images", search.V1BingImagesHandler).Methods("GET", "POST")
+	v1.HandleFunc("/bing/news", search.V1BingNewsHandler).Methods("GET", "POST")
+	v1.HandleFunc("/html", search.V1HTMLHandler).Methods("GET", "POST")
+	v1.HandleFunc("/regions", search.V1RegionsHandler).Methods("GET")
+	v1.HandleFunc("/stock/chart", stock.V1ChartHandler).Methods("GET", "POST")
+	v1.HandleFunc("/stock/price", stock.V1PriceHandler).Methods("GET", "POST")
+	v1.HandleFunc("/stock/live", stock.V1LiveHandler).Methods("GET", "POST")
+	v1.HandleFunc("/stock/forecast", stock.V1ForecastHandler).Methods("GET", "POST")
+	v1.HandleFunc("/stock/shareholdings", stock.V1ShareholdingsHandler).Methods("GET", "POST")
+	v1.HandleFunc("/stock/overview", stock.V1OverviewHandler).Methods("GET", "POST")
+	v1.HandleFunc("/scrape", scraper.V1ScrapeHandler).Methods("GET", "POST")
+	v1.HandleFunc("/clean-html", scraper.V1CleanHTMLHandler).Methods("GET", "POST")
+	v1.HandleFunc("/usage", auth.V1UsageHandler).Methods("GET")
+
+	// Deprecated routes, kept as aliases of their /v1 successors
+	router.HandleFunc("/search/{query}/{location}/{maxResults}/{latitude}/{longitude}/{useCoords}", api.Deprecated("/v1/search", search.StandardSearchHandler)).Methods("GET")
+	router.HandleFunc("/finance/{symbol}", api.Deprecated("/v1/finance", search.StandardFinanceHandler))
+	router.HandleFunc("/image/{query}", api.Deprecated("/v1/images", search.StandardImageHandler))
+	router.HandleFunc("/shopping/{query}", api.Deprecated("/v1/shopping", search.StandardShoppingHandler))
+	router.HandleFunc("/news/{query}", api.Deprecated("/v1/news", search.StandardNewsHandler)).Methods("GET")
+	router.HandleFunc("/local/{query}", api.Deprecated("/v1/local", search.StandardLocalHandler)).Methods("GET")
+	router.HandleFunc("/bing/images/{query}", api.Deprecated("/v1/bing/images", search.StandardBingImagesHandler)).Methods("GET")
+	router.HandleFunc("/bing/news/{query}", api.Deprecated("/v1/bing/news", search.StandardBingNewsHandler)).Methods("GET")
+	router.HandleFunc("/bing/{query}", api.Deprecated("/v1/bing/search", search.StandardBingHandler))
+	router.HandleFunc("/html", api.Deprecated("/v1/html", search.GetHTMLFromUrl))
+	router.HandleFunc("/regions", api.Deprecated("/v1/regions", search.RegionsHandler)).Methods("GET")
+	router.HandleFunc("/stock/charts", api.Deprecated("/v1/stock/chart", stock.GetCharts)).Methods("GET", "POST")
+	router.HandleFunc("/stock/live/{tickerId}", api.Deprecated("/v1/stock/live", stock.GetLivePricePred))
+	router.HandleFunc("/stock/shareholdings/{tickerId}/{type}", api.Deprecated("/v1/stock/shareholdings", stock.GetShareholdingsHandler))
+	router.HandleFunc("/stock/live-price/{tickerId}", api.Deprecated("/v1/stock/price", stock.GetLivePriceV2Handler))
+	router.HandleFunc("/stock/forecast/{tickerId}", api.Deprecated("/v1/stock/forecast", stock.GetStockForecastHandler)).Methods("GET")
+	router.HandleFunc("/scrape/{stockIdentifier}", api.Deprecated("/v1/stock/overview", stock.ScrapeStockData)).Methods("GET")
+	router.HandleFunc("/scrape-url", api.Deprecated("/v1/scrape", scraper.ScrapeURLHandler)).Methods("POST")
+	router.HandleFunc("/clean-html", api.Deprecated("/v1/clean-html", scraper.GetCleanHTMLHandler)).Methods("POST")
+
+	// Admin endpoints, guarded by ADMIN_TOKEN
+	adminRouter := router.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(admin.RequireToken)
+	adminRouter.HandleFunc("/cookies", cookies.ListHandler).Methods("GET")
+	adminRouter.HandleFunc("/cookies", cookies.AddHandler).Methods("POST")
+	adminRouter.HandleFunc("/cookies/bootstrap", cookies.BootstrapHandler).Methods("POST")
+	adminRouter.HandleFunc("/cookies/{id}", cookies.DeleteHandler).Methods("DELETE")
+	adminRouter.HandleFunc("/cookies/{id}/restore", cookies.RestoreHandler).Methods("POST")
+	adminRouter.HandleFunc("/proxies", proxy.StatsHandler).Methods("GET")
+
+	// API description
+	router.HandleFunc("/openapi.json", openapi.SpecHandler).Methods("GET")
+	router.HandleFunc("/docs", openapi.DocsHandler).Methods("GET")
+	return router
+}
+
+// generateKey prints a new API key and the entry to store for it
+func generateKey(id, name string) error {
+	secret, key, err := auth.GenerateKey(id, name)
+	if err != nil {
+		return err
+	}
+	entry, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("API key (give it to the client, it isn't stored): %s\n\n", secret)
+	fmt.Printf("Add this entry to the api_keys.json array:\n%s\n\n", entry)
+	fmt.Printf("Or, with API_KEY_STORE=redis:\nredis-cli HSET auth:keys %s '%s'\n", key.ID, entry)
+	return nil
+}
+
+func main() {
+	keyID := flag.String("generate-key", "", "print a new API key with this ID and its stored entry, then exit")
+	keyName := flag.String("key-name", "", "name of the key made by -generate-key")
+	flag.Parse()
+	if *keyID != "" {
+		if err := generateKey(*keyID, *keyName); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	// Define routes
-	router.HandleFunc("/search/{query}/{location}/{maxResults}/{latitude}/{longitude}/{useCoords}", search.StandardSearchHandler).Methods("GET")
-	router.HandleFunc("/finance/{symbol}", search.StandardFinanceHandler)
-	router.HandleFunc("/image/{query}", search.StandardImageHandler)
-	router.HandleFunc("/shopping/{query}", search.StandardShoppingHandler)
-	router.HandleFunc("/bing/{query}", search.StandardBingHandler)
-	router.HandleFunc("/html", search.GetHTMLFromUrl)
-	router.HandleFunc("/stock/charts", stock.GetCharts)
-	router.HandleFunc("/stock/live/{tickerId}", stock.GetLivePricePred)
-	router.HandleFunc("/stock/shareholdings/{tickerId}/{type}", stock.GetShareholdingsHandler)
-	router.HandleFunc("/stock/live-price/{tickerId}", stock.GetLivePriceV2Handler)
-	router.HandleFunc("/stock/forecast/{tickerId}", stock.GetStockForecastHandler).Methods("GET")
-	router.HandleFunc("/scrape/{stockIdentifier}", stock.ScrapeStockData).Methods("GET")
-
-	// Add the URL scraper endpoints
-	router.HandleFunc("/scrape-url", scraper.ScrapeURLHandler).Methods("POST")
-	router.HandleFunc("/clean-html", scraper.GetCleanHTMLHandler).Methods("POST") // New endpoint for clean HTML
-
-	// Read environment variables
-	redisAddr := os.Getenv("REDIS_ADDR")
-	if redisAddr == "" {
-		redisAddr = "localhost:6379"
+	router := newRouter()
+	if err := openapi.Verify(router); err != nil {
+		log.Fatalf("OpenAPI document is out of date: %v", err)
 	}
 
 	port := os.Getenv("PORT")
@@ -50,8 +131,12 @@ func main() {
 	corsHandler := handlers.CORS(
 		handlers.AllowedOrigins([]string{"*"}),
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
-	)(router)
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", apierror.RequestIDHeader, auth.APIKeyHeader}),
+		handlers.ExposedHeaders([]string{
+			apierror.RequestIDHeader, "Retry-After", search.RenderPathHeader, "Deprecation", "Link",
+			auth.LimitHeader, auth.RemainingHeader, auth.ResetHeader,
+		}),
+	)(apierror.RequestID(auth.Require(router))) // Outside the router so unmatched routes get an ID too
 
 	fmt.Printf("Server is running on port %s\n", port)
 	log.Fatal(http.ListenAndServe(":"+port, corsHandler))
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"googlescrapper/openapi"
+)
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	if err := openapi.Verify(newRouter()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestVerifyReportsUndocumentedRoutes(t *testing.T) {
+	router := newRouter()
+	router.HandleFunc("/v1/undocumented", func(http.ResponseWriter, *http.Request) {}).Methods("GET")
+	router.HandleFunc("/v1/search", func(http.ResponseWriter, *http.Request) {}).Methods("DELETE")
+
+	err := openapi.Verify(router)
+	if err == nil {
+		t.Fatal("Verify accepted routes missing from the OpenAPI document")
+	}
+	for _, want := range []string{"GET /v1/undocumented", "DELETE /v1/search"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Verify error doesn't mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestVerifyReportsUnregisteredRoutes(t *testing.T) {
+	routes := openapi.Routes
+	defer func() { openapi.Routes = routes }()
+	openapi.Routes = append(routes[:len(routes):len(routes)], openapi.Route{Path: "/v1/removed", Methods: []string{"GET"}})
+
+	err := openapi.Verify(newRouter())
+	if err == nil || !strings.Contains(err.Error(), "documented but not registered: GET /v1/removed") {
+		t.Errorf("Verify = %v, want the removed route reported", err)
+	}
+}
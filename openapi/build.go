@@ -0,0 +1,295 @@
+// Package openapi describes the server's routes as an OpenAPI 3.0 document.
+// Schemas are generated from the Go response types by reflection, so they
+// follow the handlers; the route list itself is checked against the router
+// at startup by Verify.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"googlescrapper/api"
+	"googlescrapper/apierror"
+	"googlescrapper/auth"
+	"googlescrapper/search"
+)
+
+// Version is the version of the API the document describes
+const Version = "1.0.0"
+
+const (
+	apiKeyScheme     = "apiKey"
+	adminTokenScheme = "adminToken"
+	errorSchema      = "apierror.Response"
+)
+
+// Build returns the OpenAPI document of routes
+func Build(routes []Route) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "Google Scrapper API",
+			Version: Version,
+			Description: "Search, stock and scraping endpoints. The /v1 endpoints take their parameters from " +
+				"the query string or a JSON body and wrap results in a {\"data\", \"meta\"} envelope with snake_case keys. " +
+				"The other public routes are deprecated aliases kept for existing clients.",
+		},
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				apiKeyScheme: {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        auth.APIKeyHeader,
+					Description: "Requests are metered against the key's per-minute and per-day quotas; see /v1/usage",
+				},
+				adminTokenScheme: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "The ADMIN_TOKEN the server was started with",
+				},
+			},
+		},
+	}
+
+	v1, legacy := newGenerators(doc.Components.Schemas)
+	doc.Components.Schemas[errorSchema] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"error": v1.schema(reflect.TypeOf(apierror.Error{}))},
+		Required:   []string{"error"},
+	}
+
+	for _, route := range routes {
+		item := doc.Paths[route.Path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[route.Path] = item
+		}
+		gen := legacy
+		if isV1(route.Path) {
+			gen = v1
+		}
+		for _, method := range documentedMethods(route) {
+			op := gen.operation(route, method)
+			switch method {
+			case http.MethodGet:
+				item.Get = op
+			case http.MethodPost:
+				item.Post = op
+			case http.MethodPut:
+				item.Put = op
+			case http.MethodDelete:
+				item.Delete = op
+			}
+		}
+	}
+	return doc
+}
+
+func isV1(path string) bool {
+	return strings.HasPrefix(path, "/v1/")
+}
+
+// documentedMethods returns the methods a route is documented under. Routes
+// registered without a method restriction answer any method, and are
+// documented under the one their clients use.
+func documentedMethods(route Route) []string {
+	if len(route.Methods) > 0 {
+		return route.Methods
+	}
+	if route.Body != nil {
+		return []string{http.MethodPost}
+	}
+	return []string{http.MethodGet}
+}
+
+// operation describes one method of a route
+func (g *generator) operation(route Route, method string) *Operation {
+	op := &Operation{
+		Summary:     route.Summary,
+		OperationID: operationID(method, route.Path),
+		Tags:        []string{route.Tag},
+		Responses:   map[string]*Response{},
+		Deprecated:  route.Successor != "",
+	}
+	if route.Successor != "" {
+		op.Description = "Deprecated, use " + route.Successor + "."
+	}
+	switch {
+	case route.Admin:
+		op.Security = []map[string][]string{{adminTokenScheme: {}}}
+	case !route.Public:
+		op.Security = []map[string][]string{{apiKeyScheme: {}}}
+	}
+
+	for _, param := range route.PathParams {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        param.Name,
+			In:          "path",
+			Description: param.Description,
+			Required:    true,
+			Schema:      paramSchema(param),
+		})
+	}
+
+	// /v1 routes take the same parameters from a JSON body on POST
+	if isV1(route.Path) && method == http.MethodPost && len(route.Params) > 0 {
+		op.RequestBody = &RequestBody{
+			Content: map[string]*MediaType{"application/json": {Schema: paramsBodySchema(route.Params)}},
+		}
+		for _, param := range route.Params {
+			if param.Required {
+				op.RequestBody.Required = true
+			}
+		}
+	} else {
+		for _, param := range route.Params {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:        param.Name,
+				In:          "query",
+				Description: param.Description,
+				Required:    param.Required,
+				Schema:      paramSchema(param),
+			})
+		}
+	}
+
+	if route.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: g.schema(reflect.TypeOf(route.Body))}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = g.response(route, status)
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Headers: map[string]*Header{
+			apierror.RequestIDHeader: {Description: "ID of the request, also in the error body", Schema: &Schema{Type: "string"}},
+			"Retry-After":            {Description: "Seconds to wait before retrying, on retryable errors", Schema: &Schema{Type: "integer"}},
+		},
+		Content: map[string]*MediaType{"application/json": {Schema: ref(errorSchema)}},
+	}
+	return op
+}
+
+// response describes the success response of a route
+func (g *generator) response(route Route, status int) *Response {
+	resp := &Response{
+		Description: http.StatusText(status),
+		Headers: map[string]*Header{
+			apierror.RequestIDHeader: {Description: "ID of the request", Schema: &Schema{Type: "string"}},
+		},
+	}
+	for _, param := range route.Params {
+		if param.Name == "render" {
+			resp.Headers[search.RenderPathHeader] = &Header{Description: `"http" or "browser", the path that rendered the Google page`, Schema: &Schema{Type: "string"}}
+		}
+	}
+	if route.Successor != "" {
+		resp.Headers["Deprecation"] = &Header{Description: "When the route was deprecated, as @<unix time>", Schema: &Schema{Type: "string"}}
+		resp.Headers["Link"] = &Header{Description: "The successor route, rel=\"successor-version\"", Schema: &Schema{Type: "string"}}
+	}
+	if route.Response == nil {
+		return resp
+	}
+
+	contentType := route.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	schema := g.schema(reflect.TypeOf(route.Response))
+	if isV1(route.Path) {
+		schema = &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"data": schema,
+				"meta": g.schema(reflect.TypeOf(api.Meta{})),
+			},
+			Required: []string{"data", "meta"},
+		}
+	}
+	resp.Content = map[string]*MediaType{contentType: {Schema: schema}}
+	return resp
+}
+
+// paramSchema returns the schema of a path or query parameter
+func paramSchema(param api.Param) *Schema {
+	s := &Schema{Type: string(param.Type), Minimum: param.Min, Maximum: param.Max}
+	if param.Type == api.TypeDate {
+		s.Type, s.Format = "string", "date"
+	}
+	for _, value := range param.Enum {
+		s.Enum = append(s.Enum, value)
+	}
+	if param.Default != "" {
+		s.Default = typedDefault(param)
+	}
+	if param.Repeated {
+		return &Schema{Type: "array", Items: s}
+	}
+	return s
+}
+
+// paramsBodySchema returns the schema of the JSON body form of params
+func paramsBodySchema(params []api.Param) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, param := range params {
+		s.Properties[param.Name] = paramSchema(param)
+		s.Properties[param.Name].Description = param.Description
+		if param.Required {
+			s.Required = append(s.Required, param.Name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// typedDefault converts a parameter's default to the JSON type of the
+// parameter
+func typedDefault(param api.Param) interface{} {
+	switch param.Type {
+	case api.TypeInteger:
+		if n, err := strconv.Atoi(param.Default); err == nil {
+			return n
+		}
+	case api.TypeNumber:
+		if f, err := strconv.ParseFloat(param.Default, 64); err == nil {
+			return f
+		}
+	case api.TypeBoolean:
+		if b, err := strconv.ParseBool(param.Default); err == nil {
+			return b
+		}
+	}
+	return param.Default
+}
+
+// operationID derives an identifier from the method and path, e.g.
+// getV1BingSearch for GET /v1/bing/search
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range path {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"googlescrapper/apierror"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+var (
+	specOnce sync.Once
+	spec     []byte
+	specErr  error
+)
+
+// SpecHandler serves the OpenAPI document of Routes
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	specOnce.Do(func() {
+		spec, specErr = json.MarshalIndent(Build(Routes), "", "    ")
+	})
+	if specErr != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", specErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// DocsHandler serves the interactive documentation, a Swagger UI page
+// reading /openapi.json
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"net/http"
+
+	"googlescrapper/api"
+	"googlescrapper/auth"
+	"googlescrapper/config"
+	"googlescrapper/cookies"
+	"googlescrapper/finance"
+	"googlescrapper/proxy"
+	"googlescrapper/scraper"
+	"googlescrapper/search"
+	"googlescrapper/standard_search"
+	"googlescrapper/stock"
+)
+
+// Route documents a route registered in main.go. Verify fails at startup
+// when the router and this list disagree.
+type Route struct {
+	Path        string
+	Methods     []string
+	Summary     string
+	Tag         string
+	PathParams  []api.Param
+	Params      []api.Param // Query parameters, and JSON body fields of /v1 POST requests
+	Body        interface{} // JSON body of routes outside /v1
+	Response    interface{} // A value of the response type, nil when there is no body
+	Status      int         // Success status, 200 when zero
+	ContentType string      // Success content type, application/json when empty
+	Successor   string      // /v1 route replacing a deprecated route
+	Admin       bool        // Requires the admin token
+	Public      bool        // Needs no API key
+}
+
+// Tags
+const (
+	tagSearch  = "Search"
+	tagBing    = "Bing"
+	tagStock   = "Stock"
+	tagScrape  = "Scraping"
+	tagRegions = "Regions"
+	tagUsage   = "Usage"
+	tagAdmin   = "Admin"
+	tagDocs    = "Documentation"
+)
+
+var getPost = []string{http.MethodGet, http.MethodPost}
+
+// Routes lists every route of the server
+var Routes = []Route{
+	// Versioned API
+	{Path: "/v1/search", Methods: getPost, Tag: tagSearch, Summary: "Google web search", Params: search.SearchParams, Response: &search.SearchResponse{}},
+	{Path: "/v1/web", Methods: getPost, Tag: tagSearch, Summary: "Web results from Google or Bing in one schema", Params: search.WebParams, Response: &search.Results{}},
+	{Path: "/v1/images", Methods: getPost, Tag: tagSearch, Summary: "Google image search", Params: search.ImageParams, Response: []search.ImageInfo{}},
+	{Path: "/v1/shopping", Methods: getPost, Tag: tagSearch, Summary: "Google shopping search", Params: search.ShoppingParams, Response: []search.ProductInfo{}},
+	{Path: "/v1/news", Methods: getPost, Tag: tagSearch, Summary: "Google news search", Params: search.NewsParams, Response: []search.NewsArticle{}},
+	{Path: "/v1/local", Methods: getPost, Tag: tagSearch, Summary: "Google local (Maps) results around a point", Params: search.LocalParams, Response: []standard_search.LocalResult{}},
+	{Path: "/v1/finance", Methods: getPost, Tag: tagSearch, Summary: "Google Finance quote page", Params: search.FinanceParams, Response: &finance.FinanceData{}},
+	{Path: "/v1/bing/search", Methods: getPost, Tag: tagBing, Summary: "Bing web search", Params: search.BingParams, Response: search.BingInfo{}},
+	{Path: "/v1/bing/images", Methods: getPost, Tag: tagBing, Summary: "Bing image search", Params: search.BingParams, Response: []search.ImageInfo{}},
+	{Path: "/v1/bing/news", Methods: getPost, Tag: tagBing, Summary: "Bing news search", Params: search.BingParams, Response: []search.NewsArticle{}},
+	{Path: "/v1/html", Methods: getPost, Tag: tagScrape, Summary: "HTML of a page rendered in the browser", Params: search.HTMLParams, Response: search.PageHTML{}},
+	{Path: "/v1/regions", Methods: []string{http.MethodGet}, Tag: tagRegions, Summary: "Region codes accepted as location", Response: []config.Region{}},
+	{Path: "/v1/stock/chart", Methods: getPost, Tag: tagStock, Summary: "Price chart", Params: stock.ChartParams, Response: []stock.StockChartResponse{}},
+	{Path: "/v1/stock/price", Methods: getPost, Tag: tagStock, Summary: "Live price", Params: stock.TickerParams, Response: stock.LivePriceV2Response{}},
+	{Path: "/v1/stock/live", Methods: getPost, Tag: tagStock, Summary: "Live price with prediction", Params: stock.TickerParams, Response: stock.LivePriceResponse{}},
+	{Path: "/v1/stock/forecast", Methods: getPost, Tag: tagStock, Summary: "Analyst forecast", Params: stock.TickerParams, Response: stock.StockForecastResponse{}},
+	{Path: "/v1/stock/shareholdings", Methods: getPost, Tag: tagStock, Summary: "Shareholding trend", Params: stock.ShareholdingParams, Response: []stock.ShareholdingTrend{}},
+	{Path: "/v1/stock/overview", Methods: getPost, Tag: tagStock, Summary: "Price, metrics, financials, peers and news from the LiveMint stock page", Params: stock.TickerParams, Response: stock.StockData{}},
+	{Path: "/v1/scrape", Methods: getPost, Tag: tagScrape, Summary: "Main content of a page as Markdown", Params: scraper.URLParams, Response: &scraper.ScrapedContent{}},
+	{Path: "/v1/clean-html", Methods: getPost, Tag: tagScrape, Summary: "Cleaned HTML of a page without a specialized scraper", Params: scraper.URLParams, Response: scraper.CleanHTML{}},
+	{Path: "/v1/usage", Methods: []string{http.MethodGet}, Tag: tagUsage, Summary: "Usage and quotas of the API key", Response: auth.Usage{}},
+
+	// Deprecated routes
+	{
+		Path: "/search/{query}/{location}/{maxResults}/{latitude}/{longitude}/{useCoords}", Methods: []string{http.MethodGet}, Tag: tagSearch, Summary: "Google web search",
+		PathParams: []api.Param{
+			pathParam("query", api.TypeString),
+			pathParam("location", api.TypeString),
+			{Name: "maxResults", Type: api.TypeInteger, Required: true, Min: api.Limit(1), Max: api.Limit(search.MaxSearchResults)},
+			pathParam("latitude", api.TypeNumber),
+			pathParam("longitude", api.TypeNumber),
+			pathParam("useCoords", api.TypeBoolean),
+		},
+		Params:    append(pick(search.SearchParams, "city", "paa_depth", "page_token", "render"), search.QueryOptionParams...),
+		Response:  &search.SearchResponse{},
+		Successor: "/v1/search",
+	},
+	{Path: "/finance/{symbol}", Tag: tagSearch, Summary: "Google Finance quote page", PathParams: []api.Param{pathParam("symbol", api.TypeString)}, Params: omit(search.FinanceParams, "symbol"), Response: &finance.FinanceData{}, Successor: "/v1/finance"},
+	{Path: "/image/{query}", Tag: tagSearch, Summary: "Google image search", PathParams: queryPath, Params: omit(search.ImageParams, "q"), Response: []search.ImageInfo{}, Successor: "/v1/images"},
+	{Path: "/shopping/{query}", Tag: tagSearch, Summary: "Google shopping search", PathParams: queryPath, Params: omit(search.ShoppingParams, "q"), Response: []search.ProductInfo{}, Successor: "/v1/shopping"},
+	{Path: "/news/{query}", Methods: []string{http.MethodGet}, Tag: tagSearch, Summary: "Google news search", PathParams: queryPath, Params: omit(search.NewsParams, "q"), Response: []search.NewsArticle{}, Successor: "/v1/news"},
+	{Path: "/local/{query}", Methods: []string{http.MethodGet}, Tag: tagSearch, Summary: "Google local (Maps) results around a point", PathParams: queryPath, Params: omit(search.LocalParams, "q"), Response: []standard_search.LocalResult{}, Successor: "/v1/local"},
+	{Path: "/bing/images/{query}", Methods: []string{http.MethodGet}, Tag: tagBing, Summary: "Bing image search", PathParams: queryPath, Params: omit(search.BingParams, "q"), Response: []search.ImageInfo{}, Successor: "/v1/bing/images"},
+	{Path: "/bing/news/{query}", Methods: []string{http.MethodGet}, Tag: tagBing, Summary: "Bing news search", PathParams: queryPath, Params: omit(search.BingParams, "q"), Response: []search.NewsArticle{}, Successor: "/v1/bing/news"},
+	{Path: "/bing/{query}", Tag: tagBing, Summary: "Bing web search", PathParams: queryPath, Params: omit(search.BingParams, "q"), Response: search.BingInfo{}, Successor: "/v1/bing/search"},
+	{Path: "/html", Tag: tagScrape, Summary: "HTML of a page rendered in the browser", Body: urlBody{}, Response: "", ContentType: "text/plain", Successor: "/v1/html"},
+	{Path: "/regions", Methods: []string{http.MethodGet}, Tag: tagRegions, Summary: "Region codes accepted as location", Response: []config.Region{}, Successor: "/v1/regions"},
+	{Path: "/stock/charts", Methods: getPost, Tag: tagStock, Summary: "Price chart", Body: stock.StockRequest{}, Response: []stock.StockChartResponse{}, Successor: "/v1/stock/chart"},
+	{Path: "/stock/live/{tickerId}", Tag: tagStock, Summary: "Live price with prediction", PathParams: tickerPath, Response: stock.LivePriceResponse{}, Successor: "/v1/stock/live"},
+	{Path: "/stock/shareholdings/{tickerId}/{type}", Tag: tagStock, Summary: "Shareholding trend", PathParams: append(tickerPath, pathParam("type", api.TypeString)), Response: []stock.ShareholdingTrend{}, Successor: "/v1/stock/shareholdings"},
+	{Path: "/stock/live-price/{tickerId}", Tag: tagStock, Summary: "Live price", PathParams: tickerPath, Response: stock.LivePriceV2Response{}, Successor: "/v1/stock/price"},
+	{Path: "/stock/forecast/{tickerId}", Methods: []string{http.MethodGet}, Tag: tagStock, Summary: "Analyst forecast", PathParams: tickerPath, Response: stock.StockForecastResponse{}, Successor: "/v1/stock/forecast"},
+	{Path: "/scrape/{stockIdentifier}", Methods: []string{http.MethodGet}, Tag: tagStock, Summary: "Price, metrics, financials, peers and news from the LiveMint stock page", PathParams: []api.Param{pathParam("stockIdentifier", api.TypeString)}, Response: stock.StockData{}, Successor: "/v1/stock/overview"},
+	{Path: "/scrape-url", Methods: []string{http.MethodPost}, Tag: tagScrape, Summary: "Main content of a page as Markdown", Body: urlBody{}, Response: &scraper.ScrapedContent{}, Successor: "/v1/scrape"},
+	{Path: "/clean-html", Methods: []string{http.MethodPost}, Tag: tagScrape, Summary: "Cleaned HTML of a page without a specialized scraper", Body: urlBody{}, Response: scraper.CleanHTML{}, Successor: "/v1/clean-html"},
+
+	// Admin
+	{Path: "/admin/cookies", Methods: []string{http.MethodGet}, Tag: tagAdmin, Summary: "List stored cookies with their health", Response: []cookies.CookieView{}, Admin: true},
+	{Path: "/admin/cookies", Methods: []string{http.MethodPost}, Tag: tagAdmin, Summary: "Store a cookie", Body: cookies.AddCookieRequest{}, Response: cookies.CookieView{}, Status: http.StatusCreated, Admin: true},
+	{Path: "/admin/cookies/bootstrap", Methods: []string{http.MethodPost}, Tag: tagAdmin, Summary: "Bootstrap an anonymous consent cookie in the browser", Params: []api.Param{{Name: "region", Type: api.TypeString, Description: "Region code from /v1/regions"}}, Response: cookies.CookieView{}, Status: http.StatusCreated, Admin: true},
+	{Path: "/admin/cookies/{id}", Methods: []string{http.MethodDelete}, Tag: tagAdmin, Summary: "Remove a cookie", PathParams: []api.Param{pathParam("id", api.TypeString)}, Status: http.StatusNoContent, Admin: true},
+	{Path: "/admin/cookies/{id}/restore", Methods: []string{http.MethodPost}, Tag: tagAdmin, Summary: "Restore a quarantined cookie", PathParams: []api.Param{pathParam("id", api.TypeString)}, Response: cookies.CookieView{}, Admin: true},
+	{Path: "/admin/proxies", Methods: []string{http.MethodGet}, Tag: tagAdmin, Summary: "Proxy health", Response: []proxy.Stats{}, Admin: true},
+
+	// Documentation
+	{Path: "/openapi.json", Methods: []string{http.MethodGet}, Tag: tagDocs, Summary: "This OpenAPI document", Response: map[string]interface{}{}, Public: true},
+	{Path: "/docs", Methods: []string{http.MethodGet}, Tag: tagDocs, Summary: "Interactive API documentation", Response: "", ContentType: "text/html", Public: true},
+}
+
+// urlBody is the JSON body of the legacy scraping routes
+type urlBody = struct {
+	URL string `json:"url"`
+}
+
+var (
+	queryPath  = []api.Param{pathParam("query", api.TypeString)}
+	tickerPath = []api.Param{pathParam("tickerId", api.TypeString)}
+)
+
+func pathParam(name string, typ api.ParamType) api.Param {
+	return api.Param{Name: name, Type: typ, Required: true}
+}
+
+// pick returns the named parameters of params
+func pick(params []api.Param, names ...string) []api.Param {
+	var picked []api.Param
+	for _, param := range params {
+		for _, name := range names {
+			if param.Name == name {
+				picked = append(picked, param)
+			}
+		}
+	}
+	return picked
+}
+
+// omit returns params without the named parameters
+func omit(params []api.Param, names ...string) []api.Param {
+	var kept []api.Param
+	for _, param := range params {
+		if len(pick([]api.Param{param}, names...)) == 0 {
+			kept = append(kept, param)
+		}
+	}
+	return kept
+}
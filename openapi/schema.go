@@ -0,0 +1,253 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"googlescrapper/api"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// generator builds schemas from Go types by reflection and collects the named
+// ones as components. /v1 responses are written with snake_case keys by
+// api.Write while legacy routes use the plain encoding/json names, so each
+// naming gets its own generator; types whose names differ between the two are
+// registered a second time under a "legacy." prefix.
+type generator struct {
+	snake   bool
+	schemas map[string]*Schema
+	renamed map[reflect.Type]bool
+}
+
+func newGenerators(schemas map[string]*Schema) (v1, legacy *generator) {
+	renamed := make(map[reflect.Type]bool)
+	return &generator{snake: true, schemas: schemas, renamed: renamed},
+		&generator{snake: false, schemas: schemas, renamed: renamed}
+}
+
+// schema returns the schema of values of type t, as a reference for named
+// struct types
+func (g *generator) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{Description: "Any JSON value"}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler):
+		return &Schema{Description: "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: api.Limit(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Interface:
+		return &Schema{Description: "Any JSON value"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.component(t)
+	}
+	return &Schema{Description: "Any JSON value"}
+}
+
+// component registers a named struct type and returns a reference to it
+func (g *generator) component(t reflect.Type) *Schema {
+	name := g.componentName(t)
+	if _, ok := g.schemas[name]; ok {
+		return ref(name)
+	}
+
+	// Register a placeholder first so recursive types terminate
+	placeholder := &Schema{}
+	g.schemas[name] = placeholder
+	if set, ok := polymorphic[t]; ok {
+		*placeholder = *g.variantsSchema(t, name, set)
+	} else {
+		*placeholder = *g.structSchema(t)
+	}
+	return ref(name)
+}
+
+// componentName is the package-qualified type name, e.g. search.ImageInfo
+func (g *generator) componentName(t reflect.Type) string {
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	if !g.snake && g.differs(t, map[reflect.Type]bool{}) {
+		name = "legacy." + name
+	}
+	return name
+}
+
+// differs reports whether any field name reachable from t changes when
+// converted to snake_case
+func (g *generator) differs(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	if renamed, ok := g.renamed[t]; ok {
+		return renamed
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	renamed := false
+	for _, field := range structFields(t) {
+		name, _ := jsonName(field)
+		if name != api.SnakeCase(name) || g.differs(field.Type, visiting) {
+			renamed = true
+			break
+		}
+	}
+	if set, ok := polymorphic[t]; ok && !renamed {
+		for _, variant := range set.variants {
+			if variant != nil && g.differs(reflect.TypeOf(variant), visiting) {
+				renamed = true
+				break
+			}
+		}
+	}
+
+	if t.Name() != "" {
+		g.renamed[t] = renamed
+	}
+	return renamed
+}
+
+// structSchema returns the inline object schema of a struct type
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range structFields(t) {
+		name, omitempty := jsonName(field)
+		if g.snake {
+			name = api.SnakeCase(name)
+		}
+		s.Properties[name] = g.schema(field.Type)
+		if !omitempty && field.Type.Kind() != reflect.Pointer && field.Type.Kind() != reflect.Interface {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// variantsSchema returns a oneOf over the variants of a polymorphic struct,
+// each registered as its own component with the discriminator fixed
+func (g *generator) variantsSchema(t reflect.Type, name string, set variantSet) *Schema {
+	discriminator, _ := t.FieldByName(set.discriminator)
+	field, _ := t.FieldByName(set.field)
+	discriminatorName, _ := jsonName(discriminator)
+	fieldName, _ := jsonName(field)
+	if g.snake {
+		discriminatorName = api.SnakeCase(discriminatorName)
+		fieldName = api.SnakeCase(fieldName)
+	}
+
+	s := &Schema{
+		Description:   set.description,
+		Discriminator: &Discriminator{PropertyName: discriminatorName, Mapping: map[string]string{}},
+	}
+
+	keys := make([]string, 0, len(set.variants))
+	for key := range set.variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		variant := g.structSchema(t)
+		variant.Properties[discriminatorName] = &Schema{Type: "string", Enum: []interface{}{key}}
+		variantName := name + "." + key
+		if key == "" {
+			// No answer: the content is absent, and so is the discriminator
+			// when it is omitted empty
+			variantName = name + ".none"
+			delete(variant.Properties, fieldName)
+		} else {
+			variant.Properties[fieldName] = g.schema(reflect.TypeOf(set.variants[key]))
+			variant.Required = appendMissing(variant.Required, discriminatorName)
+			s.Discriminator.Mapping[key] = ref(variantName).Ref
+		}
+
+		g.schemas[variantName] = variant
+		s.OneOf = append(s.OneOf, ref(variantName))
+	}
+	return s
+}
+
+// structFields returns the fields of t that encoding/json writes, with
+// embedded structs flattened
+func structFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, structFields(embedded)...)
+				continue
+			}
+		}
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// jsonName returns the name encoding/json writes a field under and whether
+// it is omitted when empty
+func jsonName(field reflect.StructField) (string, bool) {
+	name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+options+",", ",omitempty,")
+}
+
+func appendMissing(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	list = append(list, value)
+	sort.Strings(list)
+	return list
+}
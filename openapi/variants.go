@@ -0,0 +1,49 @@
+package openapi
+
+import (
+	"reflect"
+
+	"googlescrapper/bingsearch"
+	"googlescrapper/standard_search"
+)
+
+// variantSet describes a struct whose interface{} field holds a different
+// type depending on the value of another field
+type variantSet struct {
+	discriminator string                 // Go name of the field naming the variant
+	field         string                 // Go name of the interface{} field
+	variants      map[string]interface{} // A value of the field's type per variant, "" for none
+	description   string
+}
+
+// polymorphic lists the response types documented as a oneOf of their
+// variants. A new answer box type needs an entry here.
+var polymorphic = map[reflect.Type]variantSet{
+	reflect.TypeOf(standard_search.AnswerBox{}): {
+		discriminator: "Type",
+		field:         "Content",
+		variants: map[string]interface{}{
+			"":                 nil,
+			"weather":          standard_search.WeatherBoxContent{},
+			"time":             standard_search.TimeBoxContent{},
+			"math":             standard_search.MathBoxContent{},
+			"featured_snippet": standard_search.FeaturedSnippetContent{},
+			"stock":            standard_search.StockBoxContent{},
+		},
+		description: "Google answer box, an empty object when the page has none",
+	},
+	reflect.TypeOf(bingsearch.BingAnswerBox{}): {
+		discriminator: "Type",
+		field:         "Content",
+		variants: map[string]interface{}{
+			"":                 nil,
+			"info_box":         "",
+			"weather":          "",
+			"time":             bingsearch.TimeBoxContent{},
+			"stock":            bingsearch.StockBoxContent{},
+			"person":           bingsearch.PersonBoxContent{},
+			"featured_snippet": "",
+		},
+		description: "Bing answer box, with an empty type when the page has none; info_box, weather and featured_snippet content is text",
+	},
+}
@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Verify checks that Routes documents exactly the routes registered on router,
+// method by method. A route registered without a method restriction only
+// needs an entry for its path.
+func Verify(router *mux.Router) error {
+	documented := make(map[string]bool)
+	for _, route := range Routes {
+		for _, method := range documentedMethods(route) {
+			documented[route.Path+" "+method] = true
+		}
+		if len(route.Methods) == 0 {
+			documented[route.Path] = true
+		}
+	}
+
+	registered := make(map[string]bool)
+	var problems []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil // Subrouter prefixes
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// No method restriction
+			registered[path] = true
+			if !documented[path] {
+				problems = append(problems, "missing from the OpenAPI routes: "+path)
+			}
+			return nil
+		}
+		for _, method := range methods {
+			registered[path+" "+method] = true
+			if !documented[path+" "+method] {
+				problems = append(problems, "missing from the OpenAPI routes: "+method+" "+path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, route := range Routes {
+		if len(route.Methods) == 0 {
+			if !registered[route.Path] {
+				problems = append(problems, "documented but not registered: "+route.Path)
+			}
+			continue
+		}
+		for _, method := range route.Methods {
+			if !registered[route.Path+" "+method] {
+				problems = append(problems, "documented but not registered: "+method+" "+route.Path)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("routes and OpenAPI document disagree:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+// Package profile provides browser header profiles. Each profile describes one
+// real browser version so that the User-Agent, client hints, Accept headers
+// and Accept-Language format sent with a request all agree with each other.
+//
+// net/http writes request headers in its own order, so only the chromedp
+// browser pool reproduces a browser's exact header order on the wire; HTTP
+// scrapers send the same header set the browser would.
+package profile
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Browser families with their own header sets
+const (
+	BrowserChrome  = "chrome"
+	BrowserEdge    = "edge"
+	BrowserFirefox = "firefox"
+	BrowserSafari  = "safari"
+)
+
+// Brand is one entry of the Sec-CH-UA client hints
+type Brand struct {
+	Brand       string `json:"brand"`
+	Version     string `json:"version"`      // Major version, sent in Sec-CH-UA
+	FullVersion string `json:"full_version"` // Sent in Sec-CH-UA-Full-Version-List
+}
+
+// Profile is a single browser version on a single platform
+type Profile struct {
+	Name            string  `json:"name"`
+	Browser         string  `json:"browser"` // chrome, edge, firefox or safari
+	UserAgent       string  `json:"user_agent"`
+	Platform        string  `json:"platform"` // Windows, macOS or Linux
+	PlatformVersion string  `json:"platform_version,omitempty"`
+	Brands          []Brand `json:"brands,omitempty"` // Chromium-based browsers only
+}
+
+// Chromium reports whether the profile is a Chromium-based browser, which is
+// the only kind the chromedp pool can impersonate coherently
+func (p Profile) Chromium() bool {
+	return p.Browser == BrowserChrome || p.Browser == BrowserEdge
+}
+
+// SecCHUA returns the Sec-CH-UA header value, or "" for browsers without
+// client hints
+func (p Profile) SecCHUA() string {
+	brands := make([]string, len(p.Brands))
+	for i, brand := range p.Brands {
+		brands[i] = fmt.Sprintf("%q;v=%q", brand.Brand, brand.Version)
+	}
+	return strings.Join(brands, ", ")
+}
+
+// NavigationHeader returns the headers the browser sends when the user opens
+// a page from the address bar. acceptLanguage is an Accept-Language value or
+// a single language tag; it is rewritten in the browser's own format.
+func (p Profile) NavigationHeader(acceptLanguage string) http.Header {
+	header := http.Header{}
+	header.Set("User-Agent", p.UserAgent)
+	header.Set("Accept-Language", p.AcceptLanguage(acceptLanguage))
+	header.Set("Sec-Fetch-Dest", "document")
+	header.Set("Sec-Fetch-Mode", "navigate")
+	header.Set("Sec-Fetch-Site", "none")
+	header.Set("Sec-Fetch-User", "?1")
+	header.Set("Upgrade-Insecure-Requests", "1")
+	header.Set("Priority", "u=0, i")
+
+	switch p.Browser {
+	case BrowserFirefox:
+		header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+		header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
+		header.Set("TE", "trailers")
+	case BrowserSafari:
+		header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+		header.Set("Accept-Encoding", "gzip, deflate, br")
+		header.Del("Sec-Fetch-User")
+	default:
+		header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
+		header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
+		p.setClientHints(header)
+	}
+	return header
+}
+
+// FetchHeader returns the headers the browser sends with a cross-site fetch()
+// call, as made by a page calling a JSON API
+func (p Profile) FetchHeader(acceptLanguage string) http.Header {
+	header := http.Header{}
+	header.Set("User-Agent", p.UserAgent)
+	header.Set("Accept", "*/*")
+	header.Set("Accept-Language", p.AcceptLanguage(acceptLanguage))
+	header.Set("Sec-Fetch-Dest", "empty")
+	header.Set("Sec-Fetch-Mode", "cors")
+	header.Set("Sec-Fetch-Site", "cross-site")
+	header.Set("Priority", "u=1, i")
+
+	switch p.Browser {
+	case BrowserSafari:
+		header.Set("Accept-Encoding", "gzip, deflate, br")
+	default:
+		header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
+		if p.Chromium() {
+			p.setClientHints(header)
+		}
+	}
+	return header
+}
+
+func (p Profile) setClientHints(header http.Header) {
+	header.Set("Sec-CH-UA", p.SecCHUA())
+	header.Set("Sec-CH-UA-Mobile", "?0")
+	header.Set("Sec-CH-UA-Platform", strconv.Quote(p.Platform))
+}
+
+// AcceptLanguage formats the languages in preferred the way the browser does.
+// Chromium and Safari lower the quality by 0.1 per language, Firefox spreads
+// it evenly. It defaults to US English.
+func (p Profile) AcceptLanguage(preferred string) string {
+	languages := Languages(preferred)
+	if len(languages) == 0 {
+		languages = []string{"en-US", "en"}
+	}
+
+	parts := make([]string, len(languages))
+	for i, lang := range languages {
+		if i == 0 {
+			parts[i] = lang
+			continue
+		}
+
+		q := 1 - 0.1*float64(i)
+		if p.Browser == BrowserFirefox {
+			q = 1 - float64(i)/float64(len(languages))
+		}
+		if q < 0.1 {
+			q = 0.1
+		}
+		parts[i] = lang + ";q=" + strconv.FormatFloat(q, 'f', 1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Languages extracts the language tags from an Accept-Language value in order
+// of preference. A lone regional tag such as "de-DE" is followed by its base
+// language, as browsers do.
+func Languages(acceptLanguage string) []string {
+	languages := []string{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		lang, _, _ := strings.Cut(part, ";")
+		lang = strings.TrimSpace(lang)
+		if lang == "" || lang == "*" || seen[lang] {
+			continue
+		}
+		seen[lang] = true
+		languages = append(languages, lang)
+	}
+
+	if len(languages) == 1 {
+		if base, _, ok := strings.Cut(languages[0], "-"); ok {
+			languages = append(languages, base)
+		}
+	}
+	return languages
+}
+
+//go:embed profiles.json
+var defaultProfiles []byte
+
+// Profiles holds every available profile. It is loaded from the embedded
+// profiles.json, or from the file named by PROFILES_FILE.
+var Profiles = mustLoadProfiles()
+
+func mustLoadProfiles() []Profile {
+	data := defaultProfiles
+	if path := os.Getenv("PROFILES_FILE"); path != "" {
+		custom, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read PROFILES_FILE %s: %v", path, err)
+		}
+		data = custom
+	}
+
+	profiles, err := parseProfiles(data)
+	if err != nil {
+		log.Fatalf("Failed to load header profiles: %v", err)
+	}
+	return profiles
+}
+
+func parseProfiles(data []byte) ([]Profile, error) {
+	var profiles []Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid profiles JSON: %v", err)
+	}
+
+	hasChromium := false
+	for _, p := range profiles {
+		switch {
+		case p.Name == "" || p.UserAgent == "":
+			return nil, fmt.Errorf("every profile must set name and user_agent")
+		case p.Browser != BrowserChrome && p.Browser != BrowserEdge && p.Browser != BrowserFirefox && p.Browser != BrowserSafari:
+			return nil, fmt.Errorf("profile %q has unknown browser %q", p.Name, p.Browser)
+		case p.Chromium() && len(p.Brands) == 0:
+			return nil, fmt.Errorf("profile %q must set brands", p.Name)
+		}
+		hasChromium = hasChromium || p.Chromium()
+	}
+	if !hasChromium {
+		return nil, fmt.Errorf("at least one Chromium profile is required for the browser pool")
+	}
+	return profiles, nil
+}
+
+// Get returns the profile with the given name
+func Get(name string) (Profile, bool) {
+	for _, p := range Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// Random returns a random profile, for rotating per request
+func Random() Profile {
+	return Profiles[rand.Intn(len(Profiles))]
+}
+
+// RandomChromium returns a random Chromium-based profile for the browser pool
+func RandomChromium() Profile {
+	chromium := make([]Profile, 0, len(Profiles))
+	for _, p := range Profiles {
+		if p.Chromium() {
+			chromium = append(chromium, p)
+		}
+	}
+	return chromium[rand.Intn(len(chromium))]
+}
+
+// ForSession returns the same profile for every call with the same key, so a
+// session such as a cookie always presents as one browser
+func ForSession(key string) Profile {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return Profiles[h.Sum32()%uint32(len(Profiles))]
+}
@@ -0,0 +1,26 @@
+package proxy
+
+import (
+	"encoding/json"
+	"googlescrapper/apierror"
+	"net/http"
+	"time"
+)
+
+// StatsHandler lists every proxy with its health
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	stats := make([]Stats, 0, len(Default.proxies))
+	for _, p := range Default.proxies {
+		stats = append(stats, p.Stats(now))
+	}
+
+	jsonData, err := json.MarshalIndent(stats, "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"googlescrapper/fetch"
+)
+
+func testPool(t *testing.T, entries ...Entry) *Pool {
+	t.Helper()
+	pool, err := NewPool(entries)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	return pool
+}
+
+func TestPickEmptyPoolIsDirect(t *testing.T) {
+	p, err := testPool(t).Pick("us", "")
+	if p != nil || err != nil {
+		t.Errorf("Pick on an empty pool = %v, %v, want a direct connection", p, err)
+	}
+}
+
+func TestPickAllCoolingDown(t *testing.T) {
+	pool := testPool(t, Entry{URL: "http://a.example:8080"}, Entry{URL: "http://b.example:8080"})
+	for _, p := range pool.Proxies() {
+		p.Report(time.Second, &fetch.UpstreamError{Upstream: fetch.UpstreamGoogle, Err: fetch.ErrCaptcha})
+	}
+
+	p, err := pool.Pick("us", "")
+	if p != nil || !errors.Is(err, fetch.ErrNoProxy) {
+		t.Fatalf("Pick = %v, %v, want ErrNoProxy", p, err)
+	}
+	if status, _, ok := fetch.ErrorStatus(err); !ok || status != 503 {
+		t.Errorf("ErrorStatus(ErrNoProxy) = %d, %v, want a retryable 503", status, ok)
+	}
+	if !fetch.Unsent(err) {
+		t.Error("ErrNoProxy isn't reported as unsent")
+	}
+}
+
+func TestPickPrefersCountry(t *testing.T) {
+	pool := testPool(t,
+		Entry{URL: "http://any.example:8080"},
+		Entry{URL: "http://de.example:8080", Country: "de"},
+		Entry{URL: "http://in.example:8080", Country: "in"},
+	)
+
+	for country, want := range map[string]string{"de": "de.example:8080", "in": "in.example:8080", "fr": "any.example:8080"} {
+		p, err := pool.Pick(country, "")
+		if err != nil || p.ID != want {
+			t.Errorf("Pick(%q) = %v, %v, want %s", country, p, err, want)
+		}
+	}
+}
+
+func TestPickSessionIsSticky(t *testing.T) {
+	pool := testPool(t, Entry{URL: "http://a.example:8080"}, Entry{URL: "http://b.example:8080"}, Entry{URL: "http://c.example:8080"})
+
+	first, _ := pool.Pick("", "cookie-1")
+	for i := 0; i < 20; i++ {
+		if p, _ := pool.Pick("", "cookie-1"); p != first {
+			t.Fatalf("session moved from %s to %s", first.ID, p.ID)
+		}
+	}
+
+	// The session moves only while its proxy is cooling down
+	first.Report(time.Second, &fetch.UpstreamError{Upstream: fetch.UpstreamGoogle, Err: fetch.ErrBlocked})
+	if p, _ := pool.Pick("", "cookie-1"); p == first {
+		t.Errorf("session kept the cooling down proxy %s", first.ID)
+	}
+}
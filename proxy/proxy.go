@@ -0,0 +1,212 @@
+// Package proxy manages the outbound proxies used for upstream requests:
+// loading, per-request and sticky selection by country, health scoring and
+// cooldowns for blocked proxies
+package proxy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+
+	"googlescrapper/fetch"
+)
+
+const (
+	// ewmaWeight is how much the latest request moves the success, block and
+	// latency averages
+	ewmaWeight = 0.2
+	// failureThreshold is how many network failures in a row cool a proxy down
+	failureThreshold = 3
+	// baseCooldown is the first cooldown period; it doubles with every
+	// consecutive cooldown up to maxCooldown
+	baseCooldown = time.Minute
+	maxCooldown  = 30 * time.Minute
+)
+
+// Proxy is one outbound proxy and its health
+type Proxy struct {
+	ID      string   // Host and port, unique within the pool
+	URL     *url.URL // http, https, socks5 or socks5h URL, possibly with credentials
+	Country string   // Country code (gl) of the exit IP, empty if unknown
+
+	mu                  sync.Mutex
+	requests            int
+	successes           int
+	failures            int
+	blocks              int
+	consecutiveFailures int
+	cooldowns           int
+	successRate         float64 // EWMA, starts at 1
+	blockRate           float64 // EWMA, starts at 0
+	latency             time.Duration
+	cooldownUntil       time.Time
+	lastUsed            time.Time
+	lastBlock           time.Time
+	lastError           string
+}
+
+func newProxy(u *url.URL, country string) *Proxy {
+	return &Proxy{
+		ID:          u.Host,
+		URL:         u,
+		Country:     country,
+		successRate: 1,
+	}
+}
+
+// ProxyURL returns the proxy's URL, or nil for a direct connection. It is
+// safe to call on a nil *Proxy.
+func (p *Proxy) ProxyURL() *url.URL {
+	if p == nil {
+		return nil
+	}
+	return p.URL
+}
+
+// Context attaches the proxy to ctx for fetch. A nil *Proxy leaves ctx as is.
+func (p *Proxy) Context(ctx context.Context) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return fetch.WithProxy(ctx, p.URL)
+}
+
+// Score rates the proxy between 0 and 1 from its success rate, block rate
+// and latency; higher is better
+func (p *Proxy) Score() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.score()
+}
+
+func (p *Proxy) score() float64 {
+	return p.successRate * (1 - p.blockRate) / (1 + p.latency.Seconds())
+}
+
+// Available reports whether the proxy is out of cooldown
+func (p *Proxy) Available(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !now.Before(p.cooldownUntil)
+}
+
+// Report records the outcome of a request sent through the proxy. Blocks,
+// captchas and rate limits cool the proxy down right away; network errors do
+// after failureThreshold in a row. Upstream status errors such as a 404 mean
+// the proxy itself worked. A nil *Proxy ignores the report.
+func (p *Proxy) Report(latency time.Duration, err error) {
+	if p == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.requests++
+	p.lastUsed = now
+
+	_, _, blocked := fetch.ErrorStatus(err)
+	var statusErr *fetch.StatusError
+	switch {
+	case err == nil || errors.As(err, &statusErr):
+		p.successes++
+		p.consecutiveFailures = 0
+		p.cooldowns = 0
+		p.successRate = ewma(p.successRate, 1)
+		p.blockRate = ewma(p.blockRate, 0)
+		if p.latency == 0 {
+			p.latency = latency
+		} else {
+			p.latency = time.Duration(ewma(float64(p.latency), float64(latency)))
+		}
+	case blocked:
+		p.blocks++
+		p.lastBlock = now
+		p.lastError = err.Error()
+		p.blockRate = ewma(p.blockRate, 1)
+		p.coolDown(now)
+	default:
+		p.failures++
+		p.consecutiveFailures++
+		p.lastError = err.Error()
+		p.successRate = ewma(p.successRate, 0)
+		if p.consecutiveFailures >= failureThreshold {
+			p.consecutiveFailures = 0
+			p.coolDown(now)
+		}
+	}
+}
+
+// coolDown takes the proxy out of rotation. Must be called with p.mu held.
+func (p *Proxy) coolDown(now time.Time) {
+	d := time.Duration(float64(baseCooldown) * math.Pow(2, float64(p.cooldowns)))
+	if d <= 0 || d > maxCooldown {
+		d = maxCooldown
+	}
+	p.cooldownUntil = now.Add(d)
+	p.cooldowns++
+}
+
+func ewma(average, sample float64) float64 {
+	return (1-ewmaWeight)*average + ewmaWeight*sample
+}
+
+// Stats is a snapshot of a proxy's health for the admin API
+type Stats struct {
+	ID            string     `json:"id"`
+	URL           string     `json:"url"` // Password redacted
+	Country       string     `json:"country,omitempty"`
+	Status        string     `json:"status"` // "active" or "cooldown"
+	Score         float64    `json:"score"`
+	Requests      int        `json:"requests"`
+	Successes     int        `json:"successes"`
+	Failures      int        `json:"failures"`
+	Blocks        int        `json:"blocks"`
+	SuccessRate   float64    `json:"success_rate"`
+	BlockRate     float64    `json:"block_rate"`
+	LatencyMs     int64      `json:"latency_ms"`
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+	LastUsed      *time.Time `json:"last_used,omitempty"`
+	LastBlock     *time.Time `json:"last_block,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// Stats returns a snapshot of the proxy's health
+func (p *Proxy) Stats(now time.Time) Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := Stats{
+		ID:          p.ID,
+		URL:         p.URL.Redacted(),
+		Country:     p.Country,
+		Status:      "active",
+		Score:       p.score(),
+		Requests:    p.requests,
+		Successes:   p.successes,
+		Failures:    p.failures,
+		Blocks:      p.blocks,
+		SuccessRate: p.successRate,
+		BlockRate:   p.blockRate,
+		LatencyMs:   p.latency.Milliseconds(),
+		LastError:   p.lastError,
+	}
+	if now.Before(p.cooldownUntil) {
+		stats.Status = "cooldown"
+		until := p.cooldownUntil
+		stats.CooldownUntil = &until
+	}
+	if !p.lastUsed.IsZero() {
+		lastUsed := p.lastUsed
+		stats.LastUsed = &lastUsed
+	}
+	if !p.lastBlock.IsZero() {
+		lastBlock := p.lastBlock
+		stats.LastBlock = &lastBlock
+	}
+	return stats
+}
@@ -2,6 +2,7 @@
 package scraper
 
 import (
+	"errors"
 	"net/url"
 	"strings"
 	"sync"
@@ -9,6 +10,10 @@ import (
 	"github.com/PuerkitoBio/goquery"
 )
 
+// ErrSpecializedScraper is returned by GetCleanHTML for URLs that a
+// specialized scraper already handles
+var ErrSpecializedScraper = errors.New("specialized scraper already exists for this URL")
+
 // ScrapedContent represents the extracted content in Markdown format
 type ScrapedContent struct {
 	Markdown string `json:"markdown"` // The main content in Markdown format
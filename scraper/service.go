@@ -2,12 +2,15 @@
 package scraper
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"googlescrapper/apierror"
 	"googlescrapper/browser"
 
 	"github.com/PuerkitoBio/goquery"
@@ -31,16 +34,18 @@ func NewService(pool *browser.Pool, registry *Registry) *Service {
 var DefaultService = NewService(browser.DefaultPool, DefaultRegistry)
 
 // ScrapeURL fetches a URL and scrapes its content using the appropriate scraper
-func (s *Service) ScrapeURL(urlStr string) (*ScrapedContent, error) {
+func (s *Service) ScrapeURL(ctx context.Context, urlStr string) (*ScrapedContent, error) {
 	// Validate URL
 	if !strings.HasPrefix(urlStr, "http://") && !strings.HasPrefix(urlStr, "https://") {
 		urlStr = "https://" + urlStr
 	}
 
 	// Get HTML content using browser pool
-	htmlContent, err := s.browserPool.FetchURL(urlStr, 15*time.Second)
+	fetchCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	htmlContent, err := s.browserPool.FetchURL(fetchCtx, urlStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL content: %v", err)
+		return nil, fmt.Errorf("failed to fetch URL content: %w", err)
 	}
 
 	// Parse the HTML with goquery
@@ -60,16 +65,18 @@ func (s *Service) ScrapeURL(urlStr string) (*ScrapedContent, error) {
 }
 
 // GetCleanHTML fetches a URL and returns the HTML with scripts, styles, and meta tags removed
-func (s *Service) GetCleanHTML(urlStr string) (string, error) {
+func (s *Service) GetCleanHTML(ctx context.Context, urlStr string) (string, error) {
 	// Validate URL
 	if !strings.HasPrefix(urlStr, "http://") && !strings.HasPrefix(urlStr, "https://") {
 		urlStr = "https://" + urlStr
 	}
 	
 	// Get HTML content using browser pool
-	htmlContent, err := s.browserPool.FetchURL(urlStr, 15*time.Second)
+	fetchCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	htmlContent, err := s.browserPool.FetchURL(fetchCtx, urlStr)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch URL content: %v", err)
+		return "", fmt.Errorf("failed to fetch URL content: %w", err)
 	}
 	
 	// Parse the HTML with goquery
@@ -81,7 +88,7 @@ func (s *Service) GetCleanHTML(urlStr string) (string, error) {
 	// Check if a specialized scraper exists
 	if scraper := s.registry.FindScraper(urlStr); scraper != nil && !IsGenericScraper(scraper) {
 		// If a non-fallback scraper exists, return a message
-		return "", fmt.Errorf("specialized scraper already exists for this URL")
+		return "", ErrSpecializedScraper
 	}
 	
 	// Remove unwanted elements
@@ -137,7 +144,7 @@ func CleanDocument(doc *goquery.Document) {
 func ScrapeURLHandler(w http.ResponseWriter, r *http.Request) {
 	// Only allow POST requests
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed"))
 		return
 	}
 
@@ -147,19 +154,19 @@ func ScrapeURLHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Invalid JSON body")
 		return
 	}
 
 	if requestBody.URL == "" {
-		http.Error(w, "URL parameter is required in the request body", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "URL parameter is required in the request body")
 		return
 	}
 
 	// Scrape the URL
-	content, err := DefaultService.ScrapeURL(requestBody.URL)
+	content, err := DefaultService.ScrapeURL(r.Context(), requestBody.URL)
 	if err != nil {
-		http.Error(w, "Error scraping URL: "+err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error scraping URL")
 		return
 	}
 
@@ -172,7 +179,7 @@ func ScrapeURLHandler(w http.ResponseWriter, r *http.Request) {
 func GetCleanHTMLHandler(w http.ResponseWriter, r *http.Request) {
 	// Only allow POST requests
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed"))
 		return
 	}
 	
@@ -182,26 +189,26 @@ func GetCleanHTMLHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Invalid JSON body")
 		return
 	}
 	
 	if requestBody.URL == "" {
-		http.Error(w, "URL parameter is required in the request body", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "URL parameter is required in the request body")
 		return
 	}
 	
 	// Get clean HTML from the URL
-	cleanHTML, err := DefaultService.GetCleanHTML(requestBody.URL)
+	cleanHTML, err := DefaultService.GetCleanHTML(r.Context(), requestBody.URL)
 	if err != nil {
 		// If there's a specialized scraper, let the client know
-		if strings.Contains(err.Error(), "specialized scraper already exists") {
-			http.Error(w, err.Error(), http.StatusConflict) // 409 Conflict
+		if errors.Is(err, ErrSpecializedScraper) {
+			apierror.Write(w, r, apierror.New(http.StatusConflict, apierror.CodeConflict, err.Error()))
 			return
 		}
 		
 		// Other errors
-		http.Error(w, "Error getting HTML: "+err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error getting HTML")
 		return
 	}
 	
@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"errors"
+	"net/http"
+
+	"googlescrapper/api"
+	"googlescrapper/apierror"
+)
+
+// URLParams are the parameters of the /v1 scraping endpoints
+var URLParams = []api.Param{
+	{Name: "url", Type: api.TypeString, Required: true, Description: "Page to scrape; https:// is assumed when no scheme is given"},
+}
+
+// CleanHTML is the cleaned HTML of a page
+type CleanHTML struct {
+	URL  string `json:"url"`
+	HTML string `json:"html"`
+}
+
+// V1ScrapeHandler handles GET and POST /v1/scrape
+func V1ScrapeHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, URLParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	content, err := DefaultService.ScrapeURL(r.Context(), v.Get("url"))
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping URL")
+		return
+	}
+	api.Write(w, r, content, api.Meta{})
+}
+
+// V1CleanHTMLHandler handles GET and POST /v1/clean-html
+func V1CleanHTMLHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, URLParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	html, err := DefaultService.GetCleanHTML(r.Context(), v.Get("url"))
+	if errors.Is(err, ErrSpecializedScraper) {
+		apierror.Write(w, r, apierror.New(http.StatusConflict, apierror.CodeConflict, err.Error()+", use /v1/scrape"))
+		return
+	}
+	if err != nil {
+		apierror.Handle(w, r, err, "Error getting HTML")
+		return
+	}
+	api.Write(w, r, CleanHTML{URL: v.Get("url"), HTML: html}, api.Meta{})
+}
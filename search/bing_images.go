@@ -0,0 +1,137 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"googlescrapper/apierror"
+	"googlescrapper/cache"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gorilla/mux"
+)
+
+// bingImageFreshness maps freshness values to Bing's image age filters
+var bingImageFreshness = map[string]string{
+	"day":   "+filterui:age-lt1440",
+	"week":  "+filterui:age-lt10080",
+	"month": "+filterui:age-lt43200",
+}
+
+// bingImageMeta is the JSON stored in the "m" attribute of each a.iusc link
+type bingImageMeta struct {
+	Title        string `json:"t"`
+	MediaURL     string `json:"murl"`
+	ThumbnailURL string `json:"turl"`
+	PageURL      string `json:"purl"`
+}
+
+var bingDimensionsRe = regexp.MustCompile(`(\d+)\s*[x×]\s*(\d+)`)
+
+// buildBingImagesURL creates a Bing image search URL for the given query
+func (s *BingScraper) buildBingImagesURL(query string) string {
+	params := s.bingParams(query)
+	if filter, ok := bingImageFreshness[s.config.Freshness]; ok {
+		params.Add("qft", filter)
+	}
+	s.config.Options.ApplyBing(params)
+
+	return "https://www.bing.com/images/search?" + params.Encode()
+}
+
+// BingImageScrape performs a Bing image search and returns the results
+func (s *BingScraper) BingImageScrape(ctx context.Context) ([]ImageInfo, error) {
+	cacheKey := s.cacheKey("bing_images")
+	cacheTTL := 1 * time.Hour // Cache results for 1 hour
+
+	return cache.Memoize(ctx, cacheKey, cacheTTL, func(ctx context.Context) ([]ImageInfo, error) {
+		return s.fetchBingImages(ctx)
+	})
+}
+
+// fetchBingImages renders the image results page and parses every tile
+func (s *BingScraper) fetchBingImages(ctx context.Context) ([]ImageInfo, error) {
+	htmlContent, err := s.renderBingPage(ctx, "images", s.buildBingImagesURL(s.config.Query), `a.iusc`)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	return extractBingImages(doc), nil
+}
+
+// extractBingImages parses the image tiles of a Bing image results page
+func extractBingImages(doc *goquery.Document) []ImageInfo {
+	images := []ImageInfo{}
+	doc.Find("a.iusc").Each(func(i int, a *goquery.Selection) {
+		var meta bingImageMeta
+		if err := json.Unmarshal([]byte(a.AttrOr("m", "")), &meta); err != nil || meta.MediaURL == "" {
+			return
+		}
+
+		image := ImageInfo{
+			Position:  len(images) + 1,
+			Title:     meta.Title,
+			URL:       meta.MediaURL,
+			Thumbnail: meta.ThumbnailURL,
+			SourceURL: meta.PageURL,
+		}
+		if parsed, err := url.Parse(meta.PageURL); err == nil {
+			image.SourceDomain = parsed.Hostname()
+		}
+
+		// Dimensions are shown as "1920 x 1080 · jpeg" below the tile
+		tile := a.Closest("div.imgpt")
+		if match := bingDimensionsRe.FindStringSubmatch(tile.Find(".img_info span").First().Text()); match != nil {
+			image.Width, _ = strconv.Atoi(match[1])
+			image.Height, _ = strconv.Atoi(match[2])
+		}
+
+		images = append(images, image)
+	})
+
+	return images
+}
+
+// StandardBingImagesHandler handles HTTP requests for Bing image searches
+func StandardBingImagesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := vars["query"]
+	if query == "" {
+		apierror.BadRequest(w, r, "Query parameter is required")
+		return
+	}
+
+	config, err := parseBingConfig(query, r.URL.Query())
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+
+	scraper := NewBingScraper(config)
+	images, err := scraper.BingImageScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(images, "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
@@ -0,0 +1,162 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"googlescrapper/apierror"
+	"googlescrapper/cache"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gorilla/mux"
+)
+
+// bingNewsFreshness maps freshness values to Bing News interval filters
+var bingNewsFreshness = map[string]string{
+	"day":   `interval="7"`,
+	"week":  `interval="8"`,
+	"month": `interval="9"`,
+}
+
+// bingShortTimeRe matches Bing's abbreviated ages such as "15m", "2h" or "3d"
+var bingShortTimeRe = regexp.MustCompile(`^(\d+)\s*(m|min|h|d|w|mo|y)$`)
+
+// bingTimeUnits expands the abbreviated units to the names parsePublishTime understands
+var bingTimeUnits = map[string]string{
+	"m":   "minute",
+	"min": "minute",
+	"h":   "hour",
+	"d":   "day",
+	"w":   "week",
+	"mo":  "month",
+	"y":   "year",
+}
+
+// buildBingNewsURL creates a Bing News search URL for the given query
+func (s *BingScraper) buildBingNewsURL(query string) string {
+	params := s.bingParams(query)
+	if filter, ok := bingNewsFreshness[s.config.Freshness]; ok {
+		params.Add("qft", filter)
+	}
+	s.config.Options.ApplyBing(params)
+
+	return "https://www.bing.com/news/search?" + params.Encode()
+}
+
+// BingNewsScrape performs a Bing News search and returns the articles
+func (s *BingScraper) BingNewsScrape(ctx context.Context) ([]NewsArticle, error) {
+	cacheKey := s.cacheKey("bing_news")
+	cacheTTL := 15 * time.Minute // News goes stale quickly
+
+	return cache.Memoize(ctx, cacheKey, cacheTTL, func(ctx context.Context) ([]NewsArticle, error) {
+		return s.fetchBingNews(ctx)
+	})
+}
+
+// fetchBingNews renders the news results page and parses every card
+func (s *BingScraper) fetchBingNews(ctx context.Context) ([]NewsArticle, error) {
+	htmlContent, err := s.renderBingPage(ctx, "news", s.buildBingNewsURL(s.config.Query), `div.news-card`)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	return extractBingNews(doc, time.Now()), nil
+}
+
+// extractBingNews parses the news cards of a Bing News results page,
+// resolving relative ages against now
+func extractBingNews(doc *goquery.Document, now time.Time) []NewsArticle {
+	articles := []NewsArticle{}
+	doc.Find("div.news-card").Each(func(i int, card *goquery.Selection) {
+		title := strings.TrimSpace(card.AttrOr("data-title", ""))
+		if title == "" {
+			title = strings.TrimSpace(card.Find("a.title").First().Text())
+		}
+		link := card.AttrOr("url", card.Find("a.title").AttrOr("href", ""))
+		if title == "" || link == "" {
+			return
+		}
+
+		article := NewsArticle{
+			Position:  len(articles) + 1,
+			Title:     title,
+			URL:       link,
+			Publisher: strings.TrimSpace(card.AttrOr("data-author", "")),
+			Snippet:   strings.TrimSpace(card.Find("div.snippet").First().Text()),
+		}
+
+		// The age is shown abbreviated ("2h"), with the full text in aria-label
+		if age := card.Find("div.source span[aria-label]").First(); age.Length() > 0 {
+			article.Published = strings.TrimSpace(age.AttrOr("aria-label", ""))
+			if article.Published == "" {
+				article.Published = strings.TrimSpace(age.Text())
+			}
+			article.PublishedAt = parseBingTime(article.Published, now)
+		}
+
+		if img := card.Find("div.image img").First(); img.Length() > 0 {
+			thumbnail := img.AttrOr("data-src-hq", img.AttrOr("data-src", img.AttrOr("src", "")))
+			if strings.HasPrefix(thumbnail, "/") {
+				thumbnail = "https://www.bing.com" + thumbnail
+			}
+			if !strings.HasPrefix(thumbnail, "data:") {
+				article.Thumbnail = thumbnail
+			}
+		}
+
+		articles = append(articles, article)
+	})
+
+	return articles
+}
+
+// parseBingTime resolves both "2 hours ago" and the abbreviated "2h" form
+func parseBingTime(text string, now time.Time) *time.Time {
+	text = strings.TrimSpace(text)
+	if match := bingShortTimeRe.FindStringSubmatch(strings.ToLower(text)); match != nil {
+		text = match[1] + " " + bingTimeUnits[match[2]] + " ago"
+	}
+	return parsePublishTime(text, now)
+}
+
+// StandardBingNewsHandler handles HTTP requests for Bing News searches
+func StandardBingNewsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := vars["query"]
+	if query == "" {
+		apierror.BadRequest(w, r, "Query parameter is required")
+		return
+	}
+
+	config, err := parseBingConfig(query, r.URL.Query())
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+
+	scraper := NewBingScraper(config)
+	articles, err := scraper.BingNewsScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(articles, "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
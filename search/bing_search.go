@@ -7,16 +7,20 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"googlescrapper/apierror"
 	"googlescrapper/bingsearch"
 	"googlescrapper/browser"
 	"googlescrapper/cache"
+	"googlescrapper/fetch"
+	"googlescrapper/throttle"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/cdproto/network"
@@ -42,9 +46,41 @@ type BingInfo struct {
 
 // BingConfig holds configuration for Bing searches
 type BingConfig struct {
-	Query string
+	Query      string
+	Market     string // Market code such as "en-IN" (mkt)
+	Country    string // Two letter country code (cc)
+	Language   string // UI language such as "en" or "de-DE" (setlang)
+	SafeSearch string // off, moderate or strict
+	Freshness  string // day, week or month
+	First      int    // 1-based index of the first result (first)
+	Count      int    // Results per page, up to 50 (count)
+	Options    QueryOptions
 }
 
+// bingFreshness maps freshness values to Bing's time filters
+var bingFreshness = map[string]string{
+	"day":   `ex1:"ez1"`,
+	"week":  `ex1:"ez2"`,
+	"month": `ex1:"ez3"`,
+}
+
+// bingSafeSearch lists the accepted adlt values, which are also the
+// SafeSearch levels accepted by QueryOptions
+var bingSafeSearch = map[string]bool{
+	"off":      true,
+	"moderate": true,
+	"strict":   true,
+}
+
+// maxBingCount is the largest page size Bing accepts
+const maxBingCount = 50
+
+var (
+	bingMarketRe   = regexp.MustCompile(`^[a-zA-Z]{2}-[a-zA-Z]{2}$`)
+	bingCountryRe  = regexp.MustCompile(`^[a-zA-Z]{2}$`)
+	bingLanguageRe = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z]{2,4})?$`)
+)
+
 // BingScraper handles the scraping functionality for Bing search
 type BingScraper struct {
 	config BingConfig
@@ -59,18 +95,88 @@ func NewBingScraper(config BingConfig) *BingScraper {
 
 // buildBingURL creates a Bing search URL for the given query
 func (s *BingScraper) buildBingURL(query string) string {
-	return fmt.Sprintf("https://www.bing.com/search?q=%s", url.QueryEscape(query))
+	params := s.bingParams(query)
+	if filter, ok := bingFreshness[s.config.Freshness]; ok {
+		params.Add("filters", filter)
+	}
+	s.config.Options.ApplyBing(params)
+
+	return "https://www.bing.com/search?" + params.Encode()
+}
+
+// bingParams returns the query parameters shared by every Bing vertical
+func (s *BingScraper) bingParams(query string) url.Values {
+	params := url.Values{}
+	params.Add("q", s.config.Options.Build(query))
+
+	if s.config.Market != "" {
+		params.Add("mkt", s.config.Market)
+	}
+	if s.config.Country != "" {
+		params.Add("cc", s.config.Country)
+	}
+	if s.config.Language != "" {
+		params.Add("setlang", s.config.Language)
+	}
+	if s.config.SafeSearch != "" {
+		params.Add("adlt", s.config.SafeSearch)
+	}
+	if s.config.First > 1 {
+		params.Add("first", strconv.Itoa(s.config.First))
+	}
+	if s.config.Count > 0 {
+		params.Add("count", strconv.Itoa(s.config.Count))
+	}
+
+	return params
 }
 
-// generateCacheKey creates a unique key for caching based on the query
+// acceptLanguage builds the Accept-Language header from the UI language,
+// falling back to US English
+func (s *BingScraper) acceptLanguage() string {
+	lang := s.config.Language
+	if lang == "" && s.config.Market != "" {
+		lang = s.config.Market
+	}
+	if lang == "" {
+		return "en-US,en;q=0.9"
+	}
+
+	primary := strings.SplitN(lang, "-", 2)[0]
+	if primary == lang {
+		return lang
+	}
+	return fmt.Sprintf("%s,%s;q=0.9", lang, primary)
+}
+
+// generateCacheKey creates a unique key for caching based on the query and
+// every option that changes the results
 func (s *BingScraper) generateCacheKey() string {
-	// Create a hash of the query for a consistent cache key
-	hash := md5.Sum([]byte(s.config.Query))
-	return "bing_search:" + hex.EncodeToString(hash[:])
+	return s.cacheKey("bing_search")
+}
+
+// cacheKey hashes the query and options under the given prefix
+func (s *BingScraper) cacheKey(prefix string) string {
+	c := s.config
+	key := strings.Join([]string{
+		c.Query,
+		strings.ToLower(c.Market),
+		strings.ToLower(c.Country),
+		strings.ToLower(c.Language),
+		c.SafeSearch,
+		c.Freshness,
+		strconv.Itoa(c.First),
+		strconv.Itoa(c.Count),
+		fmt.Sprintf("%+v", c.Options),
+	}, "|")
+
+	// Create a hash of the options for a consistent cache key
+	hash := md5.Sum([]byte(key))
+	return prefix + ":" + hex.EncodeToString(hash[:])
 }
 
 // BingScrape performs a Bing search and returns the results
-func (s *BingScraper) BingScrape() (BingInfo, error) {
+func (s *BingScraper) BingScrape(ctx context.Context) (BingInfo, error) {
 	// Check if the query is related to time or weather - these shouldn't be cached
 	lowerQuery := strings.ToLower(s.config.Query)
 	timeWeatherPatterns := []string{
@@ -90,74 +196,26 @@ func (s *BingScraper) BingScrape() (BingInfo, error) {
 
 	// For time/weather queries, bypass cache and fetch directly
 	if isTimeWeatherQuery {
-		return s.fetchBingResults()
+		return s.fetchBingResults(ctx)
 	}
 
 	// For other queries, use cache
 	cacheKey := s.generateCacheKey()
 	cacheTTL := 1 * time.Hour // Cache results for 1 hour
 
-	result, err := cache.Memoize(cacheKey, cacheTTL, func() (BingInfo, error) {
+	result, err := cache.Memoize(ctx, cacheKey, cacheTTL, func(ctx context.Context) (BingInfo, error) {
 		// This is the original function that will be called if cache misses
-		return s.fetchBingResults()
+		return s.fetchBingResults(ctx)
 	})
 
 	return result, err
 }
 
 // fetchBingResults performs the actual scraping of Bing search results
-func (s *BingScraper) fetchBingResults() (BingInfo, error) {
-	// Get a browser context from the pool
-	ctx, returnCtx, err := browser.DefaultPool.GetContext()
+func (s *BingScraper) fetchBingResults(ctx context.Context) (BingInfo, error) {
+	htmlContent, err := s.renderBingPage(ctx, "search", s.buildBingURL(s.config.Query), `li.b_algo`)
 	if err != nil {
-		return BingInfo{}, fmt.Errorf("failed to get browser context: %v", err)
-	}
-	defer returnCtx() // Return the context to the pool when done
-
-	searchURL := s.buildBingURL(s.config.Query)
-	var htmlContent string
-
-	// Add a timeout for this specific operation
-	timeoutCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
-
-	// Navigate to the search URL and scrape the content
-	err = chromedp.Run(timeoutCtx,
-		// Set custom headers for this request
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			return network.SetExtraHTTPHeaders(map[string]interface{}{
-				"accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
-				"accept-language":           "en-US,en;q=0.9",
-				"cache-control":             "no-cache",
-				"pragma":                    "no-cache",
-				"sec-ch-ua":                 "\"Chromium\";v=\"135\", \"Not-A.Brand\";v=\"8\"",
-				"sec-ch-ua-mobile":          "?0",
-				"sec-ch-ua-platform":        "\"Linux\"",
-				"sec-fetch-dest":            "document",
-				"sec-fetch-mode":            "navigate",
-				"sec-fetch-site":            "same-origin",
-				"sec-fetch-user":            "?1",
-				"upgrade-insecure-requests": "1",
-			}).Do(ctx)
-		}),
-		// Clear cookies to avoid personalization
-		network.ClearBrowserCookies(),
-		// Navigate to the search URL
-		chromedp.Navigate(searchURL),
-		// Wait for results to appear
-		chromedp.WaitVisible(`li.b_algo`, chromedp.ByQuery),
-		// Extract the full HTML of the page
-		chromedp.OuterHTML(`html`, &htmlContent, chromedp.ByQuery),
-	)
-
-	if err != nil {
-		return BingInfo{}, fmt.Errorf("failed to scrape content: %v", err)
-	}
-
-	// Optionally write the HTML to file for debugging
-	if err := ioutil.WriteFile("bing.html", []byte(htmlContent), 0644); err != nil {
-		// Just log error but continue with processing
-		fmt.Printf("Warning: Failed to write debug file: %v\n", err)
+		return BingInfo{}, err
 	}
 
 	// Parse the retrieved HTML with goquery
@@ -166,16 +224,19 @@ func (s *BingScraper) fetchBingResults() (BingInfo, error) {
 		return BingInfo{}, fmt.Errorf("failed to parse HTML: %v", err)
 	}
 
-	var BingLinks []BingLink
 	var BingInfos BingInfo
 	var wg sync.WaitGroup
-	var mu sync.Mutex
+
+	// Results are extracted concurrently, so each worker writes into its own
+	// slot to keep the links in page order.
+	items := doc.Find("li.b_algo")
+	slots := make([]*BingLink, items.Length())
 
 	// Use a worker pool for processing results
 	const maxWorkers = 10
 	semaphore := make(chan struct{}, maxWorkers)
 
-	doc.Find("li.b_algo").Each(func(i int, s *goquery.Selection) {
+	items.Each(func(i int, s *goquery.Selection) {
 		semaphore <- struct{}{} // Acquire token
 		wg.Add(1)
 
@@ -205,21 +266,26 @@ func (s *BingScraper) fetchBingResults() (BingInfo, error) {
 				tags = append(tags, tag.Text())
 			})
 
-			mu.Lock()
-			BingLinks = append(BingLinks, BingLink{
+			slots[i] = &BingLink{
 				Title:              title,
 				URL:                link,
 				WebsiteName:        websiteName,
 				WebsiteAttribution: websiteAttribution,
 				Tags:               tags,
 				Caption:            caption,
-			})
-			mu.Unlock()
+			}
 		}(i, s)
 	})
 
 	wg.Wait()
 
+	var BingLinks []BingLink
+	for _, link := range slots {
+		if link != nil {
+			BingLinks = append(BingLinks, *link)
+		}
+	}
+
 	// Process answer box concurrently
 	answerBoxCh := make(chan *bingsearch.BingAnswerBox, 1)
 	go func() {
@@ -240,9 +306,101 @@ func (s *BingScraper) fetchBingResults() (BingInfo, error) {
 	return BingInfos, nil
 }
 
+// renderBingPage loads a Bing URL in the browser pool, waits for
+// waitSelector to appear and returns the rendered HTML. The page load counts
+// against the vertical's rate limit and Bing's circuit breaker.
+func (s *BingScraper) renderBingPage(ctx context.Context, vertical, pageURL, waitSelector string) (htmlContent string, err error) {
+	done, err := throttle.Acquire(ctx, throttle.EngineBing, vertical)
+	if err != nil {
+		return "", err
+	}
+	defer func() { done(err) }()
+
+	// Get a browser context from the pool for a proxy in the market's country
+	p, pool, err := proxiedBrowser(s.proxyCountry())
+	if err != nil {
+		return "", err
+	}
+	tabCtx, returnCtx, err := pool.GetContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get browser context: %v", err)
+	}
+	defer returnCtx() // Return the context to the pool when done
+
+	start := time.Now()
+	defer func() { p.Report(time.Since(start), err) }()
+
+	// Add a timeout for this specific operation
+	timeoutCtx, cancel := context.WithTimeout(tabCtx, 15*time.Second)
+	defer cancel()
+
+	// Navigate to the URL and scrape the content
+	err = chromedp.Run(timeoutCtx,
+		// Present the tab's profile with the requested language
+		browser.Emulate(pool.Profile(tabCtx), s.acceptLanguage()),
+		// Clear cookies to avoid personalization
+		network.ClearBrowserCookies(),
+		// Navigate to the URL
+		chromedp.Navigate(pageURL),
+		// Wait for results to appear
+		chromedp.WaitVisible(waitSelector, chromedp.ByQuery),
+		// Extract the full HTML of the page
+		chromedp.OuterHTML(`html`, &htmlContent, chromedp.ByQuery),
+	)
+	if err != nil {
+		// The results never appeared; check whether Bing served a challenge
+		if blockErr := s.detectBingBlock(tabCtx); blockErr != nil {
+			return "", blockErr
+		}
+		return "", fmt.Errorf("failed to scrape content: %w", err)
+	}
+
+	return htmlContent, nil
+}
+
+// detectBingBlock inspects the page currently loaded in the browser for a
+// captcha or challenge
+func (s *BingScraper) detectBingBlock(ctx context.Context) error {
+	checkCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var location, htmlContent string
+	err := chromedp.Run(checkCtx,
+		chromedp.Location(&location),
+		chromedp.OuterHTML(`html`, &htmlContent, chromedp.ByQuery),
+	)
+	if err != nil {
+		return nil
+	}
+
+	return fetch.DetectBlock(fetch.UpstreamBing, 0, location, []byte(htmlContent))
+}
+
+// proxyCountry returns the country to pick proxies from: the cc parameter,
+// or the region of the market
+func (s *BingScraper) proxyCountry() string {
+	if s.config.Country != "" {
+		return strings.ToLower(s.config.Country)
+	}
+	if _, region, ok := strings.Cut(s.config.Market, "-"); ok {
+		return strings.ToLower(region)
+	}
+	return ""
+}
+
 // getHTML fetches the HTML content of a given URL
-func getHTML(url string) (string, error) {
-	return browser.DefaultPool.FetchURL(url, 15*time.Second)
+func getHTML(ctx context.Context, url string) (string, error) {
+	p, pool, err := proxiedBrowser("")
+	if err != nil {
+		return "", err
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	htmlContent, err := pool.FetchURL(fetchCtx, url)
+	p.Report(time.Since(start), err)
+	return htmlContent, err
 }
 
 // GetHTMLFromUrl handles HTTP requests to get HTML content from a URL
@@ -253,18 +411,18 @@ func GetHTMLFromUrl(w http.ResponseWriter, r *http.Request) {
 
 	// Parse the JSON body
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Invalid JSON body")
 		return
 	}
 
 	if requestBody.URL == "" {
-		http.Error(w, "URL parameter is required in the request body", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "URL parameter is required in the request body")
 		return
 	}
 
-	htmlContent, err := getHTML(requestBody.URL)
+	htmlContent, err := getHTML(r.Context(), requestBody.URL)
 	if err != nil {
-		http.Error(w, "Error scraping results: "+err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error scraping results")
 		return
 	}
 
@@ -272,29 +430,83 @@ func GetHTMLFromUrl(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(htmlContent))
 }
 
+// parseBingConfig reads the Bing options shared by every Bing vertical from
+// the request's query parameters
+func parseBingConfig(query string, params url.Values) (BingConfig, error) {
+	config := BingConfig{
+		Query:      query,
+		Market:     params.Get("mkt"),
+		Country:    params.Get("cc"),
+		Language:   params.Get("setlang"),
+		SafeSearch: strings.ToLower(params.Get("safe")),
+		Freshness:  strings.ToLower(params.Get("freshness")),
+	}
+
+	if config.Market != "" && !bingMarketRe.MatchString(config.Market) {
+		return BingConfig{}, paramError("mkt", "Invalid mkt parameter, expected a market code such as en-US")
+	}
+	if config.Country != "" && !bingCountryRe.MatchString(config.Country) {
+		return BingConfig{}, paramError("cc", "Invalid cc parameter, expected a two letter country code")
+	}
+	if config.Language != "" && !bingLanguageRe.MatchString(config.Language) {
+		return BingConfig{}, paramError("setlang", "Invalid setlang parameter")
+	}
+	if config.SafeSearch != "" && !bingSafeSearch[config.SafeSearch] {
+		return BingConfig{}, paramError("safe", "Invalid safe parameter, expected one of off, moderate, strict")
+	}
+	if _, ok := bingFreshness[config.Freshness]; config.Freshness != "" && !ok {
+		return BingConfig{}, paramError("freshness", "Invalid freshness parameter, expected one of day, week, month")
+	}
+
+	if first := params.Get("first"); first != "" {
+		value, err := strconv.Atoi(first)
+		if err != nil || value < 1 {
+			return BingConfig{}, paramError("first", "Invalid first parameter")
+		}
+		config.First = value
+	}
+	if count := params.Get("count"); count != "" {
+		value, err := strconv.Atoi(count)
+		if err != nil || value < 1 || value > maxBingCount {
+			return BingConfig{}, paramError("count", fmt.Sprintf("Invalid count parameter, expected 1-%d", maxBingCount))
+		}
+		config.Count = value
+	}
+
+	options, err := queryOptionsFromParams(params)
+	if err != nil {
+		return BingConfig{}, err
+	}
+	config.Options = options
+
+	return config, nil
+}
+
 // StandardBingHandler handles HTTP requests for Bing searches
 func StandardBingHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	query := vars["query"]
 	if query == "" {
-		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Query parameter is required")
 		return
 	}
 
-	config := BingConfig{
-		Query: query,
+	config, err := parseBingConfig(query, r.URL.Query())
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
 	}
 
 	scraper := NewBingScraper(config)
-	BingInfos, err := scraper.BingScrape()
+	BingInfos, err := scraper.BingScrape(r.Context())
 	if err != nil {
-		http.Error(w, "Error scraping results: "+err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error scraping results")
 		return
 	}
 
 	jsonData, err := json.MarshalIndent(BingInfos, "", "    ")
 	if err != nil {
-		http.Error(w, "Error marshaling to JSON", http.StatusInternalServerError)
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
 		return
 	}
 
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// loadFixture parses an HTML file from testdata
+func loadFixture(t *testing.T, name string) *goquery.Document {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", name, err)
+	}
+	return doc
+}
+
+func TestExtractBingImages(t *testing.T) {
+	images := extractBingImages(loadFixture(t, "bing_images.html"))
+
+	want := []ImageInfo{
+		{
+			Position:     1,
+			Title:        "The Go Gopher & friends",
+			URL:          "https://go.dev/blog/gopher/header.jpg",
+			Width:        1920,
+			Height:       1080,
+			Thumbnail:    "https://tse1.mm.bing.net/th?id=OIP.a1",
+			SourceURL:    "https://go.dev/blog/gopher",
+			SourceDomain: "go.dev",
+		},
+		{
+			Position:     2,
+			Title:        "File:Go gopher.png",
+			URL:          "https://upload.wikimedia.org/Go_gopher.png",
+			Width:        640,
+			Height:       480,
+			Thumbnail:    "https://tse2.mm.bing.net/th?id=OIP.b2",
+			SourceURL:    "https://commons.wikimedia.org/wiki/File:Go_gopher.png",
+			SourceDomain: "commons.wikimedia.org",
+		},
+		{
+			// Tiles without a media URL or with broken metadata are skipped
+			Position: 3,
+			Title:    "Without page or size",
+			URL:      "https://example.com/plain.png",
+		},
+	}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("extractBingImages =\n%+v\nwant\n%+v", images, want)
+	}
+}
+
+func TestExtractBingNews(t *testing.T) {
+	now := time.Date(2024, time.August, 14, 12, 0, 0, 0, time.UTC)
+	articles := extractBingNews(loadFixture(t, "bing_news.html"), now)
+
+	at := func(t time.Time) *time.Time { return &t }
+	want := []NewsArticle{
+		{
+			Position:    1,
+			Title:       "Go 1.23 adds range over functions",
+			URL:         "https://www.reuters.com/technology/go-1-23",
+			Publisher:   "Reuters",
+			Published:   "2 hours ago",
+			PublishedAt: at(now.Add(-2 * time.Hour)),
+			Snippet:     "The release lets for loops range over iterator functions.",
+			Thumbnail:   "https://www.bing.com/th?id=OVFT.go123&pid=News",
+		},
+		{
+			// Without data-title and url the title link is used, and the
+			// abbreviated age is parsed when aria-label is empty
+			Position:    2,
+			Title:       "Go developer survey results",
+			URL:         "https://www.theregister.com/go-survey",
+			Publisher:   "The Register",
+			Published:   "3d",
+			PublishedAt: at(now.AddDate(0, 0, -3)),
+			Snippet:     "Most developers are satisfied.",
+			Thumbnail:   "https://th.bing.com/th?id=OVFT.reg",
+		},
+	}
+	if !reflect.DeepEqual(articles, want) {
+		t.Errorf("extractBingNews =\n%+v\nwant\n%+v", articles, want)
+	}
+}
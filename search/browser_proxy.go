@@ -0,0 +1,22 @@
+package search
+
+import (
+	"googlescrapper/browser"
+	"googlescrapper/proxy"
+)
+
+// proxiedBrowser picks a proxy for the country and returns it together with
+// the browser pool that routes through it. The proxy is nil, and the pool
+// browser.DefaultPool, when no proxies are configured. When proxies are
+// configured but none can be used the error wraps fetch.ErrNoProxy.
+func proxiedBrowser(country string) (*proxy.Proxy, *browser.Pool, error) {
+	p, err := proxy.Default.Pick(country, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	pool, err := browser.ForProxy(p.ProxyURL())
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, pool, nil
+}
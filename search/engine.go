@@ -0,0 +1,460 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"googlescrapper/bingsearch"
+	"googlescrapper/config"
+	"googlescrapper/standard_search"
+)
+
+// SearchEngine is implemented by every web search backend so callers can
+// switch between or combine engines without per-engine adapters
+type SearchEngine interface {
+	// Name returns the identifier of the engine, e.g. "google" or "bing"
+	Name() string
+
+	// Search runs the query and returns results in the normalized schema
+	Search(ctx context.Context, q Query) (*Results, error)
+}
+
+// Query holds the engine-independent search parameters
+type Query struct {
+	Text       string
+	Location   string   // Region code from config.RegionConfigs
+	MaxResults int      // Defaults to 10 when not set
+	Latitude   *float64 // Optional latitude
+	Longitude  *float64 // Optional longitude
+	City       string   // Optional canonical location name, see utils.LookupLocation
+	Options    QueryOptions
+	PageToken  string     // Token from a previous Results.NextPage
+	Render     RenderMode // How Google pages are fetched, defaults to auto
+}
+
+// AnswerBoxType identifies the kind of answer box shown above the results
+type AnswerBoxType string
+
+const (
+	AnswerBoxWeather         AnswerBoxType = "weather"
+	AnswerBoxTime            AnswerBoxType = "time"
+	AnswerBoxMath            AnswerBoxType = "math"
+	AnswerBoxFeaturedSnippet AnswerBoxType = "featured_snippet"
+	AnswerBoxStock           AnswerBoxType = "stock"
+	AnswerBoxInfo            AnswerBoxType = "info_box"
+	AnswerBoxPerson          AnswerBoxType = "person"
+)
+
+// Result is a single organic result in the normalized schema
+type Result struct {
+	Position   int    `json:"position"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+	DisplayURL string `json:"display_url,omitempty"`
+	Snippet    string `json:"snippet,omitempty"`
+	SiteName   string `json:"site_name,omitempty"`
+}
+
+// AnswerBox is the normalized answer box. The variant field matching Type is
+// set when the engine extracted structured content; plain-text answers, such
+// as featured snippets, info boxes and Bing's weather line, are in Text.
+type AnswerBox struct {
+	Type       AnswerBoxType     `json:"type"`
+	Title      string            `json:"title,omitempty"`
+	Text       string            `json:"text,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	SourceURL  string            `json:"source_url,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	Weather *WeatherAnswer `json:"weather,omitempty"`
+	Time    *TimeAnswer    `json:"time,omitempty"`
+	Math    *MathAnswer    `json:"math,omitempty"`
+	Stock   *StockAnswer   `json:"stock,omitempty"`
+	Person  *PersonAnswer  `json:"person,omitempty"`
+}
+
+// WeatherAnswer is the content of a weather answer box
+type WeatherAnswer struct {
+	Location      string            `json:"location,omitempty"`
+	Time          string            `json:"time,omitempty"`
+	Temperature   string            `json:"temperature,omitempty"`
+	Condition     string            `json:"condition,omitempty"`
+	Precipitation string            `json:"precipitation,omitempty"`
+	Humidity      string            `json:"humidity,omitempty"`
+	Wind          string            `json:"wind,omitempty"`
+	Forecast      []WeatherForecast `json:"forecast,omitempty"`
+}
+
+// WeatherForecast is one day of a weather forecast
+type WeatherForecast struct {
+	Day       string `json:"day"`
+	High      string `json:"high,omitempty"`
+	Low       string `json:"low,omitempty"`
+	Condition string `json:"condition,omitempty"`
+}
+
+// TimeAnswer is the content of a time answer box
+type TimeAnswer struct {
+	Location string `json:"location,omitempty"`
+	Time     string `json:"time"`
+	Date     string `json:"date,omitempty"`
+	TimeZone string `json:"time_zone,omitempty"`
+}
+
+// MathAnswer is the content of a calculator answer box
+type MathAnswer struct {
+	Expression string `json:"expression,omitempty"`
+	Result     string `json:"result"`
+}
+
+// StockAnswer is the content of a stock quote answer box
+type StockAnswer struct {
+	Name          string `json:"name,omitempty"`
+	Ticker        string `json:"ticker,omitempty"`
+	Exchange      string `json:"exchange,omitempty"`
+	Price         string `json:"price,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+	Change        string `json:"change,omitempty"`
+	Open          string `json:"open,omitempty"`
+	High          string `json:"high,omitempty"`
+	Low           string `json:"low,omitempty"`
+	MarketCap     string `json:"market_cap,omitempty"`
+	PERatio       string `json:"pe_ratio,omitempty"`
+	DividendYield string `json:"dividend_yield,omitempty"`
+	Week52High    string `json:"week_52_high,omitempty"`
+	Week52Low     string `json:"week_52_low,omitempty"`
+}
+
+// PersonAnswer is the content of a person answer box
+type PersonAnswer struct {
+	Name        string `json:"name"`
+	Position    string `json:"position,omitempty"`
+	TermStarted string `json:"term_started,omitempty"`
+}
+
+// Results is the normalized response returned by every SearchEngine
+type Results struct {
+	Engine     string     `json:"engine"`
+	Query      string     `json:"query"`
+	Results    []Result   `json:"results"`
+	AnswerBox  *AnswerBox `json:"answer_box,omitempty"`
+	NextPage   string     `json:"next_page,omitempty"`
+	RenderPath string     `json:"render_path,omitempty"` // "http" or "browser", Google only
+}
+
+// Engines maps engine names to their SearchEngine implementation
+var Engines = map[string]SearchEngine{
+	"google": GoogleEngine{},
+	"bing":   BingEngine{},
+}
+
+// GetEngine looks up a registered engine by name
+func GetEngine(name string) (SearchEngine, error) {
+	engine, ok := Engines[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown search engine: %s", name)
+	}
+	return engine, nil
+}
+
+// GoogleEngine implements SearchEngine on top of SearchScraper
+type GoogleEngine struct{}
+
+// Name returns the engine identifier
+func (GoogleEngine) Name() string {
+	return "google"
+}
+
+// Search runs the query against Google and normalizes the response
+func (e GoogleEngine) Search(ctx context.Context, q Query) (*Results, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	maxResults := q.MaxResults
+	if maxResults <= 0 {
+		maxResults = 10
+	}
+
+	config := SearchConfig{
+		Query:      q.Text,
+		Location:   q.Location,
+		MaxResults: maxResults,
+		Latitude:   q.Latitude,
+		Longitude:  q.Longitude,
+		City:       q.City,
+		Options:    q.Options,
+		Render:     q.Render,
+	}
+
+	if q.PageToken != "" {
+		var err error
+		config.Start, config.Skip, config.RankOffset, err = DecodePageToken(q.PageToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scraper := NewSearchScraper(config)
+
+	response, err := scraper.Scrape(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &Results{
+		Engine:     e.Name(),
+		Query:      q.Text,
+		Results:    make([]Result, 0, len(response.Links)),
+		NextPage:   response.NextPage,
+		RenderPath: scraper.RenderPath(),
+	}
+
+	for _, link := range response.Links {
+		results.Results = append(results.Results, Result{
+			Position:   link.Position,
+			Title:      link.Title,
+			URL:        link.URL,
+			DisplayURL: link.DisplayURL,
+			Snippet:    link.Content,
+			SiteName:   link.SiteName,
+		})
+	}
+
+	results.AnswerBox = normalizeGoogleAnswerBox(response.AnswerBox)
+	return results, nil
+}
+
+// normalizeGoogleAnswerBox converts a standard_search.AnswerBox to the shared schema
+func normalizeGoogleAnswerBox(box standard_search.AnswerBox) *AnswerBox {
+	if box.Type == "" {
+		return nil
+	}
+
+	answerBox := &AnswerBox{
+		Type:      AnswerBoxType(box.Type),
+		Source:    box.Source,
+		SourceURL: box.SourceURL,
+	}
+
+	switch box.Type {
+	case string(AnswerBoxWeather):
+		var content standard_search.WeatherBoxContent
+		if contentAs(box.Content, &content) {
+			weather := &WeatherAnswer{
+				Location:      content.Location,
+				Time:          content.Time,
+				Temperature:   content.Temperature,
+				Condition:     content.Condition,
+				Precipitation: content.Precipitation,
+				Humidity:      content.Humidity,
+				Wind:          content.Wind,
+			}
+			for _, day := range content.Forecast {
+				weather.Forecast = append(weather.Forecast, WeatherForecast{Day: day.Day, High: day.HighTemp, Low: day.LowTemp, Condition: day.Condition})
+			}
+			answerBox.Weather = weather
+		}
+	case string(AnswerBoxTime):
+		var content standard_search.TimeBoxContent
+		if contentAs(box.Content, &content) {
+			answerBox.Time = &TimeAnswer{Location: content.Location, Time: content.Time, Date: content.Day}
+		}
+	case string(AnswerBoxMath):
+		var content standard_search.MathBoxContent
+		if contentAs(box.Content, &content) {
+			answerBox.Math = &MathAnswer{Expression: content.Expression, Result: content.Result}
+		}
+	case string(AnswerBoxStock):
+		var content standard_search.StockBoxContent
+		if contentAs(box.Content, &content) {
+			answerBox.Title = content.CompanyName
+			answerBox.Stock = &StockAnswer{
+				Name:          content.CompanyName,
+				Ticker:        content.TickerId,
+				Exchange:      content.Exchange,
+				Price:         content.Price,
+				Change:        content.PriceChange,
+				Open:          content.Open,
+				High:          content.High,
+				Low:           content.Low,
+				MarketCap:     content.MktCap,
+				PERatio:       content.PERatio,
+				DividendYield: content.DivYield,
+				Week52High:    content.Week52High,
+				Week52Low:     content.Week52Low,
+			}
+		}
+	case string(AnswerBoxFeaturedSnippet):
+		// Featured snippets carry their title and source inside the content
+		var content standard_search.FeaturedSnippetContent
+		if contentAs(box.Content, &content) {
+			answerBox.Title = content.Title
+			answerBox.Text = content.Description
+			answerBox.Source = content.Source
+			answerBox.SourceURL = content.SourceURL
+		}
+	}
+
+	return answerBox
+}
+
+// bingLocale returns the Bing market of a region, or its country and language
+// for regions Bing has no market for. Bing rejects numeric language subtags
+// such as the 419 in "es-419", so only the language is kept for those.
+func bingLocale(region config.RegionConfig) (market, country, language string) {
+	if region.BingMarket != "" {
+		return region.BingMarket, "", ""
+	}
+	language = region.Hl
+	if lang, subtag, ok := strings.Cut(language, "-"); ok && strings.Trim(subtag, "0123456789") == "" {
+		language = lang
+	}
+	return "", region.Gl, language
+}
+
+// BingEngine implements SearchEngine on top of BingScraper
+type BingEngine struct{}
+
+// Name returns the engine identifier
+func (BingEngine) Name() string {
+	return "bing"
+}
+
+// Search runs the query against Bing and normalizes the response
+func (e BingEngine) Search(ctx context.Context, q Query) (*Results, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	bingConfig := BingConfig{Query: q.Text, Options: q.Options}
+	bingConfig.Market, bingConfig.Country, bingConfig.Language = bingLocale(config.RegionConfigs[q.Location])
+	if q.MaxResults > 0 && q.MaxResults <= maxBingCount {
+		bingConfig.Count = q.MaxResults
+	}
+
+	scraper := NewBingScraper(bingConfig)
+
+	info, err := scraper.BingScrape(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &Results{
+		Engine:  e.Name(),
+		Query:   q.Text,
+		Results: make([]Result, 0, len(info.Links)),
+	}
+
+	for i, link := range info.Links {
+		if q.MaxResults > 0 && i >= q.MaxResults {
+			break
+		}
+		results.Results = append(results.Results, Result{
+			Position:   i + 1,
+			Title:      strings.TrimSpace(link.Title),
+			URL:        link.URL,
+			DisplayURL: strings.TrimSpace(link.WebsiteAttribution),
+			Snippet:    strings.TrimSpace(link.Caption),
+			SiteName:   strings.TrimSpace(link.WebsiteName),
+		})
+	}
+
+	results.AnswerBox = normalizeBingAnswerBox(info.AnswerBox)
+	return results, nil
+}
+
+// normalizeBingAnswerBox converts a bingsearch.BingAnswerBox to the shared schema
+func normalizeBingAnswerBox(box bingsearch.BingAnswerBox) *AnswerBox {
+	if box.Type == "" || box.Type == "none" {
+		return nil
+	}
+
+	boxType := AnswerBoxType(box.Type)
+	switch box.Type {
+	case "infobox":
+		boxType = AnswerBoxInfo
+	case "person-2":
+		boxType = AnswerBoxPerson
+	}
+
+	answerBox := &AnswerBox{
+		Type:  boxType,
+		Title: box.Title,
+	}
+	if len(box.Attributes) > 0 {
+		answerBox.Attributes = make(map[string]string, len(box.Attributes))
+		for label, value := range box.Attributes {
+			answerBox.Attributes[label] = fmt.Sprint(value)
+		}
+	}
+
+	if text, ok := box.Content.(string); ok {
+		answerBox.Text = text
+		return answerBox
+	}
+
+	switch box.Type {
+	case "infobox":
+		var content bingsearch.Answer
+		if contentAs(box.Content, &content) {
+			answerBox.Title = content.Title
+			answerBox.Text = strings.Join(append([]string{content.Description}, content.Lines...), "\n")
+		}
+	case "time":
+		var content bingsearch.TimeBoxContent
+		if contentAs(box.Content, &content) {
+			answerBox.Time = &TimeAnswer{Location: content.Location, Time: content.Time, Date: content.Date, TimeZone: content.TimeZone}
+		}
+	case "stock":
+		var content bingsearch.StockBoxContent
+		if contentAs(box.Content, &content) {
+			answerBox.Title = content.Name
+			answerBox.Stock = &StockAnswer{
+				Name:       content.Name,
+				Ticker:     content.Ticker,
+				Exchange:   content.Exchange,
+				Price:      content.LastTrade.Price,
+				Currency:   content.LastTrade.Currency,
+				Change:     content.LastTrade.Change,
+				Open:       content.Analytics.Open,
+				High:       content.Analytics.High,
+				Low:        content.Analytics.Low,
+				MarketCap:  content.Analytics.MarketCap,
+				Week52High: content.Analytics.FiftyTwoWkHigh,
+				Week52Low:  content.Analytics.FiftyTwoWkLow,
+			}
+		}
+	case "person":
+		var content bingsearch.PersonBoxContent
+		if contentAs(box.Content, &content) {
+			answerBox.Title = content.Name
+			answerBox.Person = &PersonAnswer{Name: content.Name, Position: content.Position, TermStarted: content.TermStarted}
+		}
+	case "person-2":
+		var content bingsearch.PersonBoxContent2
+		if contentAs(box.Content, &content) {
+			answerBox.Title = content.Title
+			answerBox.Text = content.Description
+			answerBox.Person = &PersonAnswer{Name: content.Title}
+		}
+	}
+
+	return answerBox
+}
+
+// contentAs decodes answer box content into target. Content is the
+// extractor's struct on a fresh scrape, and a JSON object when the response
+// came from the cache, so it goes through JSON either way.
+func contentAs(content interface{}, target interface{}) bool {
+	if content == nil {
+		return false
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, target) == nil
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"testing"
+
+	"googlescrapper/config"
+)
+
+func TestBingLocale(t *testing.T) {
+	tests := []struct {
+		region                    config.RegionConfig
+		market, country, language string
+	}{
+		{config.RegionConfig{Gl: "in", Hl: "en-IN", BingMarket: "en-IN"}, "en-IN", "", ""},
+		{config.RegionConfig{Gl: "ie", Hl: "en-IE"}, "", "ie", "en-IE"},
+		{config.RegionConfig{Gl: "pe", Hl: "es-419"}, "", "pe", "es"},
+		{config.RegionConfig{Gl: "ae", Hl: "ar"}, "", "ae", "ar"},
+		{config.RegionConfig{}, "", "", ""},
+	}
+	for _, tc := range tests {
+		market, country, language := bingLocale(tc.region)
+		if market != tc.market || country != tc.country || language != tc.language {
+			t.Errorf("bingLocale(%+v) = %q, %q, %q, want %q, %q, %q",
+				tc.region, market, country, language, tc.market, tc.country, tc.language)
+		}
+	}
+
+	// Every built-in region ends up with a market or a country
+	for code, region := range config.RegionConfigs {
+		if market, country, _ := bingLocale(region); market == "" && country == "" {
+			t.Errorf("region %s has no Bing market or country", code)
+		}
+	}
+}
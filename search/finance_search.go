@@ -1,19 +1,17 @@
 package search
 
 import (
-	"compress/flate"
-	"compress/gzip"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"googlescrapper/apierror"
+	"googlescrapper/config"
 	"googlescrapper/finance"
-	"io"
-	"io/ioutil"
 	"net/http"
-	"strings"
-	"time"
+	"net/url"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/andybalholm/brotli"
 	"github.com/gorilla/mux"
 )
 
@@ -31,89 +29,50 @@ type NewsItem struct {
 }
 
 type FinanceConfig struct {
-	Symbol string
-	Window string
+	Symbol   string
+	Window   string
+	Location string     // Region code from config.RegionConfigs
+	Render   RenderMode // How pages are fetched, defaults to auto
 }
 
 // FinanceScraper handles the scraping functionality
 type FinanceScraper struct {
-	client *http.Client
 	config FinanceConfig
+	googleFetcher
 }
 
 // NewFinanceScraper creates a new scraper instance
 func NewFinanceScraper(config FinanceConfig) *FinanceScraper {
 	return &FinanceScraper{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		config: config,
+		config:        config,
+		googleFetcher: googleFetcher{mode: config.Render},
 	}
 }
 
 // buildFinanceURL creates the finance URL with parameters
 func (s *FinanceScraper) buildFinanceURL(symbol, window string) string {
-	return fmt.Sprintf("https://finance.google.com/finance?q=%s&window=%s", symbol, window)
-}
-
-func (s *FinanceScraper) FinanceScrape() (*finance.FinanceData, error) {
-	req, err := http.NewRequest("GET", s.buildFinanceURL(s.config.Symbol, s.config.Window), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+	params := url.Values{}
+	params.Add("q", symbol)
+	if window != "" {
+		params.Add("window", window)
 	}
 
-	cookie := GetRandomCookie()
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:134.0) Gecko/20100101 Firefox/134.0")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-	req.Header.Set("Sec-Fetch-Dest", "document")
-	req.Header.Set("Sec-Fetch-Mode", "navigate")
-	req.Header.Set("Sec-Fetch-Site", "none")
-	req.Header.Set("Sec-Fetch-User", "?1")
-	req.Header.Set("Priority", "u=0, i")
-	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Cookie", cookie)
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("TE", "trailers")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
-	}
-
-	var reader io.ReadCloser
-	switch resp.Header.Get("Content-Encoding") {
-	case "gzip":
-		reader, err = gzip.NewReader(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %v", err)
-		}
-		defer reader.Close()
-	case "deflate":
-		reader = flate.NewReader(resp.Body)
-		defer reader.Close()
-	case "br":
-		reader = io.NopCloser(brotli.NewReader(resp.Body))
-	default:
-		reader = resp.Body
+	// Google Finance has no country hosts, the region only sets gl and hl
+	if regionConfig, ok := config.RegionConfigs[s.config.Location]; ok {
+		params.Add("gl", regionConfig.Gl)
+		params.Add("hl", regionConfig.Hl)
 	}
 
-	body, err := io.ReadAll(reader) // write to a html file
-	ioutil.WriteFile("finance.html", body, 0644)
+	return "https://finance.google.com/finance?" + params.Encode()
+}
 
+func (s *FinanceScraper) FinanceScrape(ctx context.Context) (*finance.FinanceData, error) {
+	body, err := s.get(ctx, "finance", s.buildFinanceURL(s.config.Symbol, s.config.Window), "main", googleRegion(s.config.Location))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %v", err)
 	}
@@ -129,7 +88,7 @@ func StandardFinanceHandler(w http.ResponseWriter, r *http.Request) {
 	symbol := vars["symbol"]
 
 	if symbol == "" {
-		http.Error(w, "Symbol parameter is required", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Symbol parameter is required")
 		return
 	}
 
@@ -138,25 +97,42 @@ func StandardFinanceHandler(w http.ResponseWriter, r *http.Request) {
 		window = "1d" // default window if not provided
 	}
 
+	location := r.URL.Query().Get("location")
+	if location != "" {
+		if _, ok := config.RegionConfigs[location]; !ok {
+			apierror.BadRequest(w, r, "Invalid region code")
+			return
+		}
+	}
+
 	config := FinanceConfig{
-		Symbol: symbol,
-		Window: window,
+		Symbol:   symbol,
+		Window:   window,
+		Location: location,
+	}
+
+	render, err := RenderModeFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
 	}
+	config.Render = render
 
 	scraper := NewFinanceScraper(config)
 
-	financeResponse, err := scraper.FinanceScrape()
+	financeResponse, err := scraper.FinanceScrape(r.Context())
 	if err != nil {
-		http.Error(w, "Error scraping results", http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error scraping results")
 		return
 	}
 
 	jsonData, err := json.MarshalIndent(financeResponse, "", "    ")
 	if err != nil {
-		http.Error(w, "Error marshaling to JSON", http.StatusInternalServerError)
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
 		return
 	}
 
+	w.Header().Set(RenderPathHeader, scraper.RenderPath())
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(jsonData)
 }
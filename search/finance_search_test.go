@@ -0,0 +1,26 @@
+package search
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBuildFinanceURLRegion(t *testing.T) {
+	tests := []struct {
+		location string
+		params   url.Values
+	}{
+		{"", url.Values{"q": {"RELIANCE:NSE"}, "window": {"1d"}}},
+		{"in", url.Values{"q": {"RELIANCE:NSE"}, "window": {"1d"}, "gl": {"in"}, "hl": {"en-IN"}}},
+	}
+	for _, tc := range tests {
+		s := NewFinanceScraper(FinanceConfig{Symbol: "RELIANCE:NSE", Window: "1d", Location: tc.location})
+		u, err := url.Parse(s.buildFinanceURL(s.config.Symbol, s.config.Window))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Host != "finance.google.com" || u.Query().Encode() != tc.params.Encode() {
+			t.Errorf("buildFinanceURL with location %q = %s, want %s", tc.location, u, tc.params.Encode())
+		}
+	}
+}
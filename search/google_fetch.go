@@ -0,0 +1,55 @@
+package search
+
+import (
+	"context"
+
+	"googlescrapper/config"
+	"googlescrapper/cookies"
+	"googlescrapper/fetch"
+	"googlescrapper/profile"
+	"googlescrapper/proxy"
+	"googlescrapper/throttle"
+)
+
+// defaultAcceptLanguage is used when a request isn't tied to a region
+const defaultAcceptLanguage = "en-US,en"
+
+// googleGet fetches a Google page for the region through a proxy in the
+// region's country and returns the decoded body. The request counts against
+// the vertical's rate limit and Google's circuit breaker. A cookie from the store is
+// attached when one is available and the outcome is reported back to it.
+// Requests with a cookie always use that cookie's header profile and proxy;
+// others rotate both.
+func googleGet(ctx context.Context, vertical, pageURL string, region config.RegionConfig) ([]byte, error) {
+	done, err := throttle.Acquire(ctx, throttle.EngineGoogle, vertical)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie, hasCookie := cookies.Default.Pick(region)
+	headerProfile := profile.Random()
+	if hasCookie {
+		headerProfile = cookie.HeaderProfile()
+	}
+
+	header := headerProfile.NavigationHeader(region.AcceptLanguage)
+	var session string
+	if hasCookie {
+		header.Set("Cookie", cookie.Value)
+		session = cookie.ID
+	}
+
+	resp, err := proxy.Do(ctx, region.Gl, session, fetch.Request{
+		URL:      pageURL,
+		Header:   header,
+		Upstream: fetch.UpstreamGoogle,
+	})
+	done(err)
+	if hasCookie {
+		cookies.Default.Report(cookie.ID, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,212 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"googlescrapper/browser"
+	"googlescrapper/config"
+	"googlescrapper/cookies"
+	"googlescrapper/fetch"
+	"googlescrapper/throttle"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// RenderMode selects how Google pages are fetched
+type RenderMode string
+
+const (
+	// RenderAuto fetches over HTTP and renders the page in the browser pool
+	// when Google blocks the request or serves a JavaScript-only page
+	RenderAuto RenderMode = "auto"
+	// RenderHTTP only fetches over HTTP
+	RenderHTTP RenderMode = "http"
+	// RenderBrowser always renders in the browser pool
+	RenderBrowser RenderMode = "browser"
+)
+
+// RenderPathHeader is the response header reporting which path, "http" or
+// "browser", served a Google response
+const RenderPathHeader = "X-Render-Path"
+
+// RenderModeFromRequest reads the render parameter, defaulting to auto
+func RenderModeFromRequest(r *http.Request) (RenderMode, error) {
+	switch mode := RenderMode(r.URL.Query().Get("render")); mode {
+	case "":
+		return RenderAuto, nil
+	case RenderAuto, RenderHTTP, RenderBrowser:
+		return mode, nil
+	}
+	return "", paramError("render", "invalid render parameter, expected auto, http or browser")
+}
+
+// jsOnlyMarkers identify the page Google serves to clients it wants to run
+// JavaScript, which has no results in it
+var jsOnlyMarkers = [][]byte{
+	[]byte("/httpservice/retry/enablejs"),
+	[]byte("Please click here if you are not redirected within a few seconds"),
+}
+
+// googleFetcher fetches the Google pages of a single scrape in its render
+// mode and records which path served them. Once auto mode has fallen back to
+// the browser, the remaining pages of the scrape are rendered straight away.
+type googleFetcher struct {
+	mode RenderMode
+	path RenderMode
+}
+
+// RenderPath returns "browser" when any page of the scrape was rendered in
+// the browser pool and "http" otherwise
+func (f *googleFetcher) RenderPath() string {
+	return string(f.path)
+}
+
+// get fetches a Google page in the fetcher's render mode. waitSelector is the
+// element the browser path waits for before taking the rendered HTML.
+func (f *googleFetcher) get(ctx context.Context, vertical, pageURL, waitSelector string, region config.RegionConfig) ([]byte, error) {
+	if f.mode != RenderBrowser {
+		body, err := googleGet(ctx, vertical, pageURL, region)
+		if f.mode == RenderHTTP || !needsBrowser(body, err) {
+			if err == nil && f.path == "" {
+				f.path = RenderHTTP
+			}
+			return body, err
+		}
+
+		if err == nil {
+			err = errors.New("page requires JavaScript")
+		}
+		log.Printf("Rendering Google %s page in the browser: %v", vertical, err)
+		f.mode = RenderBrowser
+	}
+
+	body, err := googleRender(ctx, vertical, pageURL, waitSelector, region)
+	if err != nil {
+		return nil, err
+	}
+	f.path = RenderBrowser
+	return body, nil
+}
+
+// needsBrowser reports whether an HTTP fetch should be retried in the
+// browser: Google blocked it, or answered with a JavaScript-only page.
+// Throttling and open circuits apply to the browser too and are not retried.
+func needsBrowser(body []byte, err error) bool {
+	if err != nil {
+		return errors.Is(err, fetch.ErrCaptcha) || errors.Is(err, fetch.ErrBlocked) ||
+			errors.Is(err, fetch.ErrConsent) || errors.Is(err, fetch.ErrRateLimited)
+	}
+	for _, marker := range jsOnlyMarkers {
+		if bytes.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// googleRender loads a Google page in the browser pool through a proxy in the
+// region's country, waits for waitSelector and returns the rendered HTML. Like
+// googleGet it counts against the vertical's rate limit and Google's circuit
+// breaker, and loads a cookie from the store into the tab when one is
+// available.
+func googleRender(ctx context.Context, vertical, pageURL, waitSelector string, region config.RegionConfig) (body []byte, err error) {
+	done, err := throttle.Acquire(ctx, throttle.EngineGoogle, vertical)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { done(err) }()
+
+	// Get a browser context from the pool for a proxy in the region's country
+	p, pool, err := proxiedBrowser(region.Gl)
+	if err != nil {
+		return nil, err
+	}
+	browserCtx, returnCtx, err := pool.GetContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get browser context: %v", err)
+	}
+	defer returnCtx() // Return the context to the pool when done
+
+	// Only cookies issued to a Chromium profile are used, so the tab keeps
+	// presenting as the browser the cookie was issued to
+	headerProfile := pool.Profile(browserCtx)
+	cookie, hasCookie := cookies.Default.Pick(region)
+	if hasCookie && cookie.HeaderProfile().Chromium() {
+		headerProfile = cookie.HeaderProfile()
+		defer func() { cookies.Default.Report(cookie.ID, err) }()
+	} else {
+		hasCookie = false
+	}
+
+	start := time.Now()
+	defer func() { p.Report(time.Since(start), err) }()
+
+	timeoutCtx, cancel := context.WithTimeout(browserCtx, 20*time.Second)
+	defer cancel()
+
+	var htmlContent string
+	err = chromedp.Run(timeoutCtx,
+		browser.Emulate(headerProfile, region.AcceptLanguage),
+		network.ClearBrowserCookies(),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if !hasCookie {
+				return nil
+			}
+			return setGoogleCookies(ctx, "https://"+region.GoogleHost()+"/", cookie.Value)
+		}),
+		chromedp.Navigate(pageURL),
+		chromedp.WaitVisible(waitSelector, chromedp.ByQuery),
+		chromedp.OuterHTML(`html`, &htmlContent, chromedp.ByQuery),
+	)
+	if err != nil {
+		// The page never finished rendering; check whether Google served a
+		// captcha or consent page instead
+		if blockErr := detectGoogleBlock(browserCtx); blockErr != nil {
+			return nil, blockErr
+		}
+		return nil, fmt.Errorf("failed to render page: %w", err)
+	}
+
+	return []byte(htmlContent), nil
+}
+
+// setGoogleCookies loads every name=value pair of a Cookie header value into
+// the tab's cookie jar for the given URL
+func setGoogleCookies(ctx context.Context, siteURL, value string) error {
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || name == "" {
+			continue
+		}
+		if err := network.SetCookie(name, val).WithURL(siteURL).WithSecure(true).Do(ctx); err != nil {
+			return fmt.Errorf("failed to set cookie %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// detectGoogleBlock inspects the page currently loaded in the browser for a
+// captcha or consent page
+func detectGoogleBlock(ctx context.Context) error {
+	checkCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var location, htmlContent string
+	err := chromedp.Run(checkCtx,
+		chromedp.Location(&location),
+		chromedp.OuterHTML(`html`, &htmlContent, chromedp.ByQuery),
+	)
+	if err != nil {
+		return nil
+	}
+
+	return fetch.DetectBlock(fetch.UpstreamGoogle, 0, location, []byte(htmlContent))
+}
@@ -1,23 +1,21 @@
 package search
 
 import (
-	"compress/flate"
-	"compress/gzip"
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"googlescrapper/apierror"
 	"googlescrapper/config"
 	"googlescrapper/standard_search"
 	"googlescrapper/utils"
-	"io"
-	"io/ioutil"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/andybalholm/brotli"
-
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gorilla/mux"
 )
@@ -34,7 +32,12 @@ type SearchResult struct {
 type SearchResponse struct {
 	Links             []standard_search.SearchResult     `json:"links,omitempty"`
 	AnswerBox         standard_search.AnswerBox          `json:"answer_box,omitempty"`
+	KnowledgeGraph    *standard_search.KnowledgeGraph    `json:"knowledge_graph,omitempty"`
 	SuggestedProducts []standard_search.SuggestedProduct `json:"suggested_products,omitempty"`
+	LocalResults      []standard_search.LocalResult      `json:"local_results,omitempty"`
+	PeopleAlsoAsk     []standard_search.PeopleAlsoAsk    `json:"people_also_ask,omitempty"`
+	RelatedSearches   []standard_search.RelatedSearch    `json:"related_searches,omitempty"`
+	NextPage          string                             `json:"next_page,omitempty"`
 }
 
 // SearchConfig holds the search parameters
@@ -45,127 +48,271 @@ type SearchConfig struct {
 	MaxResults int
 	Latitude   *float64 // Optional latitude
 	Longitude  *float64 // Optional longitude
+	City       string   // Optional canonical location name, takes precedence over coordinates
+	Start      int      // Google result offset of the page to start paging from
+	Skip       int      // Results of the first page already returned on earlier pages
+	RankOffset int      // Number of results already returned on earlier pages
+	PAADepth   int      // Rounds of browser-backed "People also ask" expansion, 0 disables
+	Vertical   string   // Optional Google tbm value, e.g. "lcl" for the local finder
+	Options    QueryOptions
+	Render     RenderMode // How pages are fetched, defaults to auto
 }
 
+// MaxSearchResults caps the results a single search request may ask for
+const MaxSearchResults = 100
+
+const (
+	// resultsPerPage is the number of organic results Google shows per page
+	resultsPerPage = 10
+	// maxPages caps how many SERP pages a single request may fetch
+	maxPages = 15
+	// pageDelay is the pause between consecutive page fetches
+	pageDelay = 500 * time.Millisecond
+)
+
 // SearchScraper handles the scraping functionality
 type SearchScraper struct {
-	client *http.Client
 	config SearchConfig
+	googleFetcher
 }
 
 // NewSearchScraper creates a new scraper instance
 func NewSearchScraper(config SearchConfig) *SearchScraper {
 	return &SearchScraper{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		config: config,
+		config:        config,
+		googleFetcher: googleFetcher{mode: config.Render},
 	}
 }
 
 // buildSearchURL creates the search URL with parameters
-func (s *SearchScraper) buildSearchURL() string {
+func (s *SearchScraper) buildSearchURL(start int) string {
 	params := url.Values{}
-	params.Add("q", s.config.Query)
+	params.Add("q", s.config.Options.Build(s.config.Query))
+	if s.config.Vertical != "" {
+		params.Add("tbm", s.config.Vertical)
+	}
+	if start > 0 {
+		params.Add("start", strconv.Itoa(start))
+	}
 
 	if regionConfig, ok := config.RegionConfigs[s.config.Location]; ok {
 		params.Add("gl", regionConfig.Gl)
-		params.Add("lr", regionConfig.Lr)
+		if regionConfig.Lr != "" {
+			params.Add("lr", regionConfig.Lr)
+		}
 		params.Add("hl", regionConfig.Hl)
 	}
 
-	// Add coordinates if both latitude and longitude are provided
-	if s.config.Latitude != nil && s.config.Longitude != nil {
-		// Add location bias parameter
+	// Target a named location, or coordinates snapped to the nearest one
+	if s.config.City != "" {
+		if uule, err := utils.UULE(s.config.City); err == nil {
+			params.Add("uule", uule)
+		}
+	} else if s.config.Latitude != nil && s.config.Longitude != nil {
 		params.Add("geoloc", fmt.Sprintf("%f,%f", *s.config.Latitude, *s.config.Longitude))
-		// Add additional location parameter used by Google
-		params.Add("uule", utils.CreateUULE(*s.config.Latitude, *s.config.Longitude))
+		params.Add("uule", utils.UULEForCoordinates(*s.config.Latitude, *s.config.Longitude))
 	}
 
-	return "https://www.google.com/search?" + params.Encode()
+	s.config.Options.ApplyGoogle(params)
+
+	return "https://" + googleRegion(s.config.Location).GoogleHost() + "/search?" + params.Encode()
 }
 
-func (s *SearchScraper) Scrape() (*SearchResponse, error) {
-	req, err := http.NewRequest("GET", s.buildSearchURL(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	cookie := GetRandomCookie()
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:134.0) Gecko/20100101 Firefox/134.0")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-	req.Header.Set("Sec-Fetch-Dest", "document")
-	req.Header.Set("Sec-Fetch-Mode", "navigate")
-	req.Header.Set("Sec-Fetch-Site", "none")
-	req.Header.Set("Sec-Fetch-User", "?1")
-	req.Header.Set("Priority", "u=0, i")
-	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Cookie", cookie)
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("TE", "trailers")
-
-	resp, err := s.client.Do(req)
+// googleRegion returns the region config for a region code, defaulting to
+// google.com with US English headers when the code is unknown
+func googleRegion(location string) config.RegionConfig {
+	if regionConfig, ok := config.RegionConfigs[location]; ok {
+		if regionConfig.AcceptLanguage == "" {
+			regionConfig.AcceptLanguage = regionConfig.Hl
+		}
+		return regionConfig
+	}
+	return config.RegionConfig{
+		GoogleDomain:   "google.com",
+		AcceptLanguage: defaultAcceptLanguage,
+	}
+}
+
+// EncodePageToken builds the opaque next_page token from the Google offset of
+// the page to resume on, the number of that page's results already returned,
+// and the absolute rank of the last returned result
+func EncodePageToken(start, skip, rank int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d:%d", start, skip, rank)))
+}
+
+// DecodePageToken parses a token produced by EncodePageToken
+func DecodePageToken(token string) (start, skip, rank int, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
+		return 0, 0, 0, fmt.Errorf("invalid page token: %v", err)
 	}
-	defer resp.Body.Close()
+	var rest string
+	if n, _ := fmt.Sscanf(string(raw), "%d:%d:%d%s", &start, &skip, &rank, &rest); n != 3 || start < 0 || skip < 0 || rank < 0 {
+		return 0, 0, 0, fmt.Errorf("invalid page token")
+	}
+	return start, skip, rank, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+// Scrape pages through the results until MaxResults unique links have been
+// collected. Answer boxes and suggested products are taken from the first page.
+func (s *SearchScraper) Scrape(ctx context.Context) (*SearchResponse, error) {
+	searchResponse := &SearchResponse{
+		Links:             []standard_search.SearchResult{},
+		AnswerBox:         standard_search.AnswerBox{},
+		SuggestedProducts: []standard_search.SuggestedProduct{},
 	}
 
-	var reader io.ReadCloser
-	switch resp.Header.Get("Content-Encoding") {
-	case "gzip":
-		reader, err = gzip.NewReader(resp.Body)
+	host := googleRegion(s.config.Location).GoogleHost()
+	pager := newPager(s.config)
+	exhausted := false
+
+	for page := 0; !pager.full(); page++ {
+		if page >= maxPages {
+			break
+		}
+		if page > 0 && pause(ctx, pageDelay) != nil {
+			// The caller gave up or ran out of time, keep what we have
+			break
+		}
+
+		doc, err := s.fetchPage(ctx, pager.start)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %v", err)
+			if page == 0 {
+				return nil, err
+			}
+			// Keep what we have; the caller can resume with the next_page token
+			break
+		}
+
+		if page == 0 {
+			// Extract answer box
+			answerBox := standard_search.ExtractAnswerbox(doc)
+			if answerBox != nil {
+				searchResponse.AnswerBox = *answerBox
+			}
+			searchResponse.KnowledgeGraph = standard_search.ExtractKnowledgeGraph(doc, host)
+			suggestedProducts := standard_search.ExtractSuggestedProducts(doc)
+			searchResponse.SuggestedProducts = suggestedProducts
+			searchResponse.LocalResults = standard_search.ExtractLocalResults(doc)
+			searchResponse.PeopleAlsoAsk = standard_search.ExtractPeopleAlsoAsk(doc, host)
+			searchResponse.RelatedSearches = standard_search.ExtractRelatedSearches(doc, host)
+		}
+
+		var more bool
+		more, exhausted = pager.add(standard_search.ExtractSearchResults(doc, 2*resultsPerPage))
+		if !more {
+			break
 		}
-		defer reader.Close()
-	case "deflate":
-		reader = flate.NewReader(resp.Body)
-		defer reader.Close()
-	case "br":
-		reader = io.NopCloser(brotli.NewReader(resp.Body))
-	default:
-		reader = resp.Body
 	}
+	searchResponse.Links = pager.links
 
-	body, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+	if s.config.PAADepth > 0 {
+		questions, err := ExpandPeopleAlsoAsk(ctx, s.buildSearchURL(s.config.Start), googleRegion(s.config.Location).Gl, s.config.PAADepth)
+		if err != nil {
+			// Fall back to the questions present in the static HTML
+			log.Printf("Warning: failed to expand people also ask: %v", err)
+		} else if len(questions) > 0 {
+			searchResponse.PeopleAlsoAsk = questions
+		}
 	}
 
-	// write to file
-	ioutil.WriteFile("response.html", body, 0644)
+	if !exhausted && len(searchResponse.Links) > 0 {
+		searchResponse.NextPage = EncodePageToken(pager.start, pager.skip, s.config.RankOffset+len(searchResponse.Links))
+	}
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	return searchResponse, nil
+}
+
+// pager collects unique results across SERP pages and tracks where the next
+// result is: the Google offset of its page, and its index among the results
+// extracted from that page
+type pager struct {
+	maxResults int
+	rankOffset int
+	start      int
+	skip       int
+	seen       map[string]bool
+	links      []standard_search.SearchResult
+}
+
+func newPager(config SearchConfig) *pager {
+	return &pager{
+		maxResults: config.MaxResults,
+		rankOffset: config.RankOffset,
+		start:      config.Start,
+		skip:       config.Skip,
+		seen:       make(map[string]bool),
+		links:      []standard_search.SearchResult{},
+	}
+}
+
+// full reports whether MaxResults links have been collected
+func (p *pager) full() bool {
+	return len(p.links) >= p.maxResults
+}
+
+// add takes the results of the page at p.start after the first p.skip,
+// skipping links already seen, and moves on to the next page. It reports
+// whether another page should be fetched, and whether the results are
+// exhausted because the page had nothing new. When MaxResults is reached
+// part-way through a page, p.start stays on it and p.skip counts the results
+// consumed. Google offsets only fall on page boundaries, since duplicates and
+// non-organic blocks make results and offsets drift apart within a page.
+func (p *pager) add(pageResults []standard_search.SearchResult) (more, exhausted bool) {
+	skip := p.skip
+	p.skip = 0
+
+	added := 0
+	for i, result := range pageResults {
+		if i < skip {
+			continue
+		}
+		if p.full() {
+			// Stopped part-way through the page, resume from this result
+			p.skip = i
+			return false, false
+		}
+		if p.seen[result.URL] {
+			continue
+		}
+		p.seen[result.URL] = true
+
+		result.Position = p.rankOffset + len(p.links) + 1
+		p.links = append(p.links, result)
+		added++
 	}
 
-	searchResponse := &SearchResponse{
-		Links:             []standard_search.SearchResult{},
-		AnswerBox:         standard_search.AnswerBox{},
-		SuggestedProducts: []standard_search.SuggestedProduct{},
+	if added == 0 {
+		// No new results on this page means we've reached the end
+		return false, true
 	}
+	p.start += resultsPerPage
+	return true, false
+}
 
-	extractedResults := standard_search.ExtractSearchResults(doc, s.config.MaxResults)
-	searchResponse.Links = extractedResults
+// pause waits for d, returning ctx's error early when ctx is done
+func pause(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
 
-	// Extract answer box
-	answerBox := standard_search.ExtractAnswerbox(doc)
-	if answerBox != nil {
-		searchResponse.AnswerBox = *answerBox
+// fetchPage fetches and parses a single SERP page starting at the given offset
+func (s *SearchScraper) fetchPage(ctx context.Context, start int) (*goquery.Document, error) {
+	body, err := s.get(ctx, "search", s.buildSearchURL(start), "#search", googleRegion(s.config.Location))
+	if err != nil {
+		return nil, err
 	}
-	suggestedProducts := standard_search.ExtractSuggestedProducts(doc)
-	searchResponse.SuggestedProducts = suggestedProducts
 
-	return searchResponse, nil
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	return doc, nil
 }
 
 func StandardSearchHandler(w http.ResponseWriter, r *http.Request) {
@@ -173,33 +320,33 @@ func StandardSearchHandler(w http.ResponseWriter, r *http.Request) {
 	query := vars["query"]
 	location := vars["location"]
 	maxResults, err := strconv.Atoi(vars["maxResults"])
-	if err != nil {
-		http.Error(w, "Invalid maxResults parameter", http.StatusBadRequest)
+	if err != nil || maxResults < 1 || maxResults > MaxSearchResults {
+		apierror.BadRequest(w, r, fmt.Sprintf("Invalid maxResults parameter, expected 1-%d", MaxSearchResults))
 		return
 	}
 
 	lat, err := strconv.ParseFloat(vars["latitude"], 64)
 	if err != nil {
-		http.Error(w, "Invalid latitude parameter", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Invalid latitude parameter")
 		return
 	}
 
 	lon, err := strconv.ParseFloat(vars["longitude"], 64)
 	if err != nil {
-		http.Error(w, "Invalid longitude parameter", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Invalid longitude parameter")
 		return
 	}
 
 	useCoords := vars["useCoords"] == "true"
 
 	if query == "" {
-		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Query parameter is required")
 		return
 	}
 
 	// Validate region
 	if _, ok := config.RegionConfigs[location]; !ok {
-		http.Error(w, "Invalid region code", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Invalid region code")
 		return
 	}
 
@@ -209,33 +356,66 @@ func StandardSearchHandler(w http.ResponseWriter, r *http.Request) {
 		MaxResults: maxResults,
 	}
 
+	config.Options, err = QueryOptionsFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+
+	if depth := r.URL.Query().Get("paa_depth"); depth != "" {
+		config.PAADepth, err = strconv.Atoi(depth)
+		if err != nil || config.PAADepth < 0 || config.PAADepth > MaxPAADepth {
+			apierror.BadRequest(w, r, fmt.Sprintf("Invalid paa_depth parameter, expected 0-%d", MaxPAADepth))
+			return
+		}
+	}
+
+	// Resume from a previous response's next_page token
+	if token := r.URL.Query().Get("page_token"); token != "" {
+		config.Start, config.Skip, config.RankOffset, err = DecodePageToken(token)
+		if err != nil {
+			apierror.BadRequest(w, r, "Invalid page_token parameter")
+			return
+		}
+	}
+
 	if useCoords {
 		config.Latitude = &lat
 		config.Longitude = &lon
 	}
 
-	scraper := NewSearchScraper(config)
+	// A named city takes precedence over the coordinates in the path
+	if city := r.URL.Query().Get("city"); city != "" {
+		loc, ok := utils.LookupLocation(city)
+		if !ok {
+			apierror.BadRequest(w, r, "Unknown city parameter")
+			return
+		}
+		config.City = loc.Name
+	}
 
-	searchResponse, err := scraper.Scrape()
+	render, err := RenderModeFromRequest(r)
 	if err != nil {
-		println(err.Error())
-		http.Error(w, "Error scraping results", http.StatusInternalServerError)
+		apierror.BadRequest(w, r, err.Error())
 		return
 	}
+	config.Render = render
 
-	jsonData, err := json.MarshalIndent(searchResponse, "", "    ")
+	scraper := NewSearchScraper(config)
+
+	searchResponse, err := scraper.Scrape(r.Context())
 	if err != nil {
-		http.Error(w, "Error marshaling to JSON", http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error scraping results")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if string(jsonData) == `{
-	"answer_box": {}
-}` {
-		http.Error(w, "Error fetching data", http.StatusInternalServerError)
+	jsonData, err := json.MarshalIndent(searchResponse, "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
 		return
 	}
 
+	w.Header().Set(RenderPathHeader, scraper.RenderPath())
+	w.Header().Set("Content-Type", "application/json")
 	w.Write(jsonData)
 }
@@ -0,0 +1,162 @@
+package search
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"googlescrapper/standard_search"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	for _, tc := range []struct{ start, skip, rank int }{{0, 0, 0}, {10, 0, 10}, {30, 7, 42}, {140, 9, 150}} {
+		start, skip, rank, err := DecodePageToken(EncodePageToken(tc.start, tc.skip, tc.rank))
+		if err != nil {
+			t.Fatalf("DecodePageToken(EncodePageToken(%d, %d, %d)): %v", tc.start, tc.skip, tc.rank, err)
+		}
+		if start != tc.start || skip != tc.skip || rank != tc.rank {
+			t.Errorf("round trip of (%d, %d, %d) = (%d, %d, %d)", tc.start, tc.skip, tc.rank, start, skip, rank)
+		}
+	}
+}
+
+func TestDecodePageTokenMalformed(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := map[string]string{
+		"empty":          "",
+		"bad base64":     "not*base64",
+		"padded base64":  base64.URLEncoding.EncodeToString([]byte("10:0:10")),
+		"not numbers":    encode("x:y:z"),
+		"missing rank":   encode("10:0"),
+		"trailing data":  encode("10:0:10:5"),
+		"negative start": encode("-10:0:10"),
+		"negative skip":  encode("10:-1:10"),
+		"negative rank":  encode("10:0:-1"),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if start, skip, rank, err := DecodePageToken(token); err == nil {
+				t.Errorf("DecodePageToken(%q) = (%d, %d, %d), want an error", token, start, skip, rank)
+			}
+		})
+	}
+}
+
+// results returns n results with URLs /0 to /n-1 of host
+func results(host string, n int) []standard_search.SearchResult {
+	out := make([]standard_search.SearchResult, n)
+	for i := range out {
+		out[i] = standard_search.SearchResult{URL: fmt.Sprintf("https://%s/%d", host, i)}
+	}
+	return out
+}
+
+func TestPagerFullPageAdvancesByPageSize(t *testing.T) {
+	p := newPager(SearchConfig{MaxResults: 30, Start: 20, RankOffset: 20})
+
+	more, exhausted := p.add(results("a", resultsPerPage))
+	if !more || exhausted {
+		t.Fatalf("add = (%v, %v), want (true, false)", more, exhausted)
+	}
+	if p.start != 20+resultsPerPage {
+		t.Errorf("start = %d, want %d", p.start, 20+resultsPerPage)
+	}
+	if got := p.links[0].Position; got != 21 {
+		t.Errorf("first position = %d, want 21", got)
+	}
+}
+
+func TestPagerCutShortResumesWithinPage(t *testing.T) {
+	p := newPager(SearchConfig{MaxResults: 13})
+
+	if more, _ := p.add(results("a", resultsPerPage)); !more {
+		t.Fatal("first page stopped paging")
+	}
+	// The second page repeats two links before the cut, so 5 of its results
+	// are consumed to collect the 3 still missing
+	page := append(results("a", 1), results("b", 2)...)
+	page = append(page, results("a", 2)[1:]...)
+	page = append(page, results("c", resultsPerPage)...)
+	more, exhausted := p.add(page)
+	if more || exhausted {
+		t.Fatalf("add = (%v, %v), want (false, false)", more, exhausted)
+	}
+	if len(p.links) != 13 {
+		t.Fatalf("collected %d links, want 13", len(p.links))
+	}
+	if last := p.links[12]; last.URL != "https://c/0" || last.Position != 13 {
+		t.Errorf("last link = %s at %d, want https://c/0 at 13", last.URL, last.Position)
+	}
+	// The offset stays on the page boundary, the rest of the page is skipped
+	if p.start != resultsPerPage || p.skip != 5 {
+		t.Fatalf("resume at (%d, %d), want (%d, 5)", p.start, p.skip, resultsPerPage)
+	}
+
+	// Resuming fetches the same page again and continues after the cut
+	resumed := newPager(SearchConfig{MaxResults: 3, Start: p.start, Skip: p.skip, RankOffset: 13})
+	resumed.add(page)
+	for i, want := range []string{"https://c/1", "https://c/2", "https://c/3"} {
+		if got := resumed.links[i]; got.URL != want || got.Position != 14+i {
+			t.Errorf("resumed link %d = %s at %d, want %s at %d", i, got.URL, got.Position, want, 14+i)
+		}
+	}
+}
+
+func TestPagerNothingNewIsExhausted(t *testing.T) {
+	p := newPager(SearchConfig{MaxResults: 50})
+	p.add(results("a", resultsPerPage))
+
+	more, exhausted := p.add(results("a", resultsPerPage))
+	if more || !exhausted {
+		t.Fatalf("add = (%v, %v), want (false, true)", more, exhausted)
+	}
+	if p.start != resultsPerPage {
+		t.Errorf("start = %d, want %d", p.start, resultsPerPage)
+	}
+	if len(p.links) != resultsPerPage {
+		t.Errorf("collected %d links, want %d", len(p.links), resultsPerPage)
+	}
+}
+
+func TestStandardSearchHandlerMaxResults(t *testing.T) {
+	for _, maxResults := range []string{"0", "-5", "101", "ten"} {
+		r := httptest.NewRequest(http.MethodGet, "/search/golang/us/"+maxResults+"/0/0/false", nil)
+		r = mux.SetURLVars(r, map[string]string{
+			"query": "golang", "location": "us", "maxResults": maxResults,
+			"latitude": "0", "longitude": "0", "useCoords": "false",
+		})
+		w := httptest.NewRecorder()
+		StandardSearchHandler(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("maxResults %s: status %d, want 400", maxResults, w.Code)
+		}
+	}
+}
+
+func TestBuildSearchURLLocation(t *testing.T) {
+	lat, lng := 18.5204, 73.8567
+
+	s := NewSearchScraper(SearchConfig{Query: "cafe", Location: "in", Latitude: &lat, Longitude: &lng})
+	u, _ := url.Parse(s.buildSearchURL(0))
+	if u.Host != "www.google.co.in" {
+		t.Errorf("host = %s, want www.google.co.in", u.Host)
+	}
+	if got := u.Query().Get("geoloc"); got != "18.520400,73.856700" {
+		t.Errorf("geoloc = %q, want the coordinates", got)
+	}
+	if u.Query().Get("uule") == "" {
+		t.Error("uule missing for coordinates")
+	}
+
+	// A named city replaces the coordinates
+	s = NewSearchScraper(SearchConfig{Query: "cafe", Location: "in", City: "Pune,Maharashtra,India", Latitude: &lat, Longitude: &lng})
+	u, _ = url.Parse(s.buildSearchURL(0))
+	if u.Query().Has("geoloc") || u.Query().Get("uule") == "" {
+		t.Errorf("city search URL = %s, want a uule and no geoloc", u)
+	}
+}
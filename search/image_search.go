@@ -1,164 +1,321 @@
 package search
 
 import (
-	"compress/flate"
-	"compress/gzip"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"googlescrapper/apierror"
+	"googlescrapper/config"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/andybalholm/brotli"
 	"github.com/gorilla/mux"
 )
 
 type ImageInfo struct {
-	Title string `json:"title"`
-	URL   string `json:"url"`
+	Position     int    `json:"position"`
+	Title        string `json:"title"`
+	URL          string `json:"url"` // Full-resolution image
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	Thumbnail    string `json:"thumbnail,omitempty"`
+	SourceURL    string `json:"source_url,omitempty"` // Page hosting the image
+	SourceDomain string `json:"source_domain,omitempty"`
 }
 
 type ImageConfig struct {
-	Query string
+	Query    string
+	Location string // Region code from config.RegionConfigs
+	Size     string // large, medium or icon
+	Color    string // color, gray, transparent or a specific color such as red
+	Type     string // face, photo, clipart, lineart or animated
+	License  string // creative_commons or commercial
+	Page     int    // Zero-based results page
+	Options  QueryOptions
+	Render   RenderMode // How pages are fetched, defaults to auto
 }
 
+// imageSizes maps size filters to tbs values
+var imageSizes = map[string]string{
+	"large":  "isz:l",
+	"medium": "isz:m",
+	"icon":   "isz:i",
+}
+
+// imageColors maps color filters to tbs values
+var imageColors = map[string]string{
+	"color":       "ic:color",
+	"gray":        "ic:gray",
+	"transparent": "ic:trans",
+	"red":         "ic:specific,isc:red",
+	"orange":      "ic:specific,isc:orange",
+	"yellow":      "ic:specific,isc:yellow",
+	"green":       "ic:specific,isc:green",
+	"teal":        "ic:specific,isc:teal",
+	"blue":        "ic:specific,isc:blue",
+	"purple":      "ic:specific,isc:purple",
+	"pink":        "ic:specific,isc:pink",
+	"white":       "ic:specific,isc:white",
+	"black":       "ic:specific,isc:black",
+	"brown":       "ic:specific,isc:brown",
+}
+
+// imageTypes maps type filters to tbs values
+var imageTypes = map[string]string{
+	"face":     "itp:face",
+	"photo":    "itp:photo",
+	"clipart":  "itp:clipart",
+	"lineart":  "itp:lineart",
+	"animated": "itp:animated",
+}
+
+// imageLicenses maps usage rights filters to tbs values
+var imageLicenses = map[string]string{
+	"creative_commons": "il:cl",
+	"commercial":       "il:ol",
+}
+
+// imagesPerPage is the number of results Google returns per image page
+const imagesPerPage = 100
+
 // ImageScraper handles the scraping functionality
 type ImageScraper struct {
-	client *http.Client
 	config ImageConfig
+	googleFetcher
 }
 
 // NewImageScraper creates a new scraper instance
 func NewImageScraper(config ImageConfig) *ImageScraper {
 	return &ImageScraper{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		config: config,
+		config:        config,
+		googleFetcher: googleFetcher{mode: config.Render},
 	}
 }
 
 // buildImageURL creates the image URL with parameters
 func (s *ImageScraper) buildImageURL(query string) string {
-	return fmt.Sprintf("https://www.google.com/search?q=%s&tbm=isch", query)
-}
+	params := url.Values{}
+	params.Add("q", s.config.Options.Build(query))
+	params.Add("tbm", "isch")
 
-func (s *ImageScraper) ImageScrape() ([]ImageInfo, error) {
-	req, err := http.NewRequest("GET", s.buildImageURL(s.config.Query), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	cookie := GetRandomCookie()
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:134.0) Gecko/20100101 Firefox/134.0")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-	req.Header.Set("Sec-Fetch-Dest", "document")
-	req.Header.Set("Sec-Fetch-Mode", "navigate")
-	req.Header.Set("Sec-Fetch-Site", "none")
-	req.Header.Set("Sec-Fetch-User", "?1")
-	req.Header.Set("Priority", "u=0, i")
-	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Cookie", cookie)
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("TE", "trailers")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
+	var tbs []string
+	for _, filter := range []string{
+		imageSizes[s.config.Size],
+		imageColors[s.config.Color],
+		imageTypes[s.config.Type],
+		imageLicenses[s.config.License],
+	} {
+		if filter != "" {
+			tbs = append(tbs, filter)
+		}
 	}
-	defer resp.Body.Close()
+	addTBS(params, tbs...)
+	s.config.Options.ApplyGoogle(params)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+	if s.config.Page > 0 {
+		params.Add("ijn", strconv.Itoa(s.config.Page))
+		params.Add("start", strconv.Itoa(s.config.Page*imagesPerPage))
 	}
 
-	var reader io.ReadCloser
-	switch resp.Header.Get("Content-Encoding") {
-	case "gzip":
-		reader, err = gzip.NewReader(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %v", err)
+	if regionConfig, ok := config.RegionConfigs[s.config.Location]; ok {
+		params.Add("gl", regionConfig.Gl)
+		if regionConfig.Lr != "" {
+			params.Add("lr", regionConfig.Lr)
 		}
-		defer reader.Close()
-	case "deflate":
-		reader = flate.NewReader(resp.Body)
-		defer reader.Close()
-	case "br":
-		reader = io.NopCloser(brotli.NewReader(resp.Body))
-	default:
-		reader = resp.Body
+		params.Add("hl", regionConfig.Hl)
 	}
 
-	body, err := io.ReadAll(reader) // write to a html file
-	ioutil.WriteFile("images.html", body, 0644)
+	return "https://" + googleRegion(s.config.Location).GoogleHost() + "/search?" + params.Encode()
+}
 
+func (s *ImageScraper) ImageScrape(ctx context.Context) ([]ImageInfo, error) {
+	body, err := s.get(ctx, "images", s.buildImageURL(s.config.Query), "#main", googleRegion(s.config.Location))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %v", err)
 	}
 
-	var imageInfos []ImageInfo
-	doc.Find("img").Each(func(i int, s *goquery.Selection) {
-		title := s.AttrOr("alt", "")
+	imageInfos := extractImageResults(string(body))
+	if len(imageInfos) == 0 {
+		// Fall back to the rendered tags when the result data isn't embedded
+		imageInfos = extractImageTags(doc)
+	}
+
+	return imageInfos, nil
+}
+
+// imageResultRe matches the thumbnail and original image entries of a result
+// in the embedded data, both given as [url, height, width]
+var imageResultRe = regexp.MustCompile(`\["(https://encrypted-tbn\d\.gstatic\.com/images\?[^"]+)",(\d+),(\d+)\],\["(https?://[^"]+)",(\d+),(\d+)\]`)
+
+// imageMetadataRe matches the "2003" metadata entry that follows each result
+// and holds the source page URL and title
+var imageMetadataRe = regexp.MustCompile(`"2003":\[null,"[^"]*","([^"]+)","((?:[^"\\]|\\.)*)"`)
+
+// extractImageResults parses image results from the JSON embedded in the page scripts
+func extractImageResults(html string) []ImageInfo {
+	var images []ImageInfo
+	seen := make(map[string]bool)
 
-		url, exists := s.Attr("src")
-		if !exists {
-			url, exists = s.Attr("data-src")
-			if !exists {
-				return
+	matches := imageResultRe.FindAllStringSubmatchIndex(html, -1)
+	for i, match := range matches {
+		original := decodeJSONString(html[match[8]:match[9]])
+		if seen[original] {
+			continue
+		}
+		seen[original] = true
+
+		height, _ := strconv.Atoi(html[match[10]:match[11]])
+		width, _ := strconv.Atoi(html[match[12]:match[13]])
+
+		image := ImageInfo{
+			Position:  len(images) + 1,
+			URL:       original,
+			Width:     width,
+			Height:    height,
+			Thumbnail: decodeJSONString(html[match[2]:match[3]]),
+		}
+
+		// The metadata sits between this result and the next one
+		end := len(html)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		if meta := imageMetadataRe.FindStringSubmatch(html[match[1]:end]); meta != nil {
+			image.SourceURL = decodeJSONString(meta[1])
+			image.Title = decodeJSONString(meta[2])
+			if parsed, err := url.Parse(image.SourceURL); err == nil {
+				image.SourceDomain = strings.TrimPrefix(parsed.Hostname(), "www.")
 			}
 		}
 
-		imageInfos = append(imageInfos, ImageInfo{
-			Title: title,
-			URL:   url,
+		images = append(images, image)
+	}
+
+	return images
+}
+
+// extractImageTags collects result images from <img> tags, skipping inline
+// data thumbnails and Google's own UI graphics
+func extractImageTags(doc *goquery.Document) []ImageInfo {
+	var images []ImageInfo
+
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		src := s.AttrOr("data-src", s.AttrOr("src", ""))
+		if !strings.HasPrefix(src, "http") || strings.Contains(src, "/images/branding/") {
+			return
+		}
+
+		images = append(images, ImageInfo{
+			Position:  len(images) + 1,
+			Title:     s.AttrOr("alt", ""),
+			URL:       src,
+			Thumbnail: src,
 		})
 	})
 
-	println(len(imageInfos))
-	return imageInfos, nil
+	return images
+}
+
+// decodeJSONString unescapes a string literal taken from embedded JSON
+func decodeJSONString(raw string) string {
+	var decoded string
+	if err := json.Unmarshal([]byte(`"`+raw+`"`), &decoded); err != nil {
+		return raw
+	}
+	return decoded
 }
 
 // StandardImageHandler handles image queries
 func StandardImageHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	query := vars["query"]
-	println(query)
 	if query == "" {
-		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Query parameter is required")
 		return
 	}
 
+	params := r.URL.Query()
+	location := params.Get("location")
+	if location != "" {
+		if _, ok := config.RegionConfigs[location]; !ok {
+			apierror.BadRequest(w, r, "Invalid region code")
+			return
+		}
+	}
+
 	config := ImageConfig{
-		Query: query,
+		Query:    query,
+		Location: location,
+		Size:     params.Get("size"),
+		Color:    params.Get("color"),
+		Type:     params.Get("type"),
+		License:  params.Get("license"),
+	}
+
+	for name, filter := range map[string]struct {
+		value   string
+		allowed map[string]string
+	}{
+		"size":    {config.Size, imageSizes},
+		"color":   {config.Color, imageColors},
+		"type":    {config.Type, imageTypes},
+		"license": {config.License, imageLicenses},
+	} {
+		if _, ok := filter.allowed[filter.value]; filter.value != "" && !ok {
+			apierror.BadRequest(w, r, "Invalid "+name+" parameter")
+			return
+		}
+	}
+
+	if page := params.Get("page"); page != "" {
+		var err error
+		config.Page, err = strconv.Atoi(page)
+		if err != nil || config.Page < 0 {
+			apierror.BadRequest(w, r, "Invalid page parameter")
+			return
+		}
+	}
+
+	options, err := QueryOptionsFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+	config.Options = options
+
+	render, err := RenderModeFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
 	}
+	config.Render = render
 
 	scraper := NewImageScraper(config)
 
-	imageInfos, err := scraper.ImageScrape()
+	imageInfos, err := scraper.ImageScrape(r.Context())
 
 	if err != nil {
-		http.Error(w, "Error scraping results", http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error scraping results")
 		return
 	}
 
 	jsonData, err := json.MarshalIndent(imageInfos, "", "    ")
 	if err != nil {
-		http.Error(w, "Error marshaling to JSON", http.StatusInternalServerError)
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
 		return
 	}
 
+	w.Header().Set(RenderPathHeader, scraper.RenderPath())
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(jsonData)
 }
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestExtractImageResults(t *testing.T) {
+	html, err := os.ReadFile("testdata/google_images.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := extractImageResults(string(html))
+	want := []ImageInfo{
+		{
+			Position:     1,
+			Title:        "The Go Gopher & friends - The Go Programming Language",
+			URL:          "https://go.dev/images/gophers/ladder.svg",
+			Width:        1600,
+			Height:       900,
+			Thumbnail:    "https://encrypted-tbn0.gstatic.com/images?q=tbn:ANd9GcQ1&s",
+			SourceURL:    "https://go.dev/blog/gopher",
+			SourceDomain: "go.dev",
+		},
+		{
+			// The duplicate of the first image is skipped, and its metadata
+			// isn't taken for the next result
+			Position:     2,
+			Title:        `File:Go "gopher".png - Wikimedia Commons`,
+			URL:          "https://upload.wikimedia.org/wikipedia/commons/2/23/Go_gopher.png",
+			Width:        640,
+			Height:       480,
+			Thumbnail:    "https://encrypted-tbn2.gstatic.com/images?q=tbn:ANd9GcQ3&s",
+			SourceURL:    "https://commons.wikimedia.org/wiki/File:Go_gopher.png",
+			SourceDomain: "commons.wikimedia.org",
+		},
+		{
+			// Results without metadata keep their image
+			Position:  3,
+			URL:       "http://www.example.org/gopher.jpg",
+			Width:     480,
+			Height:    400,
+			Thumbnail: "https://encrypted-tbn3.gstatic.com/images?q=tbn:ANd9GcQ4&s",
+		},
+	}
+
+	if len(images) != len(want) {
+		t.Fatalf("extractImageResults returned %d images, want %d: %+v", len(images), len(want), images)
+	}
+	for i := range want {
+		if images[i] != want[i] {
+			t.Errorf("image %d = %+v, want %+v", i, images[i], want[i])
+		}
+	}
+}
+
+func TestBuildImageURLRegion(t *testing.T) {
+	tests := []struct {
+		location, host string
+		params         url.Values
+	}{
+		{"", "www.google.com", url.Values{"q": {"gopher"}, "tbm": {"isch"}}},
+		{"in", "www.google.co.in", url.Values{"q": {"gopher"}, "tbm": {"isch"}, "gl": {"in"}, "hl": {"en-IN"}, "lr": {"lang_en"}}},
+	}
+	for _, tc := range tests {
+		s := NewImageScraper(ImageConfig{Query: "gopher", Location: tc.location})
+		u, err := url.Parse(s.buildImageURL(s.config.Query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Host != tc.host || u.Query().Encode() != tc.params.Encode() {
+			t.Errorf("buildImageURL with location %q = %s, want host %s and %s", tc.location, u, tc.host, tc.params.Encode())
+		}
+	}
+}
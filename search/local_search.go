@@ -0,0 +1,146 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"googlescrapper/apierror"
+	"googlescrapper/config"
+	"googlescrapper/standard_search"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ScrapeLocal fetches places from Google's local finder (tbm=lcl), paging
+// until MaxResults places have been collected
+func (s *SearchScraper) ScrapeLocal(ctx context.Context) ([]standard_search.LocalResult, error) {
+	s.config.Vertical = "lcl"
+
+	results := []standard_search.LocalResult{}
+	seen := make(map[string]bool)
+	start := s.config.Start
+
+	for page := 0; len(results) < s.config.MaxResults && page < maxPages; page++ {
+		if page > 0 && pause(ctx, pageDelay) != nil {
+			// The caller gave up or ran out of time, keep what we have
+			break
+		}
+
+		doc, err := s.fetchPage(ctx, start)
+		if err != nil {
+			if page == 0 {
+				return nil, err
+			}
+			break
+		}
+
+		places := standard_search.ExtractLocalResults(doc)
+		added := 0
+		for _, place := range places {
+			if len(results) >= s.config.MaxResults {
+				break
+			}
+
+			key := place.PlaceID
+			if key == "" {
+				key = place.Title + "|" + place.Address
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			place.Position = len(results) + 1
+			results = append(results, place)
+			added++
+		}
+
+		if added == 0 {
+			break
+		}
+		start += len(places)
+	}
+
+	return results, nil
+}
+
+// StandardLocalHandler handles local (places) queries around the given coordinates
+func StandardLocalHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := vars["query"]
+
+	if query == "" {
+		apierror.BadRequest(w, r, "Query parameter is required")
+		return
+	}
+
+	params := r.URL.Query()
+
+	lat, err := strconv.ParseFloat(params.Get("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		apierror.BadRequest(w, r, "Invalid lat parameter")
+		return
+	}
+
+	lon, err := strconv.ParseFloat(params.Get("lng"), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		apierror.BadRequest(w, r, "Invalid lng parameter")
+		return
+	}
+
+	maxResults := 20
+	if value := params.Get("max_results"); value != "" {
+		maxResults, err = strconv.Atoi(value)
+		if err != nil || maxResults <= 0 {
+			apierror.BadRequest(w, r, "Invalid max_results parameter")
+			return
+		}
+	}
+
+	location := params.Get("location")
+	if location != "" {
+		if _, ok := config.RegionConfigs[location]; !ok {
+			apierror.BadRequest(w, r, "Invalid region code")
+			return
+		}
+	}
+
+	options, err := QueryOptionsFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+
+	render, err := RenderModeFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+
+	scraper := NewSearchScraper(SearchConfig{
+		Query:      query,
+		Location:   location,
+		MaxResults: maxResults,
+		Latitude:   &lat,
+		Longitude:  &lon,
+		Options:    options,
+		Render:     render,
+	})
+
+	places, err := scraper.ScrapeLocal(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(places, "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
+		return
+	}
+
+	w.Header().Set(RenderPathHeader, scraper.RenderPath())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
@@ -0,0 +1,297 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"googlescrapper/apierror"
+	"googlescrapper/config"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gorilla/mux"
+)
+
+// NewsArticle represents a single result from the Google News vertical
+type NewsArticle struct {
+	Position    int        `json:"position"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Publisher   string     `json:"publisher,omitempty"`
+	Published   string     `json:"published,omitempty"`    // As shown on the page, e.g. "3 hours ago"
+	PublishedAt *time.Time `json:"published_at,omitempty"` // Absolute time resolved from Published
+	Snippet     string     `json:"snippet,omitempty"`
+	Thumbnail   string     `json:"thumbnail,omitempty"`
+}
+
+// newsRecency maps recency filter names to Google's tbs=qdr values
+var newsRecency = map[string]string{
+	"hour":  "qdr:h",
+	"day":   "qdr:d",
+	"week":  "qdr:w",
+	"month": "qdr:m",
+	"year":  "qdr:y",
+}
+
+type NewsConfig struct {
+	Query    string
+	Location string // Region code from config.RegionConfigs
+	Recency  string // One of hour, day, week, month, year
+	Options  QueryOptions
+	Render   RenderMode // How pages are fetched, defaults to auto
+}
+
+// NewsScraper handles the scraping functionality
+type NewsScraper struct {
+	config NewsConfig
+	googleFetcher
+}
+
+// NewNewsScraper creates a new scraper instance
+func NewNewsScraper(config NewsConfig) *NewsScraper {
+	return &NewsScraper{
+		config:        config,
+		googleFetcher: googleFetcher{mode: config.Render},
+	}
+}
+
+// buildNewsURL creates the news URL with parameters
+func (s *NewsScraper) buildNewsURL() string {
+	params := url.Values{}
+	params.Add("q", s.config.Options.Build(s.config.Query))
+	params.Add("tbm", "nws")
+
+	if regionConfig, ok := config.RegionConfigs[s.config.Location]; ok {
+		params.Add("gl", regionConfig.Gl)
+		if regionConfig.Lr != "" {
+			params.Add("lr", regionConfig.Lr)
+		}
+		params.Add("hl", regionConfig.Hl)
+	}
+
+	if qdr, ok := newsRecency[s.config.Recency]; ok {
+		addTBS(params, qdr)
+	}
+	s.config.Options.ApplyGoogle(params)
+
+	return "https://" + googleRegion(s.config.Location).GoogleHost() + "/search?" + params.Encode()
+}
+
+func (s *NewsScraper) NewsScrape(ctx context.Context) ([]NewsArticle, error) {
+	body, err := s.get(ctx, "news", s.buildNewsURL(), "#search", googleRegion(s.config.Location))
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	// Thumbnails are injected by inline scripts rather than set on the <img> tags
+	thumbnails := extractInlineImages(string(body))
+	now := time.Now()
+
+	var articles []NewsArticle
+	doc.Find("div.SoaBEf").Each(func(i int, s *goquery.Selection) {
+		link, exists := s.Find("a.WlydOe").First().Attr("href")
+		if !exists {
+			link, exists = s.Find("a").First().Attr("href")
+			if !exists {
+				return
+			}
+		}
+
+		title := strings.TrimSpace(s.Find("div.n0jPhd").First().Text())
+		if title == "" {
+			title = strings.TrimSpace(s.Find("div[role='heading']").First().Text())
+		}
+		if title == "" {
+			return
+		}
+
+		published := strings.TrimSpace(s.Find("div.OSrXXb span").Last().Text())
+		if published == "" {
+			published = strings.TrimSpace(s.Find(".LfVVr").First().Text())
+		}
+
+		thumbnail := ""
+		if img := s.Find("img").First(); img.Length() > 0 {
+			thumbnail = img.AttrOr("data-src", img.AttrOr("src", ""))
+			if id, ok := img.Attr("id"); ok && thumbnails[id] != "" {
+				thumbnail = thumbnails[id]
+			}
+		}
+
+		articles = append(articles, NewsArticle{
+			Position:    len(articles) + 1,
+			Title:       title,
+			URL:         link,
+			Publisher:   strings.TrimSpace(s.Find("div.MgUUmf span").Last().Text()),
+			Published:   published,
+			PublishedAt: parsePublishTime(published, now),
+			Snippet:     strings.TrimSpace(s.Find("div.GI74Re").First().Text()),
+			Thumbnail:   thumbnail,
+		})
+	})
+
+	return articles, nil
+}
+
+// inlineImageRe matches Google's `var s='data:...';var ii=['id',...]` thumbnail scripts
+var inlineImageRe = regexp.MustCompile(`var s='(data:image/[^']+)';var ii=\[([^\]]+)\]`)
+
+// hexEscapeRe matches the \xNN escapes used inside the inline image scripts
+var hexEscapeRe = regexp.MustCompile(`\\x([0-9a-fA-F]{2})`)
+
+// extractInlineImages maps image element IDs to the data URIs set by inline scripts
+func extractInlineImages(html string) map[string]string {
+	images := make(map[string]string)
+
+	for _, match := range inlineImageRe.FindAllStringSubmatch(html, -1) {
+		src := hexEscapeRe.ReplaceAllStringFunc(match[1], func(escape string) string {
+			b, err := strconv.ParseUint(escape[2:], 16, 8)
+			if err != nil {
+				return escape
+			}
+			return string(rune(b))
+		})
+
+		for _, id := range strings.Split(match[2], ",") {
+			images[strings.Trim(strings.TrimSpace(id), `'"`)] = src
+		}
+	}
+
+	return images
+}
+
+// relativeTimeRe matches Google's relative publish times like "3 hours ago" or "1 day ago"
+var relativeTimeRe = regexp.MustCompile(`(?i)^(\d+)\s*(sec|second|min|minute|hour|hr|day|week|month|year)s?\s+ago$`)
+
+// absoluteTimeLayouts are the date formats Google uses for older articles
+var absoluteTimeLayouts = []string{
+	"Jan 2, 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"2 January 2006",
+	"02-Jan-2006",
+	"2006-01-02",
+}
+
+// parsePublishTime resolves a relative or absolute publish time to a timestamp
+func parsePublishTime(text string, now time.Time) *time.Time {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	if match := relativeTimeRe.FindStringSubmatch(text); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil
+		}
+
+		var t time.Time
+		switch strings.ToLower(match[2]) {
+		case "sec", "second":
+			t = now.Add(-time.Duration(n) * time.Second)
+		case "min", "minute":
+			t = now.Add(-time.Duration(n) * time.Minute)
+		case "hour", "hr":
+			t = now.Add(-time.Duration(n) * time.Hour)
+		case "day":
+			t = now.AddDate(0, 0, -n)
+		case "week":
+			t = now.AddDate(0, 0, -7*n)
+		case "month":
+			t = now.AddDate(0, -n, 0)
+		case "year":
+			t = now.AddDate(-n, 0, 0)
+		}
+		t = t.UTC().Truncate(time.Minute)
+		return &t
+	}
+
+	for _, layout := range absoluteTimeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return &t
+		}
+	}
+
+	return nil
+}
+
+// StandardNewsHandler handles news queries
+func StandardNewsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := vars["query"]
+
+	if query == "" {
+		apierror.BadRequest(w, r, "Query parameter is required")
+		return
+	}
+
+	location := r.URL.Query().Get("location")
+	if location != "" {
+		if _, ok := config.RegionConfigs[location]; !ok {
+			apierror.BadRequest(w, r, "Invalid region code")
+			return
+		}
+	}
+
+	recency := r.URL.Query().Get("recency")
+	if recency != "" {
+		if _, ok := newsRecency[recency]; !ok {
+			apierror.BadRequest(w, r, "Invalid recency parameter, expected one of hour, day, week, month, year")
+			return
+		}
+	}
+
+	options, err := QueryOptionsFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+	if recency != "" && (options.TimeRange != "" || !options.DateFrom.IsZero() || !options.DateTo.IsZero()) {
+		apierror.BadRequest(w, r, "recency cannot be combined with time_range, date_from or date_to")
+		return
+	}
+
+	config := NewsConfig{
+		Query:    query,
+		Location: location,
+		Recency:  recency,
+		Options:  options,
+	}
+
+	render, err := RenderModeFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+	config.Render = render
+
+	scraper := NewNewsScraper(config)
+
+	articles, err := scraper.NewsScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(articles, "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
+		return
+	}
+
+	w.Header().Set(RenderPathHeader, scraper.RenderPath())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePublishTime(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 12, 30, 45, 0, time.UTC)
+	date := func(year int, month time.Month, day, hour, min int) *time.Time {
+		t := time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		text string
+		want *time.Time
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"30 secs ago", date(2024, time.March, 15, 12, 30)},
+		{"5 mins ago", date(2024, time.March, 15, 12, 25)},
+		{"1 minute ago", date(2024, time.March, 15, 12, 29)},
+		{"3 hours ago", date(2024, time.March, 15, 9, 30)},
+		{"2 hrs ago", date(2024, time.March, 15, 10, 30)},
+		{"1 day ago", date(2024, time.March, 14, 12, 30)},
+		{"2 weeks ago", date(2024, time.March, 1, 12, 30)},
+		{"1 month ago", date(2024, time.February, 15, 12, 30)},
+		{"2 years ago", date(2022, time.March, 15, 12, 30)},
+		{"4 Hours Ago", date(2024, time.March, 15, 8, 30)},
+		{"Jan 2, 2023", date(2023, time.January, 2, 0, 0)},
+		{"2 Jan 2023", date(2023, time.January, 2, 0, 0)},
+		{"January 2, 2023", date(2023, time.January, 2, 0, 0)},
+		{"2 January 2023", date(2023, time.January, 2, 0, 0)},
+		{"02-Jan-2023", date(2023, time.January, 2, 0, 0)},
+		{"2023-01-02", date(2023, time.January, 2, 0, 0)},
+		{"yesterday", nil},
+		{"5 fortnights ago", nil},
+		{"ago", nil},
+	}
+
+	for _, tc := range tests {
+		got := parsePublishTime(tc.text, now)
+		switch {
+		case tc.want == nil && got != nil:
+			t.Errorf("parsePublishTime(%q) = %v, want nil", tc.text, *got)
+		case tc.want != nil && got == nil:
+			t.Errorf("parsePublishTime(%q) = nil, want %v", tc.text, *tc.want)
+		case tc.want != nil && !got.Equal(*tc.want):
+			t.Errorf("parsePublishTime(%q) = %v, want %v", tc.text, *got, *tc.want)
+		}
+	}
+}
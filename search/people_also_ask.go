@@ -0,0 +1,104 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"googlescrapper/standard_search"
+	"googlescrapper/throttle"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+)
+
+// MaxPAADepth caps how many rounds of "People also ask" expansion are allowed
+const MaxPAADepth = 5
+
+// expandPAAScript clicks every "People also ask" entry that hasn't been
+// clicked yet and returns how many were clicked. Each click makes Google
+// append further questions to the list.
+const expandPAAScript = `(() => {
+	let clicked = 0;
+	document.querySelectorAll('div.related-question-pair').forEach(el => {
+		if (el.dataset.gsExpanded) return;
+		el.dataset.gsExpanded = '1';
+		const toggle = el.querySelector('[aria-expanded]') || el.querySelector('[role="button"]') || el;
+		toggle.click();
+		clicked++;
+	});
+	return clicked;
+})()`
+
+// ExpandPeopleAlsoAsk renders the SERP in the browser pool, through a proxy
+// in the given country, and clicks through the "People also ask" entries up
+// to depth rounds, returning every question and answer that was revealed
+func ExpandPeopleAlsoAsk(ctx context.Context, searchURL, country string, depth int) (paa []standard_search.PeopleAlsoAsk, err error) {
+	if depth > MaxPAADepth {
+		depth = MaxPAADepth
+	}
+
+	done, err := throttle.Acquire(ctx, throttle.EngineGoogle, "paa")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { done(err) }()
+
+	// Get a browser context from the pool
+	p, pool, err := proxiedBrowser(country)
+	if err != nil {
+		return nil, err
+	}
+	tabCtx, returnCtx, err := pool.GetContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get browser context: %v", err)
+	}
+	defer returnCtx() // Return the context to the pool when done
+
+	start := time.Now()
+	defer func() { p.Report(time.Since(start), err) }()
+
+	timeoutCtx, cancel := context.WithTimeout(tabCtx, 15*time.Second+time.Duration(depth)*3*time.Second)
+	defer cancel()
+
+	err = chromedp.Run(timeoutCtx,
+		chromedp.Navigate(searchURL),
+		chromedp.WaitVisible(`div.related-question-pair`, chromedp.ByQuery),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load people also ask block: %v", err)
+	}
+
+	for round := 0; round < depth; round++ {
+		var clicked int
+		err = chromedp.Run(timeoutCtx,
+			chromedp.Evaluate(expandPAAScript, &clicked),
+			// Give Google time to load the answers and the follow-up questions
+			chromedp.Sleep(1200*time.Millisecond),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand people also ask: %v", err)
+		}
+		if clicked == 0 {
+			break
+		}
+	}
+
+	var htmlContent string
+	if err := chromedp.Run(timeoutCtx, chromedp.OuterHTML(`html`, &htmlContent, chromedp.ByQuery)); err != nil {
+		return nil, fmt.Errorf("failed to scrape content: %v", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	host := "www.google.com"
+	if parsed, err := url.Parse(searchURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	return standard_search.ExtractPeopleAlsoAsk(doc, host), nil
+}
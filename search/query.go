@@ -0,0 +1,245 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"googlescrapper/apierror"
+)
+
+// QueryOptions holds search operators and filters that are shared by every
+// scraper. Operators are appended to the query text by Build, while filters
+// are mapped onto each engine's own URL parameters.
+type QueryOptions struct {
+	ExactPhrases []string  // Each phrase is matched verbatim ("...")
+	Exclude      []string  // Terms that must not appear (-term)
+	Site         string    // Restrict to a domain (site:)
+	FileType     string    // Restrict to a file extension (filetype:)
+	InTitle      string    // Term that must appear in the title (intitle:)
+	TimeRange    string    // hour, day, week, month or year
+	DateFrom     time.Time // Start of a custom date range
+	DateTo       time.Time // End of a custom date range
+	Language     string    // ISO 639-1 code of the result language, e.g. "de"
+	SafeSearch   string    // off, moderate or strict
+}
+
+// googleTimeRanges maps time ranges to Google's tbs=qdr values
+var googleTimeRanges = map[string]string{
+	"hour":  "qdr:h",
+	"day":   "qdr:d",
+	"week":  "qdr:w",
+	"month": "qdr:m",
+	"year":  "qdr:y",
+}
+
+// queryDateLayout is the format of date_from and date_to
+const queryDateLayout = "2006-01-02"
+
+var (
+	languageCodeRe = regexp.MustCompile(`^[a-z]{2}$`)
+	fileTypeRe     = regexp.MustCompile(`^[a-zA-Z0-9]{1,10}$`)
+)
+
+// Validate checks that the options are well formed and don't conflict
+func (o QueryOptions) Validate() error {
+	if o.TimeRange != "" {
+		if _, ok := googleTimeRanges[o.TimeRange]; !ok {
+			return paramError("time_range", "invalid time_range, expected one of hour, day, week, month, year")
+		}
+		if !o.DateFrom.IsZero() || !o.DateTo.IsZero() {
+			return paramError("time_range", "time_range cannot be combined with date_from or date_to")
+		}
+	}
+	if !o.DateFrom.IsZero() && !o.DateTo.IsZero() && o.DateFrom.After(o.DateTo) {
+		return paramError("date_from", "date_from must not be after date_to")
+	}
+	if o.Language != "" && !languageCodeRe.MatchString(o.Language) {
+		return paramError("lang", "invalid lang, expected a two letter language code")
+	}
+	if o.SafeSearch != "" && !bingSafeSearch[o.SafeSearch] {
+		return paramError("safe", "invalid safe, expected one of off, moderate, strict")
+	}
+	if o.FileType != "" && !fileTypeRe.MatchString(o.FileType) {
+		return paramError("filetype", "invalid filetype")
+	}
+	if strings.ContainsAny(o.Site, " \"") {
+		return paramError("site", "invalid site")
+	}
+	return nil
+}
+
+// Build appends the search operators to the query text. The result is plain
+// text; escaping is left to url.Values when it is added to a URL.
+func (o QueryOptions) Build(query string) string {
+	parts := []string{strings.TrimSpace(query)}
+
+	for _, phrase := range o.ExactPhrases {
+		if phrase = cleanOperand(phrase); phrase != "" {
+			parts = append(parts, `"`+phrase+`"`)
+		}
+	}
+	for _, term := range o.Exclude {
+		if term = cleanOperand(term); term != "" {
+			parts = append(parts, "-"+quoteIfSpaced(term))
+		}
+	}
+	if o.InTitle != "" {
+		parts = append(parts, "intitle:"+quoteIfSpaced(cleanOperand(o.InTitle)))
+	}
+	if o.Site != "" {
+		parts = append(parts, "site:"+o.Site)
+	}
+	if o.FileType != "" {
+		parts = append(parts, "filetype:"+strings.ToLower(o.FileType))
+	}
+
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// ApplyGoogle sets the time range, language and SafeSearch parameters on a
+// Google search URL, merging with any tbs value that is already present
+func (o QueryOptions) ApplyGoogle(params url.Values) {
+	if qdr, ok := googleTimeRanges[o.TimeRange]; ok {
+		addTBS(params, qdr)
+	} else if !o.DateFrom.IsZero() || !o.DateTo.IsZero() {
+		cdr := []string{"cdr:1"}
+		if !o.DateFrom.IsZero() {
+			cdr = append(cdr, "cd_min:"+o.DateFrom.Format("1/2/2006"))
+		}
+		if !o.DateTo.IsZero() {
+			cdr = append(cdr, "cd_max:"+o.DateTo.Format("1/2/2006"))
+		}
+		addTBS(params, cdr...)
+	}
+
+	if o.Language != "" {
+		params.Set("lr", "lang_"+o.Language)
+	}
+
+	switch o.SafeSearch {
+	case "off":
+		params.Set("safe", "off")
+	case "moderate", "strict":
+		params.Set("safe", "active")
+	}
+}
+
+// ApplyBing sets the time range, language and SafeSearch parameters on a
+// Bing URL. Values already set on params are left untouched.
+func (o QueryOptions) ApplyBing(params url.Values) {
+	if params.Get("filters") == "" {
+		// Bing has no hour or year filter, so those become custom date ranges
+		if filter, ok := bingFreshness[o.TimeRange]; ok {
+			params.Set("filters", filter)
+		} else if from, to := o.bingDateRange(); !from.IsZero() {
+			params.Set("filters", fmt.Sprintf(`ex1:"ez5_%d_%d"`, daysSinceEpoch(from), daysSinceEpoch(to)))
+		}
+	}
+
+	// Bing has no URL parameter for the result language, only an operator
+	if o.Language != "" {
+		params.Set("q", params.Get("q")+" language:"+o.Language)
+	}
+
+	if o.SafeSearch != "" && params.Get("adlt") == "" {
+		params.Set("adlt", o.SafeSearch)
+	}
+}
+
+// bingDateRange converts the hour and year time ranges and open-ended custom
+// ranges into explicit dates, since Bing only filters by whole days
+func (o QueryOptions) bingDateRange() (time.Time, time.Time) {
+	now := time.Now().UTC()
+	switch o.TimeRange {
+	case "hour":
+		return now.AddDate(0, 0, -1), now
+	case "year":
+		return now.AddDate(-1, 0, 0), now
+	}
+
+	if o.DateFrom.IsZero() && o.DateTo.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+	from, to := o.DateFrom, o.DateTo
+	if from.IsZero() {
+		from = time.Unix(0, 0).UTC()
+	}
+	if to.IsZero() {
+		to = now
+	}
+	return from, to
+}
+
+func daysSinceEpoch(t time.Time) int64 {
+	return t.Unix() / 86400
+}
+
+// addTBS appends values to the comma separated tbs parameter
+func addTBS(params url.Values, values ...string) {
+	if len(values) == 0 {
+		return
+	}
+	if existing := params.Get("tbs"); existing != "" {
+		values = append([]string{existing}, values...)
+	}
+	params.Set("tbs", strings.Join(values, ","))
+}
+
+// paramError reports an invalid request parameter. Its message is the
+// complete error, so legacy handlers can pass err.Error() on unchanged.
+func paramError(field, message string) error {
+	return &apierror.FieldError{Field: field, Message: message}
+}
+
+// cleanOperand strips quotes so user input can't break out of an operator
+func cleanOperand(text string) string {
+	return strings.TrimSpace(strings.ReplaceAll(text, `"`, ""))
+}
+
+func quoteIfSpaced(text string) string {
+	if strings.ContainsAny(text, " \t") {
+		return `"` + text + `"`
+	}
+	return text
+}
+
+// QueryOptionsFromRequest reads the shared query options from the request's
+// query parameters. exact and exclude may be repeated.
+func QueryOptionsFromRequest(r *http.Request) (QueryOptions, error) {
+	return queryOptionsFromParams(r.URL.Query())
+}
+
+func queryOptionsFromParams(params url.Values) (QueryOptions, error) {
+	options := QueryOptions{
+		ExactPhrases: params["exact"],
+		Exclude:      params["exclude"],
+		Site:         strings.TrimSpace(params.Get("site")),
+		FileType:     strings.TrimPrefix(strings.TrimSpace(params.Get("filetype")), "."),
+		InTitle:      params.Get("intitle"),
+		TimeRange:    strings.ToLower(params.Get("time_range")),
+		Language:     strings.ToLower(params.Get("lang")),
+		SafeSearch:   strings.ToLower(params.Get("safe")),
+	}
+
+	for name, target := range map[string]*time.Time{
+		"date_from": &options.DateFrom,
+		"date_to":   &options.DateTo,
+	} {
+		if value := params.Get(name); value != "" {
+			date, err := time.Parse(queryDateLayout, value)
+			if err != nil {
+				return QueryOptions{}, paramError(name, fmt.Sprintf("invalid %s, expected YYYY-MM-DD", name))
+			}
+			*target = date
+		}
+	}
+
+	if err := options.Validate(); err != nil {
+		return QueryOptions{}, err
+	}
+	return options, nil
+}
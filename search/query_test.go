@@ -0,0 +1,151 @@
+package search
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"googlescrapper/apierror"
+)
+
+func TestQueryOptionsBuild(t *testing.T) {
+	tests := []struct {
+		options QueryOptions
+		want    string
+	}{
+		{QueryOptions{}, "golang"},
+		{QueryOptions{ExactPhrases: []string{"generic types", " "}}, `golang "generic types"`},
+		{QueryOptions{ExactPhrases: []string{`break "out`}}, `golang "break out"`},
+		{QueryOptions{Exclude: []string{"rust", "c plus plus"}}, `golang -rust -"c plus plus"`},
+		{QueryOptions{InTitle: "release notes"}, `golang intitle:"release notes"`},
+		{QueryOptions{Site: "go.dev", FileType: "PDF"}, "golang site:go.dev filetype:pdf"},
+	}
+	for _, tc := range tests {
+		if got := tc.options.Build("  golang "); got != tc.want {
+			t.Errorf("%+v.Build = %q, want %q", tc.options, got, tc.want)
+		}
+	}
+}
+
+func TestQueryOptionsValidate(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		options QueryOptions
+		field   string // Empty when the options are valid
+	}{
+		{QueryOptions{}, ""},
+		{QueryOptions{TimeRange: "week", Language: "de", SafeSearch: "strict", FileType: "pdf", Site: "go.dev"}, ""},
+		{QueryOptions{DateFrom: day(1), DateTo: day(1)}, ""},
+		{QueryOptions{TimeRange: "decade"}, "time_range"},
+		{QueryOptions{TimeRange: "day", DateFrom: day(1)}, "time_range"},
+		{QueryOptions{DateFrom: day(2), DateTo: day(1)}, "date_from"},
+		{QueryOptions{Language: "deu"}, "lang"},
+		{QueryOptions{SafeSearch: "on"}, "safe"},
+		{QueryOptions{FileType: "p.df"}, "filetype"},
+		{QueryOptions{Site: `go.dev" OR "x`}, "site"},
+	}
+	for _, tc := range tests {
+		err := tc.options.Validate()
+		if tc.field == "" {
+			if err != nil {
+				t.Errorf("%+v.Validate: %v", tc.options, err)
+			}
+			continue
+		}
+		var fieldErr *apierror.FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != tc.field {
+			t.Errorf("%+v.Validate = %v, want an error on %s", tc.options, err, tc.field)
+		}
+	}
+}
+
+func TestQueryOptionsApplyGoogle(t *testing.T) {
+	tests := []struct {
+		options QueryOptions
+		initial url.Values
+		want    url.Values
+	}{
+		{QueryOptions{TimeRange: "day"}, url.Values{}, url.Values{"tbs": {"qdr:d"}}},
+		{QueryOptions{TimeRange: "week"}, url.Values{"tbs": {"isch"}}, url.Values{"tbs": {"isch,qdr:w"}}},
+		{
+			QueryOptions{DateFrom: time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC), DateTo: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+			url.Values{},
+			url.Values{"tbs": {"cdr:1,cd_min:3/5/2024,cd_max:4/1/2024"}},
+		},
+		{QueryOptions{Language: "fr", SafeSearch: "off"}, url.Values{}, url.Values{"lr": {"lang_fr"}, "safe": {"off"}}},
+		{QueryOptions{SafeSearch: "moderate"}, url.Values{}, url.Values{"safe": {"active"}}},
+	}
+	for _, tc := range tests {
+		tc.options.ApplyGoogle(tc.initial)
+		if got, want := tc.initial.Encode(), tc.want.Encode(); got != want {
+			t.Errorf("%+v.ApplyGoogle = %s, want %s", tc.options, got, want)
+		}
+	}
+}
+
+func TestQueryOptionsApplyBing(t *testing.T) {
+	params := url.Values{"q": {"golang"}}
+	QueryOptions{TimeRange: "week", Language: "de", SafeSearch: "strict"}.ApplyBing(params)
+	want := url.Values{"q": {"golang language:de"}, "filters": {`ex1:"ez2"`}, "adlt": {"strict"}}
+	if params.Encode() != want.Encode() {
+		t.Errorf("ApplyBing = %s, want %s", params.Encode(), want.Encode())
+	}
+
+	// Values the engine's own options already set win
+	params = url.Values{"q": {"golang"}, "filters": {`ex1:"ez1"`}, "adlt": {"off"}}
+	QueryOptions{TimeRange: "month", SafeSearch: "strict"}.ApplyBing(params)
+	if params.Get("filters") != `ex1:"ez1"` || params.Get("adlt") != "off" {
+		t.Errorf("ApplyBing overrode existing values: %s", params.Encode())
+	}
+
+	// Custom ranges become whole days since the epoch
+	params = url.Values{}
+	QueryOptions{DateFrom: time.Unix(86400*10, 0).UTC(), DateTo: time.Unix(86400*12, 0).UTC()}.ApplyBing(params)
+	if got := params.Get("filters"); got != `ex1:"ez5_10_12"` {
+		t.Errorf("ApplyBing date range filters = %s, want ex1:\"ez5_10_12\"", got)
+	}
+}
+
+func TestBingVerticalsApplyQueryOptions(t *testing.T) {
+	s := NewBingScraper(BingConfig{Options: QueryOptions{Language: "de", SafeSearch: "strict"}})
+	for name, raw := range map[string]string{
+		"images": s.buildBingImagesURL("golang"),
+		"news":   s.buildBingNewsURL("golang"),
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("%s URL %q: %v", name, raw, err)
+		}
+		params := u.Query()
+		if params.Get("q") != "golang language:de" || params.Get("adlt") != "strict" {
+			t.Errorf("%s URL %s doesn't apply the query options", name, raw)
+		}
+	}
+}
+
+func TestQueryOptionsFromParams(t *testing.T) {
+	options, err := queryOptionsFromParams(url.Values{
+		"exact":      {"one", "two"},
+		"exclude":    {"three"},
+		"filetype":   {".PDF"},
+		"time_range": {"DAY"},
+		"lang":       {"EN"},
+	})
+	if err != nil {
+		t.Fatalf("queryOptionsFromParams: %v", err)
+	}
+	if len(options.ExactPhrases) != 2 || options.FileType != "PDF" || options.TimeRange != "day" || options.Language != "en" {
+		t.Errorf("queryOptionsFromParams = %+v", options)
+	}
+
+	for _, params := range []url.Values{
+		{"date_from": {"01/02/2024"}},
+		{"date_to": {"2024-13-01"}},
+		{"time_range": {"day"}, "date_to": {"2024-01-01"}},
+	} {
+		if _, err := queryOptionsFromParams(params); err == nil {
+			t.Errorf("queryOptionsFromParams(%v) succeeded, want an error", params)
+		}
+	}
+}
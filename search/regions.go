@@ -0,0 +1,31 @@
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"googlescrapper/api"
+	"googlescrapper/apierror"
+	"googlescrapper/config"
+)
+
+// RegionsHandler lists every supported region code and its parameters
+func RegionsHandler(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := json.MarshalIndent(config.Regions(), "", "    ")
+	if err != nil {
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// V1RegionsHandler handles GET /v1/regions
+func V1RegionsHandler(w http.ResponseWriter, r *http.Request) {
+	if _, apiErr := api.Parse(r, nil); apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	api.Write(w, r, config.Regions(), api.Meta{})
+}
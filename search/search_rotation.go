@@ -1,72 +0,0 @@
-package search
-
-import (
-	"bufio"
-	"fmt"
-	"os"
-	"strings"
-	"time"
-
-	"golang.org/x/exp/rand"
-)
-
-var CookieJar = []string{
-	"AEC=AZ6Zc-WK4w0I45M285ZfXoJ1fRgAkOy8qVo_BpkVRWOIMWpMfuMH9T38aA; NID=521=DqxOnHQglPERb_nTJ8DWRjwBzhAXujdxtcX_p1u_wkC4VtukV6LjjfLjiugMCqSCfrm14eQhDEAjdWUTyL5npM08IFN9Hm-oZavJco1tZI_RXRGo7rIxb4Pua0dkv-eeePg-7fCdrClkT2HidDboDTj1VCT4sScI2jqM0gpdra75Jco5KXMwuNzwNePzgyszbvbBc8dRtZX049NY51ZDwE4BspGYmBmIZp2OG13Kw6N7PgRxAdfq_mlvdOH4V2yER8EI70er7PnVIo2bn4_x82n3wgOG7NrWvarhzU15bS7mfryy137QczKf_eY5X2aHtI9kugUMQPm-SMuixk0956W5yzlgSpVObT5rZj3v4WnjruJ-QwNxp6F7948DmjtazxkMVvYe80A4PJlBRbD2saB1geM0Jjkbvt_GIJbZOCdjUuduhbNAHyukx5RL_eYnhKzZVuK4ZgqvuMEhpqdLEY1hRx6XMF0SxPHEiq15_eU5_2jS7V4VHIBaBitx1m_97kKo2Lkx9fOlOaHq6N4wJMtlSbB-QVwHXsVLjpQutjTqvgaSN6CuTCIUW6-0QR7XYirMl5RW1M5DH3FclNAYblGNFBSK5uBvn-NIoWZyKFy8UJKqjLmVt-TxysYqAxI7t1ZJw2pOuBP6NpTGkHgbMydnFqC49hlyFlAhouisQctQy7CU0s_VYYPDUqRiTbMxUmhh-7zDWNXwETJ7Fm3vLzipDx5JKPui19dEhFRya-Qqxb85PO0mGd13Xm5PB32cTZqIMFj4CFvnGIT0URggxwis2-0S6JNiI6uOt-BCfxUlIfGzkKXhg4MoqPBrV3CLMu3PzBGZ7VEyKduBk_759YZ1R1SkK4dgt0WN-fG6-a8Cv8loqztfR1OKmmLGMT_9PL-2oUteaTMi-eSc6FLiba28WTme4Rzc5Q0ncGCH4e1ZL7SPlp0UT9RdaNyGczhG0Z_1FRHSYp_rZWybshqQEZzwrhC_TDM7EHGisbeDpsTFiMfzMr5iecaiIeMILwe8FheyzrQ6FSXnIuthjMoF0Liw6cZ2Ku1vfeXa24SEggac3R7zcarZMEkIyjnQ48CbCUaVIwFWXvI-tEy-vNtR6EQOl7VxKs8X4OKEo5VJt39R4ac5bdWBGLJ2bW8QWuswWd1NrztTCbCK-S3G84ZvFKmGn-MIgnzNamGSsV7Lz930WRdP8h6LQg4UNucbl874l9RlHjRkAKR3bm7YCOj64EDSkQtQ0AQ3uPLe4JPKzIyFXxJMTj9fyWH19tfoCP6YoG4PMHZlngadBCWIfMEZWgxQ46iWTcqqFXYxy_MAAG5cZP2v5YlfMqeU8uWqoFQrZHBKz1ZCL1hpC7UV3p7UIQ2HHPkLhhh7BV9BGvxb7-5YDHSV_6okWDRz-HFWK0idLH5nGtzBjKJj-yiqu23bzWAljmEjCfEkrw5aXir-2RkFVeQMI96gxy35T3TR13J3Qr8qXrMLdA8KIoQKKkCsS5qoowvP5hxdPOQwoHgIefXw0wkkaElq31fYSR_OSA2FWCV8msEcYxlLwvKb4tQbdlVOkMVn88rXaYnmu4ASdmE8RXh1TCAmjQAvW3HseTALe94uGj8ajcxkOwKaKu3Y-dfC88zi33Ss4Wj2XO_kUFwtDj4XSpa5Fn5cUQOvqwvmK2Qlns4_HyIT-HXpIkevv7bhysG0kryhjBGXafXTx1-OSApsE4B69n_CTmjH_s9MUWgT8S0c3ybtJVsVjkA6_cjMoWj2uo3pnmSfHjlPVDJPWZJpayqYhz6AOEdgF3SwyCc966irpBiFyJ6FPpQPRDVMBRTG41altVETgSkQ50PFOs2_d0H5P5O4xFFPV7c3siPhmfprH_LJiaW8-pJdm0U45SmckBsy9VL_zoFBxlyRDFSFoGk45pcpyQu2XssJ08jUff8wAHq5sQCi3FkvxZ6kIiVkl8Jk6z8GT5hreinzUj_-RNBCEXvQdSpEyZEgRC_44Og4R8zxEYWrOEsMG_ZRhgTgIhK4j-lTfp8Au1RczlG90YIgxoozQP_RY5JYNjvf6EaBu0EBKgWLH1zz1YGc_DURLv-0nyxvd-l9swNwoB-zgRtQMfzVHCJB0a_APRFPPEriTFDsY4mZqpPFMV3jq355_uaIQdWHfBz-wt85-m3T4DlhU8pyx6QrK8maBuGuuBRu1yU7vhfg2BM07FZR3kONb30NhMHjS6WXttWlEQNGDuZVpnS-Cbijr7V3hBlhJAwHnAlCuY-vX6RkMAQ8nu71bdIl4XPstGh9xRCs0Y_x0IAaclUYjtblOgRz_SnsjFTCVHsqBJwyq85ma3nzLhhHyLhOrA; SID=g.a000swiYNiWQ2Gm3i6KbHTzizrLuHnhccLctgPOS3GIJktNE0ElnustmEJZebcogZIZXeIYs-QACgYKAUgSARUSFQHGX2MinOiqOhKB79mvm9fjyMOOtBoVAUF8yKpKrFjldOs8gd6yFn_z0C480076; __Secure-1PSID=g.a000swiYNiWQ2Gm3i6KbHTzizrLuHnhccLctgPOS3GIJktNE0ElnYFDFPc-lHnrRrzP8Ob0zegACgYKAQQSARUSFQHGX2Mi4xRWUBY2jqoUt6jQB2cJiBoVAUF8yKo64eBdGhhWdSalsUSu5-jf0076; __Secure-3PSID=g.a000swiYNiWQ2Gm3i6KbHTzizrLuHnhccLctgPOS3GIJktNE0ElnQV9K4D9WIOm9EeOI7uW6hwACgYKATISARUSFQHGX2MiG2EUwwaEovivATYFTY308hoVAUF8yKp8rMWhCkBNlTykojuOTTkL0076; HSID=ASBeHemwL0VQYIOGp; SSID=AIw8K4tZIbEkA1VrM; APISID=0q_hN-C209juoDv-/Au5Tsa9wVBi9v5zMT; SAPISID=xT-_XjHpS7DbiBJC/AMGArX5cPYTF8Pm9P; __Secure-1PAPISID=xT-_XjHpS7DbiBJC/AMGArX5cPYTF8Pm9P; __Secure-3PAPISID=xT-_XjHpS7DbiBJC/AMGArX5cPYTF8Pm9P; SIDCC=AKEyXzUSyxI8MM31q-3g0uYWiDNMasvIiHZRu5PR5iza1JXW8huXO2GoNwu44Hbm4XOAWoMDlaZj; __Secure-1PSIDCC=AKEyXzVJ5tBv7Ub7Ins1ZaLvfAeyJL1EV0HWqKGxxlARYwzJBaFAsnN3dz-VN5xkSZPwYHB-PuA; __Secure-3PSIDCC=AKEyXzW89AITifvVZS1yyF0uKiN5vZQTWtS6deoJy0s_JAA4shHLsIuznzqGh752TY3BrKmt_iU-; SEARCH_SAMESITE=CgQIkp0B; __Secure-1PSIDTS=sidts-CjEBmiPuTYZeHTMjYR_bXM-TH3d7_tRZdN8kcI2Z95rjUY5tzd_E053AfSvfcQAqzh4SEAA; __Secure-3PSIDTS=sidts-CjEBmiPuTYZeHTMjYR_bXM-TH3d7_tRZdN8kcI2Z95rjUY5tzd_E053AfSvfcQAqzh4SEAA; OTZ=7904857_34_34__34_; S=billing-ui-v3=V8On6v3WAmtIJheJWRXUX2rTlLN99Ehf:billing-ui-v3-efe=V8On6v3WAmtIJheJWRXUX2rTlLN99Ehf; DV=w4dbf8ikWjJRUOUcLYCoi47bJEl8SplvU3dyzytnNAAAAIBHfY3ypUEu5AAAAPCSFA-qpoSSRgAAAKaqsXzyEc3TFAAAAA; OGPC=19031986-1:; OGP=-19031986:; CONSISTENCY=AKctkznJhpD4FFQ_-KkOKVrilfN1x1AR-Y_SyAbqNp6JqwC7xj4nW76y3om7lfMU7dGhWg7FcxTif-VGxM1a46DD06U_qMjOcAqBWIWxGTpe6Z9aG81KPtyNsE3G3EMNSKAJP4BdVnILQDXSquXxwUcZpa75eE1YkH50O0-EeCCLI36sALUxQRQ=",
-	"AEC=AZ6Zc-WK4w0I45M285ZfXoJ1fRgAkOy8qVo_BpkVRWOIMWpMfuMH9T38aA; NID=521=wHfIiqyjeBiAzz_lWs1-MOCYAbj9DNZbEcjPi9TkVI6TZNnIVN4kkaxlKIYwMby5-ub1PQmNBz2rPyTRv1larx1Xr1LeWluud9eX1x43nKQ-GLYBIIydL5E72OO-jskeUkdzSwL7mphwA_v1rDba7l8BNcNPIUFy59IUPvjjWkdAJdfRZSgV6EUE5TBL1FmnULdM86l2NkEZKwzDIoNqxTIvji5TrRpAdBHI7l9_3Cn_IJF60cCx-JrKq0eKC0_gqTLXs9AtEz50Lj5RGP-bhM-TxtgstwZ17s2m_IYaHm-N5ClEs-6Fo0OBanRKLxo6E32PEykGHy2kJ0TMqJPoEx_uftw4czc3wrMdk53krVUeK3u7fp9fSobJb_1tRGUyojcJYW1PmJeS0ZzntuqIDTNQms8ucSqBMVyXDQoq061MpZLwkTtGnlg2aLghhfu7ERsqV-piQ_-cDq-o0TVveRs-d-7maCLfbHQ7iIISUf7Cgym1an50qo0zZgpmkeGKcLaBrH3TXfJ1-Rr75nm3dRGphxa-z_yjjTqtyynjMu3j1DdvvfvkovOFITMY9wmFQwO2TEFiuR68ti8W7jZrN1qpqJPQBbU4_xD5yhUR-BkKVP7SU70ZjJ8fi-2IrqeYZg2eeTuQXYgqWFui33wTAtifmYDFYp4TRFPYHWko_CQRd1eAz6Tp3kejkJFl67-dhh4UQdSkikud9sIDlZGb3kTzMffVkT1q4BsgsAmmB7nUbT3oLyZZPP2onRUyDGjldCUBk3UElRYATyQHKXiazyq16O87qSMsxl89bXht7neuBnjhlHG8L8hCqv00vfjsWjjlQQ4ACv3G90hSZAfzXWGG4rfcmeYG2_3RLJXOGPJmDzQKIMpusA0vTfBV0XGXDla9g805sUDGaT9qjpT-LGu7MZJ-Ghv8TTpDkDUe8mpS-th9nwIjGnp-lKUYQLUvLHRGaOhn4DIVgncedTaMe_zH2J6RSVQKqMfEejHUmgi51f3hh20C46g8evn8wd_SC1icerjuuW8ABP54dNgC0SVm0QH3QXMlcdFPXZ80Oqm443TAw9Zm3HT73UlthGCXk4uSkyyndZP6DITcsOlj8h0v4MqqV9pNjHXPGd1dgnB55Pp0ZA_EtTC4OxJQ5tBsghq8y02HZzg_o0H2j_bgw3XEpOQGD0hcvR3CNhd8EvZtTolaz3NMCNinz3BdJ1PQLBMZq7T_WxXtoTwKFPD6dR02M7ekTTR3pDefUYFxLbiN2CZCDUz4HdFz2kADV4FqYBhEhfPb1BMr8OpnHJqYTpRHqX-m_OUE1aXhSxrZlHB58qntxbDYfcko_w5XVGxNyU-X-qKdGCKLZvlbWyWc_94swKPc915BjRlvBCedeM_9RM5za5bzvZq_zODYEXuBA1VreJsbjR4tjVcmn39JjweEuqulFdVck7ISU6eGrIuazqugQZPpJXkhfy-92S9Bo_HtJRA30hCOn5PkzF5JBATOQc55XowVs1s_YVkkFapk7gg_AmmJ6JXbSvmB777anPNIYCdhKjvC-96x1QtyJ7DF2Mt_DMdRlP1M65IN135ba1TvHa41F9OJXTO5wETS06xlLbkuCbA-_w8TkYBiICccOfbp6q1Rqa41GEtox6C6XFg1K28zLeHWJjdxDT8uvz_GzCBOqBamS6blE3N8Q72u-SuiX3GPi-9bOjOui6IofJAtmhdt9qq8Wou_TNrVTLCQIAsDVL8zLd1NcHdpBklTWGmlHyVsb97Yb4peqGuLRVokFkxgnMsk3AfwqLiVIz-CMOeOBIASiJvqNu_zDABoVsVulE1ev7t57YGlhqVDkxchcR5Uj_l78QHgX5OuoCdNDNRpepeukJ9MlmFb6yyCfro80LlRC8fkCNv6b6K_N3PddHNG64dcLP0rTHy5Bs_aOo_OxIBVWUf-Q1QnOg56QX1tVjgiwqXA1kFXZXNPTLnUIJaZ5lXsKqjGhNs7oXDWEpPJSYEGnoAiff3S-bCYlEji9jIWWoq0gmMnz38MZMnJZ-hvpdZNVO-RFvGP08xZ6gpZlkh1327WoEStODqO002an3hhX-0mX9qDl2fMRSQAQkpn-Bu4uIHE490hpCSiL_vM85AjVGwwppnxAvHmbg-vXc5LW4KVFq3BK8ita-W4UTqDftr191Iwo9Q2w8UqB-oYueYqi8qv9Oa1K07m9EA1LP1RCQ_dSeksPDAqvKbC9QpochqSTXr3SBIFzEVJnG6Pig5jMWBM6w_dvNjSnhTa5M1RlMZhtXybFPz0yuy9WBRsdIFgMwejIyRR_u7UFGDVMRTxVyrLbbJE4So6kFkTh2F4Dw; SID=g.a000swiYNvNbbkVsiPSPD5LfYkIplwCDbAHSHPXMADallggdEnJTAflIaL2gR7c2ccmnqGeD_AACgYKAfUSAQASFQHGX2MiPOUMrax1GTTi54l1Qzk_vBoVAUF8yKo2JNXVpyfqaWO3VM7czh550076; __Secure-1PSID=g.a000swiYNvNbbkVsiPSPD5LfYkIplwCDbAHSHPXMADallggdEnJTqT9V5wngDyOxA3VfcJ821gACgYKAQkSAQASFQHGX2MiVfVAVJRSiazkqRPA1lfsOBoVAUF8yKqdpVEnrU9m7U9VWQmmo9qm0076; __Secure-3PSID=g.a000swiYNvNbbkVsiPSPD5LfYkIplwCDbAHSHPXMADallggdEnJTmmMFuzYfD_Ul0Q581HHcfwACgYKAa0SAQASFQHGX2Mit6L9ewzXw7MK9dqlNLSkWBoVAUF8yKqm_NT6_JZCp-COcMZKdfGE0076; HSID=ASBeHemwL0VQYIOGp; SSID=AIw8K4tZIbEkA1VrM; APISID=0q_hN-C209juoDv-/Au5Tsa9wVBi9v5zMT; SAPISID=xT-_XjHpS7DbiBJC/AMGArX5cPYTF8Pm9P; __Secure-1PAPISID=xT-_XjHpS7DbiBJC/AMGArX5cPYTF8Pm9P; __Secure-3PAPISID=xT-_XjHpS7DbiBJC/AMGArX5cPYTF8Pm9P; SIDCC=AKEyXzV1HOKWEfnlK5Pfne_Jam9fKgKLY6MMZ4msA0d0_ztXpcz0WVpIQ0ifYu4mvGB3VTj73QJ8; __Secure-1PSIDCC=AKEyXzWtCveq6Wbntvpr7oKn4e44EnOwMNmlAEb0pXBiQZTHIpfMZ2HPE-KYC14XDAizezzYJN0; __Secure-3PSIDCC=AKEyXzUVF4koBhgQPN5x3PoZj3bkK8LmIw-CqgoYWt3Km_aGvvju-az9nhHo1uGwekC68BYOPwHI; SEARCH_SAMESITE=CgQIkp0B; __Secure-1PSIDTS=sidts-CjEBmiPuTYZeHTMjYR_bXM-TH3d7_tRZdN8kcI2Z95rjUY5tzd_E053AfSvfcQAqzh4SEAA; __Secure-3PSIDTS=sidts-CjEBmiPuTYZeHTMjYR_bXM-TH3d7_tRZdN8kcI2Z95rjUY5tzd_E053AfSvfcQAqzh4SEAA; OTZ=7904857_34_34__34_; S=billing-ui-v3=V8On6v3WAmtIJheJWRXUX2rTlLN99Ehf:billing-ui-v3-efe=V8On6v3WAmtIJheJWRXUX2rTlLN99Ehf; DV=o0UuqGbBFV9W0KXi2U5DAWLRLoV8ShktVbcxKQalmAAAAIBHfY3ypUEuxAAAAPCSFA-qpoSSNgAAAI9ZOOcFiGXgEgAAAA; OGPC=19031986-1:; OGP=-19031986:; CONSISTENCY=AKctkzngF77Mz63lrHbKbNGocVSjcr_5gRdEAE2JztQdTggX5GAghAzJBcq6yOrVo7eGxF-bNB8JN-Fql7Jpo5zb4oWahikZ_Q0wbJxAiwYea8hcJAL9NnByYvk6wKmDZ-Ql3ZeF_r3eqpw7FGz6JtsPOo70E6p8s0zGh9KULQs3mb_L9vdVBiYjSzCmPyc9DRKEhodJ3Dpf; SG_SS=*DjKaMmryAAYK59ZdKud98UoRGz_HL_QEADQBEArZ1MLdJLZR3WseoXkrrfn3K9iOy6UyDqSz4orfFLXDvRZy3D5jduT6RM8RMXzQpl72PQAAACBtAAAABFcBB0EANZIh4kRb9zDuSg_j5u4mPB7qFlrnjQmbidBH8zsh_7NI4cpz6RV5Hb1sEZ6xgv26UKke3WIopgIxfW9N6EOUx3H2ueiyvcFH__-fxubUEQfiMlFPK-KUapCOMg7DFAalb-oapSUFNgta5n-VDD7Ptyvx5Zco5Cnt5rjz1iiJ_jzuAH0ctdyKZtt60d4cN6C96onIqLflMAaYbOu1che34wKOzzLli_jTvpzUHRi0kgcHYQJ_N7AxlG8YRkFPQtSKYSA7Qg6jMNF0uxkYplHIwv7RKGrAuDQujp69xOpak6AGf_AVd6lTyHZnKuL2k4eh3roef21T_eZ5018Hx8SGCJNbEMb96JhlE7NhQHrza1NLzoJu5JVffdI1ZLDwQcEOv_p8pRaL3VUjHZ3oOiDtLvOniDGWsd3uckwSqnDXY8IEjbDgmpypnpvwlZ7NHAADA39vtPQlgczXtw2nIks_2QxRGjLTxpyohj3irTeyav8_iVTQzkHHPewlcxqIA1g5YFlwAViK-nJBvzES1QpMEbiz6yCbHWD2Se_9Gh1Mb9uZSCeIpkC4f773ZK0nhQ96o7bmBR4zPBvJJRuByYwkDAe_VceYu6d_EPct75AQvI2zDs0ZKv1VGHYtqsNKuK0Dt4ki5XOAQbjxwW_rs2mAOACAmXPojLQUlq1-Eah1FJV4vGsbnIKPs6_Er_fQ_wcakmJEqFtM4GsocATJu7vXpyxujx04Hyp25aDVYwp5_q2ipJlOW4MthvYGcUdPU6AYCM4AiEF9Uym-E6A-mYtDej_E7Hnr2zABvN4PxyNt2pFCkdkX3UTGnGqQ",
-	"AEC=AZ6Zc-WK4w0I45M285ZfXoJ1fRgAkOy8qVo_BpkVRWOIMWpMfuMH9T38aA; NID=521=VLg5bs_FNg3sHkrCHoEM8hLLt3P7rseIb4Zk35R_e3XysJ0k2xtV9H0mJp1SiGCP-zTx9ThpCqTCl2xkhJeBbGzZZYzvxzIzZR7EFvuxsKAPbl6lK170Zx_c1RWNJa-bXKtZTvdgZ_175Yq_aTimCIktRhM8OgoT_JPsc9_NQob9IBhwIKIgi-1ocOo4mIwENjEsJWVMSRJ8zZEJepUPRzuEntvZ04ET8JNrxmCt679lfR3VWBpRcUBoby6G5OAVT1-ZhRBQU9EAiFz6VRBc7_og_RgD-A9-kXL9kT8M8DQC-t61L9tk9KlUR3X8IeqLFOvYNdErxkKaxHjbjJ00pjYRh43vpW6BN5_GyWnBgCpOQ_ikiWmNDz6vIRZ84S95cyroPhWlfDrvLoFMEMpQH9sp4dd2cE3d0JZmNe0Qk8u6b5zQMFr15aEFG26Dth99RsWuEkjLtxr7U8LPtQWkTxJM9eLBOMIJTrrkw8TPQ7_x8VrXiYx7laCTm3zGh0Z_ow_Ntb4Qtv4e56Z8pnPKVirxkX8K9yHW3K8ntAw64EBsiD86PfxHu5GTw-Mk_oD0DDmLh27BYicM43j-yNBjY7KaJrLiEOjhUb9dVFLFZpSsOCkk13z6DxrYPciVaTIQDrAXIfYQ3ySIg22UzxHvAQAWE_hieUB6pk6Sfj9zsE7BXJCq3dGg075DF8E06JQ8N9Iy_kttgkfEAjnTjZ2WDQgouR9A6k_InQMwP7RDjQWNt3F4ftw9P-dsn37b6UifCmrOv2Y5TLSzEVfRT-aqwVpTbnA-zxnV4a0cbQD_FUskuFsAlbJH2EOM2u1vWS6UtvauReo4r63qBl-OvNYwrtF4koo-y1fYgKLR7IfRV52hEvtOAusEABg0ZZyWcxzoWvZHuf5E62IUFJWs-NXDM3Otija7dwsK7wD5bpdRyaqrOF9zN-1rBF7ROlWXg3__iNqGLl5qn17FhEvOCSVzOZRqYExmFYVgWynxlmSqIr1NnhykX3N1ruxvrxQFnxnpCL8Fzte2lDeT2I8l4B8lf8ByVbADs2WmqOGTasmsISZdFM1J5Ikq2tJ4s64e1wwOkIA1dz5ab2QriUZxhp9wyjv3IBmWqxokzCmdtb-sIG_pp8i2bNFpXuOB4sZ3F6efzl1AApHyTndyEAltbMc8hFsF4sPs3TPlu-vpP1JNVaLH0IBJDWeabGXCnJxL7FGPDHUrxuFLDMAUwFltadqSLFzaaH547eLumUoQxLhq88wnekos6Hg5rR3BjsihvUdVuBPZ_hMfIJighpJYarC3L_gySBIgqYTK7zei6VNkcJoEvWi2jKMWbRgY0ZN8xHkfkQE_NpH67KQrSwDbTuaBEFxJ-7eGRazyPkjn4ApkTZCMW-i0MIByosRQqIEQOtCJd6ggW7Nig3GOkw6opJEtQ5K5xyljXEifpiBRlAJFbO-LjO01cGVo5s5BsgOLLaUklsXwb6j2YmRPRTVgwFRwogtkFp37WhR_tiY8eAEZoqLRUau5tXHL36Ry1wMzTsR3KRhCDooPmDO41KYMFhkOqxb2PrSSHZRTPpcngLdJ3eoDBr9kEZV9XWWgYqawyJ_4MWSJNFU1n4nEx3iKPFAO7lgdCtbL-E88jBDgDw07wpJafB4mhQc1WXLettEMNXdra0d0HKoDKaTJU9f1NclGOrENdRQI4T_cXEuFv3UVuqDhIF6C_7DL9y5eaKs5GlwRtG_toI9ibj5qHm270rxMLdBnxu2OThklLhiM2wYkgWFXlJ9gQMLLeEtbtmIpEiT22uj7taFnaZY_eAuaiRm5OJjypUWqWqggoZrMOlBbTcel9SItpl9eLfgy6vjEMNPneopJQ-LXOMz0psr16XIvNeaMAZ3jx1xk-xN7pJmWntkYkzRG45ygR91epklynkUyPz-S3lbAKHILH0Z1bzCBDAGT4ELUkZgdRzSvlt1_72Q8yOZE3LfCyJx6y4h3gos-6d874r8nvctKIilcEsfOaqq-h9vEXP7ATlppTHWJRoj7rZjeUB0wOH6WIJlPAP9WWErVHcv5gu8t1IMjTZeEcxHQZrlAZVBh9KQgwu5hsexDUV_xCSqR4EZWOn_eq5sIh8e-56JcDt-3f64DhzGm8WWgL8hxezTXLojlE_6jAyWrLWY12vNx5sQNR-3kFF_wD-FQEeOmMQXx9B2IzFTL9QtweJIq_Nrrj9bl0zHvhQVK1dxOS9aW_8yz-7uDzWS7p6BCCUitr6tqZtQ8RXUSj8hkORcyd2WjDr_PFvPjM_ZROx61Lr2xxphd; SID=g.a000swiYNjmVt85bR1pNw41eoFmh0CfFHcddr2igE34BR7EjkhetjYEwQczjcKWZWXRC7cTaRwACgYKAQwSAQASFQHGX2MitB87j8HwfiA9J34AS-pUKxoVAUF8yKoPA3kgVhR9nvo11anaeK5V0076; __Secure-1PSID=g.a000swiYNjmVt85bR1pNw41eoFmh0CfFHcddr2igE34BR7EjkhetUokeljSRDhbEu-6AUHadsgACgYKAbsSAQASFQHGX2MiqeGCcr2VsfhISugWEv_2jRoVAUF8yKq0qt-jB1hAEDTqm7LkVHB70076; __Secure-3PSID=g.a000swiYNjmVt85bR1pNw41eoFmh0CfFHcddr2igE34BR7EjkhetY0VR5Vmff1oq1DkgjwYPyAACgYKAXESAQASFQHGX2Mi3mPTrIz5FOwJ-N0QbHkB2xoVAUF8yKr2Stn39c7eQMNDsvDpIej-0076; HSID=A_P9EBOqNvh7xKEF1; SSID=ACpNUBvUNjkHtNjGP; APISID=Ro0iWdqXzjfcJ4yh/APUUT9PYsBBGDUEsG; SAPISID=xdeH_DGDWkcj5OqV/ALx3l7EaMbTPWExK8; __Secure-1PAPISID=xdeH_DGDWkcj5OqV/ALx3l7EaMbTPWExK8; __Secure-3PAPISID=xdeH_DGDWkcj5OqV/ALx3l7EaMbTPWExK8; SIDCC=AKEyXzW_KMiw3LlqHME0yuV5TBqRXGaiLUgeWPh_qtt-EqKZYFJoHh4esRvTGRrJzAITFKQCpxwk; __Secure-1PSIDCC=AKEyXzWA_Qey41HbSCWmjEdIKnwvTeU3AeWlAL26z7C7imq-0EjBIqa1QJn04rQapJKt8Cy2bBg; __Secure-3PSIDCC=AKEyXzX_7Lrs8njr5QJlOEy2qAl6Wszj211h1JGBJLMikOmGbIHDnDPUSAmRD0PIyYqvxP1lNfPP; SEARCH_SAMESITE=CgQIkp0B; __Secure-1PSIDTS=sidts-CjEBmiPuTZH4SWHV9vDr62vqLG8lcUuUHZO1wXrGP2QLg9SffxqI-ScVCj9AbLISeEq1EAA; __Secure-3PSIDTS=sidts-CjEBmiPuTZH4SWHV9vDr62vqLG8lcUuUHZO1wXrGP2QLg9SffxqI-ScVCj9AbLISeEq1EAA; OTZ=7904857_34_34__34_; S=billing-ui-v3=V8On6v3WAmtIJheJWRXUX2rTlLN99Ehf:billing-ui-v3-efe=V8On6v3WAmtIJheJWRXUX2rTlLN99Ehf; DV=o0UuqGbBFV9W8JiFc16AWAY-qK97ShktVbcxKQal2AEAAIBHfY3ypUEuxAAAAPCSFA-qpoSSOgAAAF0qnu00FCAWDwAAAA",
-}
-
-var UserAgents []string
-
-func getRandomString(strings []string) (string, error) {
-	if len(strings) == 0 {
-		return "", fmt.Errorf("input slice is empty")
-	}
-
-	// Seed the random number generator with the current time
-	rand.Seed(uint64(time.Now().UnixNano()))
-
-	// Generate a random index within the bounds of the slice
-	randomIndex := rand.Intn(len(strings))
-
-	// Return the string at the random index
-	return strings[randomIndex], nil
-}
-
-// randomly select cookie
-func GetRandomCookie() string {
-	cookie, _ := getRandomString(CookieJar)
-	return cookie
-}
-
-func ReadUserAgents() error {
-	file, err := os.Open("user-agents.txt")
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	var agents []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		agent := strings.TrimSpace(scanner.Text())
-		if agent != "" {
-			agents = append(agents, agent)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	UserAgents = agents
-	return nil
-}
-
-func GetRandomUserAgent() (string, error) {
-	if len(UserAgents) == 0 {
-		return "", fmt.Errorf("user agents are empty")
-	}
-
-	return getRandomString(UserAgents)
-}
@@ -1,175 +1,279 @@
 package search
 
 import (
-	"compress/flate"
-	"compress/gzip"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"googlescrapper/apierror"
+	"googlescrapper/config"
+	"googlescrapper/utils"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/andybalholm/brotli"
 	"github.com/gorilla/mux"
 )
 
 type ProductInfo struct {
-	Title    string `json:"title,omitempty"`
-	Price    string `json:"price,omitempty"`
-	Link     string `json:"link,omitempty"`
-	ImageURL string `json:"imageURL,omitempty"`
-	Stars    string `json:"stars,omitempty"`
-	Reviews  string `json:"reviews,omitempty"`
+	Position      int          `json:"position"`
+	ProductID     string       `json:"product_id,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	Price         *utils.Price `json:"price,omitempty"`
+	OriginalPrice *utils.Price `json:"original_price,omitempty"` // Set when the item is discounted
+	Merchant      string       `json:"merchant,omitempty"`
+	Shipping      string       `json:"shipping,omitempty"`
+	Link          string       `json:"link,omitempty"`
+	ImageURL      string       `json:"imageURL,omitempty"`
+	Rating        float64      `json:"rating,omitempty"`
+	Reviews       int          `json:"reviews,omitempty"`
 }
 
 type ShoppingConfig struct {
-	Query string
+	Query    string
+	Location string  // Region code from config.RegionConfigs, picks the storefront and currency
+	MinPrice float64 // Zero means no lower bound
+	MaxPrice float64 // Zero means no upper bound
+	Sort     string  // price_low, price_high or rating
+	Page     int     // Zero-based results page
+	Options  QueryOptions
+	Render   RenderMode // How pages are fetched, defaults to auto
 }
 
+// shoppingSorts maps sort orders to tbs values
+var shoppingSorts = map[string]string{
+	"price_low":  "p_ord:p",
+	"price_high": "p_ord:pd",
+	"rating":     "p_ord:rv",
+}
+
+// productsPerPage is the number of products Google returns per shopping page
+const productsPerPage = 60
+
+var (
+	productIDRe = regexp.MustCompile(`/shopping/product/(\d+)`)
+	reviewsRe   = regexp.MustCompile(`([\d.,]+\s*[KkMm]?)\s+(?:product\s+)?reviews`)
+)
+
 // ShoppingScraper handles the scraping functionality
 type ShoppingScraper struct {
-	client *http.Client
 	config ShoppingConfig
+	googleFetcher
 }
 
 // NewShoppingScraper creates a new scraper instance
 func NewShoppingScraper(config ShoppingConfig) *ShoppingScraper {
 	return &ShoppingScraper{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		config: config,
+		config:        config,
+		googleFetcher: googleFetcher{mode: config.Render},
 	}
 }
 
 // buildShoppingURL creates the shopping URL with parameters
 func (s *ShoppingScraper) buildShoppingURL(query string) string {
-	return fmt.Sprintf("https://www.google.com/search?q=%s&tbm=shop", query)
-}
+	params := url.Values{}
+	params.Add("q", s.config.Options.Build(query))
+	params.Add("tbm", "shop")
 
-func (s *ShoppingScraper) ShoppingScrape() ([]ProductInfo, error) {
-	req, err := http.NewRequest("GET", s.buildShoppingURL(s.config.Query), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	cookie := GetRandomCookie()
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:134.0) Gecko/20100101 Firefox/134.0")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-	req.Header.Set("Sec-Fetch-Dest", "document")
-	req.Header.Set("Sec-Fetch-Mode", "navigate")
-	req.Header.Set("Sec-Fetch-Site", "none")
-	req.Header.Set("Sec-Fetch-User", "?1")
-	req.Header.Set("Priority", "u=0, i")
-	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Cookie", cookie)
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("TE", "trailers")
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
+	var tbs []string
+	if s.config.MinPrice > 0 || s.config.MaxPrice > 0 {
+		tbs = append(tbs, "mr:1", "price:1")
+		if s.config.MinPrice > 0 {
+			tbs = append(tbs, "ppr_min:"+strconv.FormatFloat(s.config.MinPrice, 'f', -1, 64))
+		}
+		if s.config.MaxPrice > 0 {
+			tbs = append(tbs, "ppr_max:"+strconv.FormatFloat(s.config.MaxPrice, 'f', -1, 64))
+		}
+	}
+	if sort, ok := shoppingSorts[s.config.Sort]; ok {
+		tbs = append(tbs, sort)
 	}
-	defer resp.Body.Close()
+	addTBS(params, tbs...)
+	s.config.Options.ApplyGoogle(params)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+	if s.config.Page > 0 {
+		params.Add("start", strconv.Itoa(s.config.Page*productsPerPage))
 	}
 
-	var reader io.ReadCloser
-	switch resp.Header.Get("Content-Encoding") {
-	case "gzip":
-		reader, err = gzip.NewReader(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %v", err)
+	if regionConfig, ok := config.RegionConfigs[s.config.Location]; ok {
+		params.Add("gl", regionConfig.Gl)
+		if regionConfig.Lr != "" {
+			params.Add("lr", regionConfig.Lr)
 		}
-		defer reader.Close()
-	case "deflate":
-		reader = flate.NewReader(resp.Body)
-		defer reader.Close()
-	case "br":
-		reader = io.NopCloser(brotli.NewReader(resp.Body))
-	default:
-		reader = resp.Body
+		params.Add("hl", regionConfig.Hl)
 	}
 
-	body, err := io.ReadAll(reader)
-	// write to a html file
-	ioutil.WriteFile("shopping.html", body, 0644)
+	return "https://" + googleRegion(s.config.Location).GoogleHost() + "/search?" + params.Encode()
+}
 
+func (s *ShoppingScraper) ShoppingScrape(ctx context.Context) ([]ProductInfo, error) {
+	body, err := s.get(ctx, "shopping", s.buildShoppingURL(s.config.Query), "#main", googleRegion(s.config.Location))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %v", err)
 	}
 
 	var products []ProductInfo
 	doc.Find(".sh-dgr__content").Each(func(i int, s *goquery.Selection) {
-		title := s.Find(".tAxDx").Text()
-		price := s.Find(".a8Pemb").Text()
-		linkElement := s.Find(".mnIHsc a").First()
-		rawLink, _ := linkElement.Attr("href")
-		// Parse the actual URL from Google's redirect URL
-		var link string
-		if strings.HasPrefix(rawLink, "/url?url=") {
-			link = strings.Split(strings.TrimPrefix(rawLink, "/url?url="), "&")[0]
-		} else {
-			link = rawLink
-		}
+		title := strings.TrimSpace(s.Find(".tAxDx").Text())
+		rawLink, _ := s.Find(".mnIHsc a").First().Attr("href")
 		imageURL, _ := s.Find(".FM6uVc img").Attr("src")
-		stars := s.Find(".Rsc7Yb").Text()
-		// reviews := s.Find(".NzUzee div span").First().Text()
-
-		products = append(products, ProductInfo{
-			Title:    title,
-			Price:    price,
-			Link:     link,
-			ImageURL: imageURL,
-			Stars:    stars,
-			// Reviews:  reviews,
-		})
+
+		product := ProductInfo{
+			Position:  len(products) + 1,
+			ProductID: extractProductID(s),
+			Title:     title,
+			Price:     utils.ParsePrice(s.Find(".a8Pemb").First().Text()),
+			Merchant:  strings.TrimSpace(s.Find(".aULzUe, .IuHnof").First().Text()),
+			Shipping:  strings.TrimSpace(s.Find(".vEjMR").First().Text()),
+			Link:      unwrapGoogleURL(rawLink),
+			ImageURL:  imageURL,
+			Rating:    utils.ParseRating(s.Find(".Rsc7Yb").First().Text()),
+		}
+
+		// The struck-through price is only shown for discounted items
+		if original := s.Find(".T14wmb, s, del").First(); original.Length() > 0 {
+			product.OriginalPrice = utils.ParsePrice(original.Text())
+		}
+
+		// Review counts are spelled out in the rating's aria-label
+		if label := s.Find(".QIrs8").AttrOr("aria-label", ""); label != "" {
+			if match := reviewsRe.FindStringSubmatch(label); match != nil {
+				product.Reviews = utils.ParseCount(match[1])
+			}
+		}
+		if product.Reviews == 0 {
+			product.Reviews = utils.ParseCount(s.Find(".NzUzee div span").Last().Text())
+		}
+
+		products = append(products, product)
 	})
 
 	return products, nil
 }
 
+// extractProductID reads Google's product ID from the result's data
+// attributes or from its product page link
+func extractProductID(s *goquery.Selection) string {
+	if docID, ok := s.Closest("[data-docid]").Attr("data-docid"); ok {
+		return docID
+	}
+	if href, ok := s.Find("a[href*='/shopping/product/']").First().Attr("href"); ok {
+		if match := productIDRe.FindStringSubmatch(href); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// unwrapGoogleURL returns the merchant URL wrapped in a Google /url redirect
+func unwrapGoogleURL(rawLink string) string {
+	parsed, err := url.Parse(rawLink)
+	if err != nil || parsed.Path != "/url" {
+		return rawLink
+	}
+
+	for _, key := range []string{"url", "q", "adurl"} {
+		if target := parsed.Query().Get(key); target != "" {
+			return target
+		}
+	}
+	return rawLink
+}
+
 // StandardShoppingHandler handles shopping queries
 func StandardShoppingHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	query := vars["query"]
 
 	if query == "" {
-		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Query parameter is required")
 		return
 	}
 
+	params := r.URL.Query()
+	location := params.Get("location")
+	if location != "" {
+		if _, ok := config.RegionConfigs[location]; !ok {
+			apierror.BadRequest(w, r, "Invalid region code")
+			return
+		}
+	}
+
 	config := ShoppingConfig{
-		Query: query,
+		Query:    query,
+		Location: location,
+		Sort:     params.Get("sort"),
+	}
+
+	for name, target := range map[string]*float64{
+		"min_price": &config.MinPrice,
+		"max_price": &config.MaxPrice,
+	} {
+		if value := params.Get(name); value != "" {
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil || price < 0 {
+				apierror.BadRequest(w, r, "Invalid "+name+" parameter")
+				return
+			}
+			*target = price
+		}
+	}
+	if config.MaxPrice > 0 && config.MinPrice > config.MaxPrice {
+		apierror.BadRequest(w, r, "min_price must not exceed max_price")
+		return
+	}
+
+	if _, ok := shoppingSorts[config.Sort]; config.Sort != "" && !ok {
+		apierror.BadRequest(w, r, "Invalid sort parameter, expected one of price_low, price_high, rating")
+		return
+	}
+
+	if page := params.Get("page"); page != "" {
+		var err error
+		config.Page, err = strconv.Atoi(page)
+		if err != nil || config.Page < 0 {
+			apierror.BadRequest(w, r, "Invalid page parameter")
+			return
+		}
+	}
+
+	options, err := QueryOptionsFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
+	}
+	config.Options = options
+
+	render, err := RenderModeFromRequest(r)
+	if err != nil {
+		apierror.BadRequest(w, r, err.Error())
+		return
 	}
+	config.Render = render
 
 	scraper := NewShoppingScraper(config)
 
-	products, err := scraper.ShoppingScrape()
+	products, err := scraper.ShoppingScrape(r.Context())
 	if err != nil {
-		http.Error(w, "Error scraping results", http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error scraping results")
 		return
 	}
 
 	jsonData, err := json.MarshalIndent(products, "", "    ")
 	if err != nil {
-		http.Error(w, "Error marshaling to JSON", http.StatusInternalServerError)
+		apierror.Internal(w, r, "Error marshaling to JSON", err)
 		return
 	}
 
+	w.Header().Set(RenderPathHeader, scraper.RenderPath())
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(jsonData)
 }
@@ -0,0 +1,26 @@
+package search
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBuildShoppingURLRegion(t *testing.T) {
+	tests := []struct {
+		location, host string
+		params         url.Values
+	}{
+		{"", "www.google.com", url.Values{"q": {"iphone"}, "tbm": {"shop"}, "tbs": {"mr:1,price:1,ppr_min:500"}}},
+		{"in", "www.google.co.in", url.Values{"q": {"iphone"}, "tbm": {"shop"}, "tbs": {"mr:1,price:1,ppr_min:500"}, "gl": {"in"}, "hl": {"en-IN"}, "lr": {"lang_en"}}},
+	}
+	for _, tc := range tests {
+		s := NewShoppingScraper(ShoppingConfig{Query: "iphone", Location: tc.location, MinPrice: 500})
+		u, err := url.Parse(s.buildShoppingURL(s.config.Query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Host != tc.host || u.Query().Encode() != tc.params.Encode() {
+			t.Errorf("buildShoppingURL with location %q = %s, want host %s and %s", tc.location, u, tc.host, tc.params.Encode())
+		}
+	}
+}
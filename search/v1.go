@@ -0,0 +1,566 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"googlescrapper/api"
+	"googlescrapper/apierror"
+	"googlescrapper/config"
+	"googlescrapper/utils"
+)
+
+// QueryOptionParams are the QueryOptions parameters every /v1 search
+// endpoint accepts
+var QueryOptionParams = []api.Param{
+	{Name: "exact", Type: api.TypeString, Repeated: true, Description: "Phrase that must match verbatim"},
+	{Name: "exclude", Type: api.TypeString, Repeated: true, Description: "Term that must not appear"},
+	{Name: "site", Type: api.TypeString, Description: "Restrict results to a domain"},
+	{Name: "filetype", Type: api.TypeString, Description: "Restrict results to a file type, e.g. pdf"},
+	{Name: "intitle", Type: api.TypeString, Description: "Term that must appear in the title"},
+	{Name: "time_range", Type: api.TypeString, Enum: []string{"hour", "day", "week", "month", "year"}},
+	{Name: "date_from", Type: api.TypeDate, Description: "Start of a custom date range, not combinable with time_range"},
+	{Name: "date_to", Type: api.TypeDate, Description: "End of a custom date range, not combinable with time_range"},
+	{Name: "lang", Type: api.TypeString, Description: "Two letter result language, e.g. de"},
+	{Name: "safe", Type: api.TypeString, Enum: []string{"off", "moderate", "strict"}},
+}
+
+// RenderParam selects how Google pages are fetched
+var RenderParam = api.Param{
+	Name:        "render",
+	Type:        api.TypeString,
+	Default:     string(RenderAuto),
+	Enum:        []string{string(RenderAuto), string(RenderHTTP), string(RenderBrowser)},
+	Description: "Fetch over HTTP with a browser fallback (auto), or only over HTTP or in the browser",
+}
+
+var queryParam = api.Param{Name: "q", Type: api.TypeString, Required: true, Description: "Search query"}
+
+var regionParam = api.Param{Name: "location", Type: api.TypeString, Description: "Region code from /v1/regions"}
+
+// Parameters of the /v1 search endpoints
+var (
+	SearchParams = joinParams([]api.Param{
+		queryParam,
+		withDefault(regionParam, "us"),
+		{Name: "max_results", Type: api.TypeInteger, Default: "10", Min: api.Limit(1), Max: api.Limit(MaxSearchResults)},
+		{Name: "lat", Type: api.TypeNumber, Min: api.Limit(-90), Max: api.Limit(90), Description: "Latitude to target results at, requires lng"},
+		{Name: "lng", Type: api.TypeNumber, Min: api.Limit(-180), Max: api.Limit(180), Description: "Longitude to target results at, requires lat"},
+		{Name: "city", Type: api.TypeString, Description: "City or canonical location name, takes precedence over lat and lng"},
+		{Name: "paa_depth", Type: api.TypeInteger, Default: "0", Min: api.Limit(0), Max: api.Limit(MaxPAADepth), Description: "Rounds of \"People also ask\" expansion in the browser"},
+		{Name: "page_token", Type: api.TypeString, Description: "next_page value of a previous response"},
+		RenderParam,
+	}, QueryOptionParams)
+
+	ImageParams = joinParams([]api.Param{
+		queryParam,
+		regionParam,
+		{Name: "size", Type: api.TypeString, Enum: sortedKeys(imageSizes)},
+		{Name: "color", Type: api.TypeString, Enum: sortedKeys(imageColors)},
+		{Name: "type", Type: api.TypeString, Enum: sortedKeys(imageTypes)},
+		{Name: "license", Type: api.TypeString, Enum: sortedKeys(imageLicenses)},
+		{Name: "page", Type: api.TypeInteger, Default: "0", Min: api.Limit(0), Description: "Zero-based results page"},
+		RenderParam,
+	}, QueryOptionParams)
+
+	ShoppingParams = joinParams([]api.Param{
+		queryParam,
+		regionParam,
+		{Name: "min_price", Type: api.TypeNumber, Min: api.Limit(0)},
+		{Name: "max_price", Type: api.TypeNumber, Min: api.Limit(0)},
+		{Name: "sort", Type: api.TypeString, Enum: sortedKeys(shoppingSorts)},
+		{Name: "page", Type: api.TypeInteger, Default: "0", Min: api.Limit(0), Description: "Zero-based results page"},
+		RenderParam,
+	}, QueryOptionParams)
+
+	NewsParams = joinParams([]api.Param{
+		queryParam,
+		regionParam,
+		{Name: "recency", Type: api.TypeString, Enum: []string{"hour", "day", "week", "month", "year"}, Description: "Not combinable with time_range, date_from or date_to"},
+		RenderParam,
+	}, QueryOptionParams)
+
+	LocalParams = joinParams([]api.Param{
+		queryParam,
+		{Name: "lat", Type: api.TypeNumber, Required: true, Min: api.Limit(-90), Max: api.Limit(90)},
+		{Name: "lng", Type: api.TypeNumber, Required: true, Min: api.Limit(-180), Max: api.Limit(180)},
+		{Name: "max_results", Type: api.TypeInteger, Default: "20", Min: api.Limit(1), Max: api.Limit(100)},
+		regionParam,
+		RenderParam,
+	}, QueryOptionParams)
+
+	FinanceParams = []api.Param{
+		{Name: "symbol", Type: api.TypeString, Required: true, Description: "Ticker symbol, e.g. GOOGL:NASDAQ"},
+		{Name: "window", Type: api.TypeString, Default: "1d", Description: "Chart window, e.g. 1d or 5d"},
+		regionParam,
+		RenderParam,
+	}
+
+	BingParams = joinParams([]api.Param{
+		queryParam,
+		{Name: "mkt", Type: api.TypeString, Description: "Market code, e.g. en-US"},
+		{Name: "cc", Type: api.TypeString, Description: "Two letter country code"},
+		{Name: "setlang", Type: api.TypeString, Description: "UI language, e.g. en or de-DE"},
+		{Name: "freshness", Type: api.TypeString, Enum: []string{"day", "week", "month"}},
+		{Name: "first", Type: api.TypeInteger, Min: api.Limit(1), Description: "1-based index of the first result"},
+		{Name: "count", Type: api.TypeInteger, Min: api.Limit(1), Max: api.Limit(maxBingCount), Description: "Results per page"},
+	}, QueryOptionParams)
+
+	WebParams = joinParams([]api.Param{
+		queryParam,
+		{Name: "engine", Type: api.TypeString, Default: "google", Enum: sortedKeys(Engines)},
+		withDefault(regionParam, "us"),
+		{Name: "max_results", Type: api.TypeInteger, Default: "10", Min: api.Limit(1), Max: api.Limit(MaxSearchResults)},
+		{Name: "page_token", Type: api.TypeString, Description: "next_page value of a previous response, Google only"},
+		RenderParam,
+	}, QueryOptionParams)
+
+	HTMLParams = []api.Param{
+		{Name: "url", Type: api.TypeString, Required: true, Description: "Page to render in the browser"},
+	}
+)
+
+// PageHTML is the rendered HTML of a page
+type PageHTML struct {
+	URL  string `json:"url"`
+	HTML string `json:"html"`
+}
+
+// V1SearchHandler handles GET and POST /v1/search
+func V1SearchHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, SearchParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	config := SearchConfig{
+		Query:      v.Get("q"),
+		Location:   v.Get("location"),
+		MaxResults: v.Int("max_results"),
+		PAADepth:   v.Int("paa_depth"),
+		Render:     RenderMode(v.Get("render")),
+	}
+
+	if err := checkRegion(config.Location); err != nil {
+		api.Invalid(w, r, "location", err)
+		return
+	}
+
+	lat, hasLat := v.Float("lat")
+	lng, hasLng := v.Float("lng")
+	if hasLat != hasLng {
+		api.Invalid(w, r, "lat", errors.New("lat and lng must be given together"))
+		return
+	}
+	if hasLat {
+		config.Latitude = &lat
+		config.Longitude = &lng
+	}
+
+	if city := v.Get("city"); city != "" {
+		loc, ok := utils.LookupLocation(city)
+		if !ok {
+			api.Invalid(w, r, "city", errors.New("unknown city"))
+			return
+		}
+		config.City = loc.Name
+	}
+
+	if token := v.Get("page_token"); token != "" {
+		var err error
+		config.Start, config.Skip, config.RankOffset, err = DecodePageToken(token)
+		if err != nil {
+			api.Invalid(w, r, "page_token", errors.New("invalid page_token"))
+			return
+		}
+	}
+
+	var err error
+	config.Options, err = queryOptionsFromParams(v.Values)
+	if err != nil {
+		api.Invalid(w, r, "", err)
+		return
+	}
+
+	scraper := NewSearchScraper(config)
+	response, err := scraper.Scrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	writeRendered(w, r, response, scraper.RenderPath())
+}
+
+// V1WebHandler handles GET and POST /v1/web, web results of either engine in
+// the normalized schema
+func V1WebHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, WebParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	engine, err := GetEngine(v.Get("engine"))
+	if err != nil {
+		api.Invalid(w, r, "engine", err)
+		return
+	}
+
+	q := Query{
+		Text:       v.Get("q"),
+		Location:   v.Get("location"),
+		MaxResults: v.Int("max_results"),
+		PageToken:  v.Get("page_token"),
+		Render:     RenderMode(v.Get("render")),
+	}
+	if err := checkRegion(q.Location); err != nil {
+		api.Invalid(w, r, "location", err)
+		return
+	}
+	if q.PageToken != "" {
+		if engine.Name() != "google" {
+			api.Invalid(w, r, "page_token", errors.New("page_token is only supported by the google engine"))
+			return
+		}
+		if _, _, _, err := DecodePageToken(q.PageToken); err != nil {
+			api.Invalid(w, r, "page_token", errors.New("invalid page_token"))
+			return
+		}
+	}
+	if q.Options, err = queryOptionsFromParams(v.Values); err != nil {
+		api.Invalid(w, r, "", err)
+		return
+	}
+
+	results, err := engine.Search(r.Context(), q)
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+	if results.RenderPath != "" {
+		writeRendered(w, r, results, results.RenderPath)
+		return
+	}
+	api.Write(w, r, results, api.Meta{})
+}
+
+// V1ImagesHandler handles GET and POST /v1/images
+func V1ImagesHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, ImageParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	location := v.Get("location")
+	if location != "" {
+		if err := checkRegion(location); err != nil {
+			api.Invalid(w, r, "location", err)
+			return
+		}
+	}
+
+	options, err := queryOptionsFromParams(v.Values)
+	if err != nil {
+		api.Invalid(w, r, "", err)
+		return
+	}
+
+	scraper := NewImageScraper(ImageConfig{
+		Query:    v.Get("q"),
+		Location: location,
+		Size:     v.Get("size"),
+		Color:    v.Get("color"),
+		Type:     v.Get("type"),
+		License:  v.Get("license"),
+		Page:     v.Int("page"),
+		Options:  options,
+		Render:   RenderMode(v.Get("render")),
+	})
+	images, err := scraper.ImageScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	writeRendered(w, r, images, scraper.RenderPath())
+}
+
+// V1ShoppingHandler handles GET and POST /v1/shopping
+func V1ShoppingHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, ShoppingParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	minPrice, _ := v.Float("min_price")
+	maxPrice, _ := v.Float("max_price")
+	if maxPrice > 0 && minPrice > maxPrice {
+		api.Invalid(w, r, "min_price", errors.New("min_price must not exceed max_price"))
+		return
+	}
+
+	location := v.Get("location")
+	if location != "" {
+		if err := checkRegion(location); err != nil {
+			api.Invalid(w, r, "location", err)
+			return
+		}
+	}
+
+	options, err := queryOptionsFromParams(v.Values)
+	if err != nil {
+		api.Invalid(w, r, "", err)
+		return
+	}
+
+	scraper := NewShoppingScraper(ShoppingConfig{
+		Query:    v.Get("q"),
+		Location: location,
+		MinPrice: minPrice,
+		MaxPrice: maxPrice,
+		Sort:     v.Get("sort"),
+		Page:     v.Int("page"),
+		Options:  options,
+		Render:   RenderMode(v.Get("render")),
+	})
+	products, err := scraper.ShoppingScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	writeRendered(w, r, products, scraper.RenderPath())
+}
+
+// V1NewsHandler handles GET and POST /v1/news
+func V1NewsHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, NewsParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	location := v.Get("location")
+	if location != "" {
+		if err := checkRegion(location); err != nil {
+			api.Invalid(w, r, "location", err)
+			return
+		}
+	}
+
+	options, err := queryOptionsFromParams(v.Values)
+	if err != nil {
+		api.Invalid(w, r, "", err)
+		return
+	}
+	recency := v.Get("recency")
+	if recency != "" && (options.TimeRange != "" || !options.DateFrom.IsZero() || !options.DateTo.IsZero()) {
+		api.Invalid(w, r, "recency", errors.New("recency cannot be combined with time_range, date_from or date_to"))
+		return
+	}
+
+	scraper := NewNewsScraper(NewsConfig{
+		Query:    v.Get("q"),
+		Location: location,
+		Recency:  recency,
+		Options:  options,
+		Render:   RenderMode(v.Get("render")),
+	})
+	articles, err := scraper.NewsScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	writeRendered(w, r, articles, scraper.RenderPath())
+}
+
+// V1LocalHandler handles GET and POST /v1/local
+func V1LocalHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, LocalParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	location := v.Get("location")
+	if location != "" {
+		if err := checkRegion(location); err != nil {
+			api.Invalid(w, r, "location", err)
+			return
+		}
+	}
+
+	options, err := queryOptionsFromParams(v.Values)
+	if err != nil {
+		api.Invalid(w, r, "", err)
+		return
+	}
+
+	lat, _ := v.Float("lat")
+	lng, _ := v.Float("lng")
+	scraper := NewSearchScraper(SearchConfig{
+		Query:      v.Get("q"),
+		Location:   location,
+		MaxResults: v.Int("max_results"),
+		Latitude:   &lat,
+		Longitude:  &lng,
+		Options:    options,
+		Render:     RenderMode(v.Get("render")),
+	})
+	places, err := scraper.ScrapeLocal(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	writeRendered(w, r, places, scraper.RenderPath())
+}
+
+// V1FinanceHandler handles GET and POST /v1/finance
+func V1FinanceHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, FinanceParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	location := v.Get("location")
+	if location != "" {
+		if err := checkRegion(location); err != nil {
+			api.Invalid(w, r, "location", err)
+			return
+		}
+	}
+
+	scraper := NewFinanceScraper(FinanceConfig{
+		Symbol:   v.Get("symbol"),
+		Window:   v.Get("window"),
+		Location: location,
+		Render:   RenderMode(v.Get("render")),
+	})
+	data, err := scraper.FinanceScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+
+	writeRendered(w, r, data, scraper.RenderPath())
+}
+
+// V1BingHandler handles GET and POST /v1/bing/search
+func V1BingHandler(w http.ResponseWriter, r *http.Request) {
+	scraper, ok := parseV1Bing(w, r)
+	if !ok {
+		return
+	}
+	info, err := scraper.BingScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+	api.Write(w, r, info, api.Meta{})
+}
+
+// V1BingImagesHandler handles GET and POST /v1/bing/images
+func V1BingImagesHandler(w http.ResponseWriter, r *http.Request) {
+	scraper, ok := parseV1Bing(w, r)
+	if !ok {
+		return
+	}
+	images, err := scraper.BingImageScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+	api.Write(w, r, images, api.Meta{})
+}
+
+// V1BingNewsHandler handles GET and POST /v1/bing/news
+func V1BingNewsHandler(w http.ResponseWriter, r *http.Request) {
+	scraper, ok := parseV1Bing(w, r)
+	if !ok {
+		return
+	}
+	articles, err := scraper.BingNewsScrape(r.Context())
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+	api.Write(w, r, articles, api.Meta{})
+}
+
+// V1HTMLHandler handles GET and POST /v1/html
+func V1HTMLHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, HTMLParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	html, err := getHTML(r.Context(), v.Get("url"))
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping results")
+		return
+	}
+	api.Write(w, r, PageHTML{URL: v.Get("url"), HTML: html}, api.Meta{})
+}
+
+// parseV1Bing builds a Bing scraper from the request, writing the response
+// and returning false when the parameters are invalid
+func parseV1Bing(w http.ResponseWriter, r *http.Request) (*BingScraper, bool) {
+	v, apiErr := api.Parse(r, BingParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return nil, false
+	}
+
+	config, err := parseBingConfig(v.Get("q"), v.Values)
+	if err != nil {
+		api.Invalid(w, r, "", err)
+		return nil, false
+	}
+	return NewBingScraper(config), true
+}
+
+// writeRendered writes a Google response with the path that rendered it
+func writeRendered(w http.ResponseWriter, r *http.Request, data interface{}, renderPath string) {
+	w.Header().Set(RenderPathHeader, renderPath)
+	api.Write(w, r, data, api.Meta{RenderPath: renderPath})
+}
+
+// checkRegion fails for region codes missing from config.RegionConfigs
+func checkRegion(code string) error {
+	if _, ok := config.RegionConfigs[code]; !ok {
+		return paramError("location", fmt.Sprintf("unknown region code %q, see /v1/regions", code))
+	}
+	return nil
+}
+
+func withDefault(param api.Param, value string) api.Param {
+	param.Default = value
+	return param
+}
+
+func joinParams(lists ...[]api.Param) []api.Param {
+	var params []api.Param
+	for _, list := range lists {
+		params = append(params, list...)
+	}
+	return params
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
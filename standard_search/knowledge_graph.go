@@ -0,0 +1,125 @@
+package standard_search
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type KnowledgeGraph struct {
+	Title               string               `json:"title"`
+	Type                string               `json:"type,omitempty"`
+	Description         string               `json:"description,omitempty"`
+	DescriptionSource   string               `json:"description_source,omitempty"`
+	DescriptionLink     string               `json:"description_link,omitempty"`
+	Image               string               `json:"image,omitempty"`
+	Facts               []KnowledgeGraphFact `json:"facts,omitempty"`
+	Profiles            []SocialProfile      `json:"profiles,omitempty"`
+	PeopleAlsoSearchFor []RelatedEntity      `json:"people_also_search_for,omitempty"`
+}
+
+type KnowledgeGraphFact struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type SocialProfile struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type RelatedEntity struct {
+	Name  string `json:"name"`
+	Link  string `json:"link,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+// ExtractKnowledgeGraph returns the knowledge panel of a SERP, or nil when it
+// has none. Relative links are resolved against host, the Google host the
+// page was fetched from.
+func ExtractKnowledgeGraph(doc *goquery.Document, host string) *KnowledgeGraph {
+	panel := doc.Find("div.kp-wholepage").First()
+	if panel.Length() == 0 {
+		panel = doc.Find("div.knowledge-panel").First()
+	}
+	if panel.Length() == 0 {
+		return nil
+	}
+
+	kg := &KnowledgeGraph{}
+
+	// Title and entity type
+	if title := panel.Find("[data-attrid='title']").First(); title.Length() > 0 {
+		kg.Title = strings.TrimSpace(title.Text())
+	}
+	if kg.Title == "" {
+		return nil
+	}
+	if subtitle := panel.Find("[data-attrid='subtitle']").First(); subtitle.Length() > 0 {
+		kg.Type = strings.TrimSpace(subtitle.Text())
+	}
+
+	// Description and the page it was taken from (usually Wikipedia)
+	if description := panel.Find("div.kno-rdesc").First(); description.Length() > 0 {
+		kg.Description = strings.TrimSpace(description.Find("span").First().Text())
+		if source := description.Find("a").First(); source.Length() > 0 {
+			kg.DescriptionSource = strings.TrimSpace(source.Text())
+			kg.DescriptionLink = source.AttrOr("href", "")
+		}
+	}
+
+	// Image
+	if image := panel.Find("g-img img, [data-attrid='image'] img").First(); image.Length() > 0 {
+		kg.Image = image.AttrOr("data-src", image.AttrOr("src", ""))
+	}
+
+	// Key facts, e.g. "Born: ..." or "Headquarters: ..."
+	panel.Find("div.wDYxhc[data-attrid^='kc:/'], div.wDYxhc[data-attrid^='ss:/']").Each(func(i int, s *goquery.Selection) {
+		label := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s.Find("span.w8qArf").First().Text()), ":"))
+		value := strings.TrimSpace(s.Find("span.LrzXr").First().Text())
+
+		if label != "" && value != "" {
+			kg.Facts = append(kg.Facts, KnowledgeGraphFact{
+				Label: label,
+				Value: value,
+			})
+		}
+	})
+
+	// Social profiles
+	panel.Find("[data-attrid='kc:/common/topic:social media presence'] a").Each(func(i int, s *goquery.Selection) {
+		href := s.AttrOr("href", "")
+		name := strings.TrimSpace(s.Text())
+		if href != "" && name != "" {
+			kg.Profiles = append(kg.Profiles, SocialProfile{
+				Name: name,
+				URL:  href,
+			})
+		}
+	})
+
+	// People also search for
+	panel.Find("[data-attrid*='also_search_for'] a, [data-attrid*='people_also_search'] a").Each(func(i int, s *goquery.Selection) {
+		name := strings.TrimSpace(s.AttrOr("title", ""))
+		if name == "" {
+			name = strings.TrimSpace(s.AttrOr("aria-label", s.Text()))
+		}
+		if name == "" {
+			return
+		}
+
+		link := s.AttrOr("href", "")
+		if strings.HasPrefix(link, "/") {
+			link = "https://" + host + link
+		}
+
+		image := s.Find("img").First()
+		kg.PeopleAlsoSearchFor = append(kg.PeopleAlsoSearchFor, RelatedEntity{
+			Name:  name,
+			Link:  link,
+			Image: image.AttrOr("data-src", image.AttrOr("src", "")),
+		})
+	})
+
+	return kg
+}
@@ -0,0 +1,70 @@
+package standard_search
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// loadFixture parses an HTML file from testdata
+func loadFixture(t *testing.T, name string) *goquery.Document {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", name, err)
+	}
+	return doc
+}
+
+func TestExtractKnowledgeGraph(t *testing.T) {
+	kg := ExtractKnowledgeGraph(loadFixture(t, "knowledge_graph.html"), "www.google.co.in")
+	if kg == nil {
+		t.Fatal("ExtractKnowledgeGraph = nil")
+	}
+
+	want := &KnowledgeGraph{
+		Title:             "Go",
+		Type:              "Programming language",
+		Description:       "Go is a statically typed, compiled high-level programming language designed at Google.",
+		DescriptionSource: "Wikipedia",
+		DescriptionLink:   "https://en.wikipedia.org/wiki/Go_(programming_language)",
+		Image:             "https://encrypted-tbn0.gstatic.com/images?q=tbn:go",
+		Facts: []KnowledgeGraphFact{
+			{Label: "Designed by", Value: "Robert Griesemer, Rob Pike, Ken Thompson"},
+			{Label: "First appeared", Value: "November 10, 2009"},
+			{Label: "Typing discipline", Value: "Inferred, static, strong, structural"},
+		},
+		Profiles: []SocialProfile{
+			{Name: "X (Twitter)", URL: "https://x.com/golang"},
+			{Name: "GitHub", URL: "https://github.com/golang"},
+		},
+		PeopleAlsoSearchFor: []RelatedEntity{
+			{Name: "Rust", Link: "https://www.google.co.in/search?q=Rust&stick=x", Image: "https://encrypted-tbn0.gstatic.com/images?q=tbn:rust"},
+			{Name: "Python", Link: "https://www.python.org/"},
+		},
+	}
+	if !reflect.DeepEqual(kg, want) {
+		t.Errorf("ExtractKnowledgeGraph =\n%+v\nwant\n%+v", kg, want)
+	}
+}
+
+func TestExtractKnowledgeGraphMissing(t *testing.T) {
+	for name, html := range map[string]string{
+		"no panel": `<div id="search"><div class="g">Result</div></div>`,
+		"no title": `<div class="kp-wholepage"><div data-attrid="subtitle">Programming language</div></div>`,
+	} {
+		doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+		if kg := ExtractKnowledgeGraph(doc, "www.google.com"); kg != nil {
+			t.Errorf("%s: ExtractKnowledgeGraph = %+v, want nil", name, kg)
+		}
+	}
+}
@@ -8,10 +8,13 @@ import (
 )
 
 type SearchResult struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	URL     string `json:"url"`
-	Favicon string `json:"favicon"`
+	Position   int    `json:"position"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	URL        string `json:"url"`
+	Favicon    string `json:"favicon"`
+	DisplayURL string `json:"display_url,omitempty"`
+	SiteName   string `json:"site_name,omitempty"`
 }
 
 func ExtractSearchResults(doc *goquery.Document, maxResults int) []SearchResult {
@@ -30,12 +33,18 @@ func ExtractSearchResults(doc *goquery.Document, maxResults int) []SearchResult
 		url, _ := urlSel.Attr("href")
 		snippet := snippetSel.Text()
 
+		// Breadcrumb-style URL and site name shown above the title
+		displayURL := sel.Find("cite").First().Text()
+		siteName := sel.Find("span.VuuXrf").First().Text()
+
 		if title != "" && url != "" {
 			results = append(results, SearchResult{
-				Title:   strings.TrimSpace(title),
-				Content: strings.TrimSpace(snippet),
-				URL:     url,
-				Favicon: utils.GetFavicon(url),
+				Title:      strings.TrimSpace(title),
+				Content:    strings.TrimSpace(snippet),
+				URL:        url,
+				Favicon:    utils.GetFavicon(url),
+				DisplayURL: strings.TrimSpace(displayURL),
+				SiteName:   strings.TrimSpace(siteName),
 			})
 		}
 	})
@@ -0,0 +1,127 @@
+package standard_search
+
+import (
+	"googlescrapper/utils"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type LocalResult struct {
+	Position  int      `json:"position"`
+	Title     string   `json:"title"`
+	PlaceID   string   `json:"place_id,omitempty"`
+	Rating    float64  `json:"rating,omitempty"`
+	Reviews   int      `json:"reviews,omitempty"`
+	Category  string   `json:"category,omitempty"`
+	Address   string   `json:"address,omitempty"`
+	Phone     string   `json:"phone,omitempty"`
+	Hours     string   `json:"hours,omitempty"`
+	Website   string   `json:"website,omitempty"`
+	MapLink   string   `json:"map_link,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+var (
+	phoneRe      = regexp.MustCompile(`\+?\(?\d[\d\s\-().]{6,}\d`)
+	hoursRe      = regexp.MustCompile(`(?i)\b(open|closed|closes|opens|24 hours)\b`)
+	mapCoordsRe  = regexp.MustCompile(`@(-?\d+\.\d+),(-?\d+\.\d+)`)
+	mapPlaceRe   = regexp.MustCompile(`!3d(-?\d+\.\d+)!4d(-?\d+\.\d+)`)
+	priceLevelRe = regexp.MustCompile(`^[$€£₹¥]{1,4}$`)
+)
+
+func ExtractLocalResults(doc *goquery.Document) []LocalResult {
+	var results []LocalResult
+
+	doc.Find("div.VkpGBb").Each(func(i int, s *goquery.Selection) {
+		title := strings.TrimSpace(s.Find("div.dbg0pd, span.OSrXXb").First().Text())
+		if title == "" {
+			return
+		}
+
+		result := LocalResult{
+			Position: len(results) + 1,
+			Title:    title,
+			Rating:   utils.ParseRating(s.Find("span.yi40Hd").First().Text()),
+			Reviews:  utils.ParseCount(s.Find("span.RDApEe").First().Text()),
+		}
+
+		if cid, ok := s.Find("[data-cid]").First().Attr("data-cid"); ok {
+			result.PlaceID = cid
+			result.MapLink = "https://maps.google.com/?cid=" + cid
+		}
+
+		// Each line of the details block holds "·" separated fields:
+		// rating and category first, then address, then hours and phone
+		s.Find("div.rllt__details > div").Each(func(line int, d *goquery.Selection) {
+			for _, part := range strings.Split(d.Text(), "·") {
+				part = strings.TrimSpace(part)
+				switch {
+				case part == "" || priceLevelRe.MatchString(part):
+				case line == 0:
+					// Skip the rating and review count, keep the category
+					if result.Category == "" && !strings.ContainsAny(part, "()") && utils.ParseRating(part) == 0 {
+						result.Category = part
+					}
+				case hoursRe.MatchString(part):
+					result.Hours = part
+				case phoneRe.MatchString(part) && result.Phone == "":
+					result.Phone = phoneRe.FindString(part)
+				case result.Address == "":
+					result.Address = part
+				}
+			}
+		})
+
+		// Website and directions buttons
+		s.Find("a").Each(func(j int, a *goquery.Selection) {
+			href := a.AttrOr("href", "")
+			switch {
+			case a.HasClass("yYlJEf") || strings.EqualFold(strings.TrimSpace(a.Text()), "website"):
+				result.Website = href
+			case strings.Contains(href, "/maps/"):
+				if result.MapLink == "" {
+					result.MapLink = href
+				}
+				if result.Latitude == nil {
+					result.Latitude, result.Longitude = coordinatesFromMapLink(href)
+				}
+			}
+		})
+
+		// Coordinates may also be exposed as data attributes
+		if result.Latitude == nil {
+			if el := s.Find("[data-lat][data-lng]").First(); el.Length() > 0 {
+				result.Latitude = parseCoordinate(el.AttrOr("data-lat", ""))
+				result.Longitude = parseCoordinate(el.AttrOr("data-lng", ""))
+			}
+		}
+
+		results = append(results, result)
+	})
+
+	return results
+}
+
+// coordinatesFromMapLink reads the place coordinates from a Google Maps URL
+func coordinatesFromMapLink(href string) (*float64, *float64) {
+	match := mapPlaceRe.FindStringSubmatch(href)
+	if match == nil {
+		match = mapCoordsRe.FindStringSubmatch(href)
+	}
+	if match == nil {
+		return nil, nil
+	}
+	return parseCoordinate(match[1]), parseCoordinate(match[2])
+}
+
+func parseCoordinate(text string) *float64 {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
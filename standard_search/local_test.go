@@ -0,0 +1,50 @@
+package standard_search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractLocalResults(t *testing.T) {
+	results := ExtractLocalResults(loadFixture(t, "local_pack.html"))
+
+	coordinate := func(v float64) *float64 { return &v }
+	want := []LocalResult{
+		{
+			Position:  1,
+			Title:     "Blue Tokai Coffee Roasters",
+			PlaceID:   "13345567890123456789",
+			Rating:    4.5,
+			Reviews:   1200,
+			Category:  "Cafe",
+			Address:   "Koregaon Park, Pune",
+			Phone:     "098765 43210",
+			Hours:     "Open ⋅ Closes 11 PM",
+			Website:   "https://bluetokaicoffee.com/",
+			MapLink:   "https://maps.google.com/?cid=13345567890123456789",
+			Latitude:  coordinate(18.5362),
+			Longitude: coordinate(73.894),
+		},
+		{
+			Position:  2,
+			Title:     "Café Goodluck",
+			Rating:    4.2,
+			Reviews:   87,
+			Category:  "Irani cafe",
+			Address:   "12 FC Road, Deccan Gymkhana",
+			Phone:     "+91 20 2567 3456",
+			Hours:     "Closed ⋅ Opens 7 AM",
+			Latitude:  coordinate(18.5204),
+			Longitude: coordinate(73.8567),
+		},
+	}
+
+	if len(results) != len(want) {
+		t.Fatalf("ExtractLocalResults returned %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(results[i], want[i]) {
+			t.Errorf("result %d =\n%+v\nwant\n%+v", i, results[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package standard_search
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type PeopleAlsoAsk struct {
+	Question  string `json:"question"`
+	Answer    string `json:"answer,omitempty"`
+	Source    string `json:"source,omitempty"`
+	SourceURL string `json:"source_url,omitempty"`
+}
+
+// ExtractPeopleAlsoAsk returns the "People also ask" entries of a SERP.
+// Relative source links are resolved against host, the Google host the page
+// was fetched from.
+func ExtractPeopleAlsoAsk(doc *goquery.Document, host string) []PeopleAlsoAsk {
+	var questions []PeopleAlsoAsk
+	seen := make(map[string]bool)
+
+	doc.Find("div.related-question-pair").Each(func(i int, s *goquery.Selection) {
+		// The question is kept in data-q, fall back to the visible label
+		question := strings.TrimSpace(s.AttrOr("data-q", ""))
+		if question == "" {
+			question = strings.TrimSpace(s.Find("div.JlqpRe span").First().Text())
+		}
+		if question == "" || seen[question] {
+			return
+		}
+		seen[question] = true
+
+		paa := PeopleAlsoAsk{
+			Question: question,
+		}
+
+		// Answer text, present once the entry has been expanded
+		if answer := s.Find("span.hgKElc"); answer.Length() > 0 {
+			paa.Answer = strings.TrimSpace(answer.First().Text())
+		} else if answer := s.Find("div.wDYxhc"); answer.Length() > 0 {
+			paa.Answer = strings.TrimSpace(answer.First().Text())
+		}
+
+		// Source page the answer was taken from
+		if source := s.Find("a:has(h3)").First(); source.Length() > 0 {
+			paa.Source = strings.TrimSpace(source.Find("h3").Text())
+			paa.SourceURL = source.AttrOr("href", "")
+			if strings.HasPrefix(paa.SourceURL, "/") {
+				paa.SourceURL = "https://" + host + paa.SourceURL
+			}
+		}
+
+		questions = append(questions, paa)
+	})
+
+	return questions
+}
@@ -0,0 +1,34 @@
+package standard_search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractPeopleAlsoAsk(t *testing.T) {
+	questions := ExtractPeopleAlsoAsk(loadFixture(t, "people_also_ask.html"), "www.google.co.uk")
+
+	want := []PeopleAlsoAsk{
+		{
+			Question:  "Is Go faster than Python?",
+			Answer:    "Go is generally faster than Python because it is compiled to machine code.",
+			Source:    "Frequently Asked Questions (FAQ) - The Go Programming Language",
+			SourceURL: "https://go.dev/doc/faq",
+		},
+		{
+			// Without data-q the visible label is used, and relative source
+			// links are resolved against the host
+			Question:  "What is Go used for?",
+			Answer:    "Go is used for cloud services, command line tools and web servers.",
+			Source:    "What Is Go Used For?",
+			SourceURL: "https://www.google.co.uk/url?q=https://example.com/go-uses&sa=U",
+		},
+		{
+			// Unexpanded entries only have their question
+			Question: "Who created Go?",
+		},
+	}
+	if !reflect.DeepEqual(questions, want) {
+		t.Errorf("ExtractPeopleAlsoAsk =\n%+v\nwant\n%+v", questions, want)
+	}
+}
@@ -0,0 +1,47 @@
+package standard_search
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type RelatedSearch struct {
+	Query string `json:"query"`
+	Link  string `json:"link,omitempty"`
+}
+
+// ExtractRelatedSearches returns the related searches of a SERP, with links
+// on host, the Google host the page was fetched from
+func ExtractRelatedSearches(doc *goquery.Document, host string) []RelatedSearch {
+	var related []RelatedSearch
+	seen := make(map[string]bool)
+
+	doc.Find("div#bres a, a.k8XOCe").Each(func(i int, s *goquery.Selection) {
+		href := s.AttrOr("href", "")
+		if !strings.HasPrefix(href, "/search?") {
+			return
+		}
+
+		// Prefer the query from the link itself over the highlighted label
+		query := ""
+		if parsed, err := url.Parse(href); err == nil {
+			query = parsed.Query().Get("q")
+		}
+		if query == "" {
+			query = strings.TrimSpace(s.Text())
+		}
+		if query == "" || seen[query] {
+			return
+		}
+		seen[query] = true
+
+		related = append(related, RelatedSearch{
+			Query: query,
+			Link:  "https://" + host + href,
+		})
+	})
+
+	return related
+}
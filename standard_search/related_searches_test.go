@@ -0,0 +1,20 @@
+package standard_search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractRelatedSearches(t *testing.T) {
+	related := ExtractRelatedSearches(loadFixture(t, "related_searches.html"), "www.google.de")
+
+	want := []RelatedSearch{
+		{Query: "golang tutorial", Link: "https://www.google.de/search?q=golang+tutorial&sa=X&ved=2ah"},
+		// Links without a q parameter fall back to the label
+		{Query: "golang vs rust", Link: "https://www.google.de/search?sa=X&ved=2ah"},
+		{Query: "golang generics", Link: "https://www.google.de/search?q=golang+generics&sa=X"},
+	}
+	if !reflect.DeepEqual(related, want) {
+		t.Errorf("ExtractRelatedSearches =\n%+v\nwant\n%+v", related, want)
+	}
+}
@@ -1,11 +1,12 @@
 package stock
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"googlescrapper/apierror"
 	"googlescrapper/cache"
-	"io/ioutil"
+	"googlescrapper/fetch"
 	"net/http"
 	"time"
 )
@@ -27,10 +28,10 @@ type StockRequest struct {
 	TickerId string `json:"tickerId"`
 }
 
-func FetchStockChart(days, tickerId, tickerType string) ([]StockChartResponse, error) {
+func FetchStockChart(ctx context.Context, days, tickerId, tickerType string) ([]StockChartResponse, error) {
 	cacheKey := fmt.Sprintf("stock-chart:%s:%s:%s", days, tickerId, tickerType)
 
-	return cache.Memoize(cacheKey, 5*time.Minute, func() ([]StockChartResponse, error) {
+	return cache.Memoize(ctx, cacheKey, 5*time.Minute, func(ctx context.Context) ([]StockChartResponse, error) {
 
 		url := "https://api-mintgenie.livemint.com/api-gateway/fundamental/api/v2/charts"
 
@@ -47,25 +48,15 @@ func FetchStockChart(days, tickerId, tickerType string) ([]StockChartResponse, e
 			return nil, err
 		}
 
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
-		if err != nil {
-			return nil, err
-		}
-
-		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:135.0) Gecko/20100101 Firefox/135.0")
-		req.Header.Set("Accept", "*/*")
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("mintgenie-client", "LM-WEB")
-		req.Header.Set("Cache-Control", "no-cache")
-
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
+		body, err := mintgenieDo(ctx, fetch.Request{
+			Method: http.MethodPost,
+			URL:    url,
+			Body:   requestBody,
+			Header: http.Header{
+				"Content-Type":     {"application/json"},
+				"Mintgenie-Client": {"LM-WEB"},
+			},
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -83,22 +74,22 @@ func GetCharts(w http.ResponseWriter, r *http.Request) {
 	var reqBody StockRequest
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&reqBody); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.BadRequest(w, r, "Invalid request body")
 		return
 	}
 
 	TickerId := reqBody.TickerId
 
-	liveMindTickerData, err := FetchStockTickerData(TickerId)
+	liveMindTickerData, err := FetchStockTickerData(r.Context(), TickerId)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error looking up ticker")
 		return
 
 	}
 
 	if len(liveMindTickerData) == 0 {
-		http.Error(w, "No data found", http.StatusNotFound)
+		apierror.NotFound(w, r, "No data found")
 		return
 	}
 
@@ -106,14 +97,14 @@ func GetCharts(w http.ResponseWriter, r *http.Request) {
 
 	livemintTickerJSON, err := json.Marshal(livemintTicker)
 	if err != nil {
-		http.Error(w, "Error converting ticker data to JSON", http.StatusInternalServerError)
+		apierror.Internal(w, r, "Error converting ticker data to JSON", err)
 		return
 	}
 	println(string(livemintTickerJSON))
 
-	stockData, err := FetchStockChart(reqBody.Days, livemintTicker.ID, "bse")
+	stockData, err := FetchStockChart(r.Context(), reqBody.Days, livemintTicker.ID, "bse")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error fetching stock chart")
 		return
 	}
 
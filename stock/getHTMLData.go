@@ -1,10 +1,12 @@
 package stock
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"googlescrapper/apierror"
 	"googlescrapper/cache"
-	"io/ioutil"
 	"net/http"
 	"strings"
 	"time"
@@ -127,20 +129,19 @@ type FAQ struct {
 	Answer   string `json:"answer"`
 }
 
-func ScrapeStockData(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	stockIdentifier := vars["stockIdentifier"]
-
+// FetchStockData scrapes the LiveMint market stats page of the first ticker
+// matching stockIdentifier
+func FetchStockData(ctx context.Context, stockIdentifier string) (StockData, error) {
 	cacheKey := fmt.Sprintf("stock-data:%s", stockIdentifier)
 
-	result, err := cache.Memoize(cacheKey, 12*time.Hour, func() (interface{}, error) {
-		liveMindTickerData, err := FetchStockTickerData(stockIdentifier)
+	return cache.Memoize(ctx, cacheKey, 12*time.Hour, func(ctx context.Context) (StockData, error) {
+		liveMindTickerData, err := FetchStockTickerData(ctx, stockIdentifier)
 		if err != nil {
-			return nil, err
+			return StockData{}, err
 		}
 
 		if len(liveMindTickerData) == 0 {
-			return nil, fmt.Errorf("no data found for stock: %s", stockIdentifier)
+			return StockData{}, apierror.New(http.StatusNotFound, apierror.CodeNotFound, "No data found for stock: "+stockIdentifier)
 		}
 
 		livemintTicker := liveMindTickerData[0]
@@ -150,19 +151,14 @@ func ScrapeStockData(w http.ResponseWriter, r *http.Request) {
 		)
 
 		url := fmt.Sprintf("https://www.livemint.com/market/market-stats/%s", strings.ToLower(identifier))
-		res, err := http.Get(url)
+		body, err := livemintGet(ctx, url)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch webpage: %v", err)
+			return StockData{}, fmt.Errorf("failed to fetch webpage: %w", err)
 		}
-		defer res.Body.Close()
 
-		if res.StatusCode != 200 {
-			return nil, fmt.Errorf("failed to fetch webpage, status code: %d", res.StatusCode)
-		}
-
-		doc, err := goquery.NewDocumentFromReader(res.Body)
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse HTML: %v", err)
+			return StockData{}, fmt.Errorf("failed to parse HTML: %v", err)
 		}
 
 		// Price Info
@@ -255,15 +251,6 @@ func ScrapeStockData(w http.ResponseWriter, r *http.Request) {
 				})
 			}
 		})
-		// Print HTML of .storyDetails_compInfo__wSZUv
-		html, _ := doc.Html()
-
-		// write to file
-		err = ioutil.WriteFile("response.html", []byte(html), 0644)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to get HTML: %v", err)
-		}
 
 		company := CompanyDetails{
 			Industry:    cleanText(doc.Find(".storyDetails_compInfo__wSZUv li:contains('Industry')").Contents().Last().Text()),
@@ -359,9 +346,14 @@ func ScrapeStockData(w http.ResponseWriter, r *http.Request) {
 
 		return response, nil
 	})
+}
+
+func ScrapeStockData(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
 
+	result, err := FetchStockData(r.Context(), vars["stockIdentifier"])
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error scraping stock data")
 		return
 	}
 
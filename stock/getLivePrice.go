@@ -1,11 +1,13 @@
 package stock
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"googlescrapper/apierror"
 	"googlescrapper/cache"
-	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -47,30 +49,14 @@ type LivePriceV2Response struct {
 }
 
 // FetchLivePriceV2 fetches live stock price from the API
-func FetchLivePriceV2(tickerId, exchangeCode string) (LivePriceV2Response, error) {
+func FetchLivePriceV2(ctx context.Context, tickerId, exchangeCode string) (LivePriceV2Response, error) {
 	cacheKey := fmt.Sprintf("live-price-v2:%s:%s", tickerId, exchangeCode)
 
-	return cache.Memoize(cacheKey, 5*time.Minute, func() (LivePriceV2Response, error) {
+	return cache.Memoize(ctx, cacheKey, 5*time.Minute, func(ctx context.Context) (LivePriceV2Response, error) {
 
-		url := fmt.Sprintf("https://api-mintgenie.livemint.com/api-gateway/fundamental/markets-data/live-price/v2?exchangeCode=%s&tickerId=%s", exchangeCode, tickerId)
+		apiURL := fmt.Sprintf("https://api-mintgenie.livemint.com/api-gateway/fundamental/markets-data/live-price/v2?exchangeCode=%s&tickerId=%s", url.QueryEscape(exchangeCode), url.QueryEscape(tickerId))
 
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return LivePriceV2Response{}, err
-		}
-
-		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:135.0) Gecko/20100101 Firefox/135.0")
-		req.Header.Set("Accept", "*/*")
-		req.Header.Set("Cache-Control", "no-cache")
-
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return LivePriceV2Response{}, err
-		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
+		body, err := mintgenieGet(ctx, apiURL)
 		if err != nil {
 			return LivePriceV2Response{}, err
 		}
@@ -89,24 +75,24 @@ func GetLivePriceV2Handler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	tickerId := params["tickerId"]
 
-	liveMindTickerData, err := FetchStockTickerData(tickerId)
+	liveMindTickerData, err := FetchStockTickerData(r.Context(), tickerId)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error looking up ticker")
 		return
 
 	}
 
 	if len(liveMindTickerData) == 0 {
-		http.Error(w, "No data found", http.StatusNotFound)
+		apierror.NotFound(w, r, "No data found")
 		return
 	}
 
 	livemintTicker := liveMindTickerData[0]
 
-	livePrice, err := FetchLivePriceV2(livemintTicker.ID, "bse")
+	livePrice, err := FetchLivePriceV2(r.Context(), livemintTicker.ID, "bse")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error fetching live price")
 		return
 	}
 
@@ -1,11 +1,13 @@
 package stock
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"googlescrapper/apierror"
 	"googlescrapper/cache"
-	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -18,29 +20,13 @@ type LivePriceResponse struct {
 	Description     string `json:"description"`
 }
 
-func FetchLivePrice(tickerId, exchangeCode string) (LivePriceResponse, error) {
+func FetchLivePrice(ctx context.Context, tickerId, exchangeCode string) (LivePriceResponse, error) {
 	cacheKey := fmt.Sprintf("live-price:%s:%s", tickerId, exchangeCode)
 
-	return cache.Memoize(cacheKey, 5*time.Minute, func() (LivePriceResponse, error) {
-		url := fmt.Sprintf("https://api-mintgenie.livemint.com/api-gateway/fundamental/markets-data/live-price/v4?tickerId=%s&exchangeCode=%s", tickerId, exchangeCode)
+	return cache.Memoize(ctx, cacheKey, 5*time.Minute, func(ctx context.Context) (LivePriceResponse, error) {
+		apiURL := fmt.Sprintf("https://api-mintgenie.livemint.com/api-gateway/fundamental/markets-data/live-price/v4?tickerId=%s&exchangeCode=%s", url.QueryEscape(tickerId), url.QueryEscape(exchangeCode))
 
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return LivePriceResponse{}, err
-		}
-
-		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:135.0) Gecko/20100101 Firefox/135.0")
-		req.Header.Set("Accept", "*/*")
-		req.Header.Set("Cache-Control", "no-cache")
-
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return LivePriceResponse{}, err
-		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
+		body, err := mintgenieGet(ctx, apiURL)
 		if err != nil {
 			return LivePriceResponse{}, err
 		}
@@ -58,24 +44,24 @@ func GetLivePricePred(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	tickerId := params["tickerId"]
 
-	liveMindTickerData, err := FetchStockTickerData(tickerId)
+	liveMindTickerData, err := FetchStockTickerData(r.Context(), tickerId)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error looking up ticker")
 		return
 
 	}
 
 	if len(liveMindTickerData) == 0 {
-		http.Error(w, "No data found", http.StatusNotFound)
+		apierror.NotFound(w, r, "No data found")
 		return
 	}
 
 	livemintTicker := liveMindTickerData[0]
 
-	livePrice, err := FetchLivePrice(livemintTicker.ID, "bse")
+	livePrice, err := FetchLivePrice(r.Context(), livemintTicker.ID, "bse")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error fetching live price")
 		return
 	}
 
@@ -1,18 +1,16 @@
 package stock
 
 import (
-	"compress/flate"
-	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"googlescrapper/apierror"
 	"googlescrapper/cache"
-	"io"
 	"net/http"
+	"net/url"
 	"time"
 
-	"github.com/andybalholm/brotli"
 	"github.com/gorilla/mux"
-	"github.com/klauspost/compress/zstd"
 )
 
 // ShareholdingTrend represents the shareholding trend response
@@ -32,61 +30,14 @@ type ShareholdingPieChart struct {
 }
 
 // FetchShareholdings fetches shareholding details from the API
-func FetchShareholdings(tickerId, shareType string) ([]ShareholdingTrend, error) {
+func FetchShareholdings(ctx context.Context, tickerId, shareType string) ([]ShareholdingTrend, error) {
 	cacheKey := fmt.Sprintf("shareholdings:%s:%s", tickerId, shareType)
 
-	return cache.Memoize(cacheKey, 12*time.Hour, func() ([]ShareholdingTrend, error) {
+	return cache.Memoize(ctx, cacheKey, 12*time.Hour, func(ctx context.Context) ([]ShareholdingTrend, error) {
 
-		url := fmt.Sprintf("https://api-mintgenie.livemint.com/api-gateway/fundamental/v2/getShareHoldingsDetailByTickerIdAndType?tickerId=%s&type=%s", tickerId, shareType)
+		apiURL := fmt.Sprintf("https://api-mintgenie.livemint.com/api-gateway/fundamental/v2/getShareHoldingsDetailByTickerIdAndType?tickerId=%s&type=%s", url.QueryEscape(tickerId), url.QueryEscape(shareType))
 
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:135.0) Gecko/20100101 Firefox/135.0")
-		req.Header.Set("Accept", "*/*")
-		req.Header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
-
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
-
-		var body []byte
-		switch resp.Header.Get("Content-Encoding") {
-		case "gzip":
-			gzipReader, err := gzip.NewReader(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			defer gzipReader.Close()
-			body, err = io.ReadAll(gzipReader)
-			if err != nil {
-				return nil, err
-			}
-		case "deflate":
-			flateReader := flate.NewReader(resp.Body)
-			defer flateReader.Close()
-			body, err = io.ReadAll(flateReader)
-		case "br":
-			brReader := brotli.NewReader(resp.Body)
-			body, err = io.ReadAll(brReader)
-		case "zstd":
-			zstdReader, err := zstd.NewReader(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			defer zstdReader.Close()
-			body, err = io.ReadAll(zstdReader)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			body, err = io.ReadAll(resp.Body)
-		}
+		body, err := mintgenieGet(ctx, apiURL)
 		if err != nil {
 			return nil, err
 		}
@@ -107,24 +58,24 @@ func GetShareholdingsHandler(w http.ResponseWriter, r *http.Request) {
 	tickerId := params["tickerId"]
 	shareType := params["type"]
 
-	liveMindTickerData, err := FetchStockTickerData(tickerId)
+	liveMindTickerData, err := FetchStockTickerData(r.Context(), tickerId)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error looking up ticker")
 		return
 
 	}
 
 	if len(liveMindTickerData) == 0 {
-		http.Error(w, "No data found", http.StatusNotFound)
+		apierror.NotFound(w, r, "No data found")
 		return
 	}
 
 	livemintTicker := liveMindTickerData[0]
 
-	shareholdingsData, err := FetchShareholdings(livemintTicker.ID, shareType)
+	shareholdingsData, err := FetchShareholdings(r.Context(), livemintTicker.ID, shareType)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error fetching shareholdings")
 		return
 	}
 
@@ -1,9 +1,13 @@
 package stock
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"googlescrapper/apierror"
+	"googlescrapper/fetch"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -171,27 +175,19 @@ type StockForecastResponse struct {
 }
 
 // FetchStockForecast fetches stock forecast data from the Mint Genie API
-func FetchStockForecast(tickerId, exchangeCode string) (StockForecastResponse, error) {
-	url := fmt.Sprintf("https://api-mintgenie.livemint.com/api-gateway/fundamental/v2/getStockFore/%s/%s", tickerId, exchangeCode)
+func FetchStockForecast(ctx context.Context, tickerId, exchangeCode string) (StockForecastResponse, error) {
+	apiURL := fmt.Sprintf("https://api-mintgenie.livemint.com/api-gateway/fundamental/v2/getStockFore/%s/%s", url.PathEscape(tickerId), url.PathEscape(exchangeCode))
 
-	req, err := http.NewRequest("GET", url, nil)
+	body, err := mintgenieDo(ctx, fetch.Request{
+		URL:     apiURL,
+		Timeout: 10 * time.Second,
+	})
 	if err != nil {
 		return StockForecastResponse{}, err
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:135.0) Gecko/20100101 Firefox/135.0")
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Cache-Control", "no-cache")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return StockForecastResponse{}, err
-	}
-	defer resp.Body.Close()
-
 	var forecast StockForecastResponse
-	if err := json.NewDecoder(resp.Body).Decode(&forecast); err != nil {
+	if err := json.Unmarshal(body, &forecast); err != nil {
 		return StockForecastResponse{}, err
 	}
 	// print the complete body
@@ -204,24 +200,24 @@ func GetStockForecastHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	tickerId := params["tickerId"]
 
-	liveMindTickerData, err := FetchStockTickerData(tickerId)
+	liveMindTickerData, err := FetchStockTickerData(r.Context(), tickerId)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error looking up ticker")
 		return
 
 	}
 
 	if len(liveMindTickerData) == 0 {
-		http.Error(w, "No data found", http.StatusNotFound)
+		apierror.NotFound(w, r, "No data found")
 		return
 	}
 
 	livemintTicker := liveMindTickerData[0]
 
-	forecast, err := FetchStockForecast(livemintTicker.ID, "bse")
+	forecast, err := FetchStockForecast(r.Context(), livemintTicker.ID, "bse")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Handle(w, r, err, "Error fetching stock forecast")
 		return
 	}
 
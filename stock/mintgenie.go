@@ -0,0 +1,63 @@
+package stock
+
+import (
+	"context"
+
+	"googlescrapper/fetch"
+	"googlescrapper/profile"
+	"googlescrapper/proxy"
+	"googlescrapper/throttle"
+)
+
+// mintgenieCountry is the country proxies for MintGenie and LiveMint are
+// picked from; both serve the Indian market
+const mintgenieCountry = "in"
+
+// mintgenieDo sends a request to the MintGenie API through a proxy with a
+// rotated browser header profile, within MintGenie's rate limit, and returns
+// the decoded body
+func mintgenieDo(ctx context.Context, req fetch.Request) ([]byte, error) {
+	header := profile.Random().FetchHeader("")
+	header.Set("Cache-Control", "no-cache")
+	for key, values := range req.Header {
+		header[key] = values
+	}
+	req.Header = header
+	req.Upstream = fetch.UpstreamMintGenie
+
+	done, err := throttle.Acquire(ctx, throttle.EngineMintGenie, "api")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := proxy.Do(ctx, mintgenieCountry, "", req)
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// mintgenieGet fetches a MintGenie API URL
+func mintgenieGet(ctx context.Context, apiURL string) ([]byte, error) {
+	return mintgenieDo(ctx, fetch.Request{URL: apiURL})
+}
+
+// livemintGet fetches a LiveMint page through a proxy as a browser
+// navigation, within LiveMint's rate limit
+func livemintGet(ctx context.Context, pageURL string) ([]byte, error) {
+	done, err := throttle.Acquire(ctx, throttle.EngineLiveMint, "page")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := proxy.Do(ctx, mintgenieCountry, "", fetch.Request{
+		URL:    pageURL,
+		Header: profile.Random().NavigationHeader("en-IN"),
+	})
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,160 @@
+package stock
+
+import (
+	"context"
+	"net/http"
+
+	"googlescrapper/api"
+	"googlescrapper/apierror"
+)
+
+var tickerParam = api.Param{Name: "ticker", Type: api.TypeString, Required: true, Description: "Company name or ticker to look up, e.g. RELIANCE"}
+
+// Parameters of the /v1 stock endpoints
+var (
+	TickerParams = []api.Param{tickerParam}
+
+	ChartParams = []api.Param{
+		tickerParam,
+		{Name: "days", Type: api.TypeString, Required: true, Description: "Chart period as accepted by MintGenie"},
+	}
+
+	ShareholdingParams = []api.Param{
+		tickerParam,
+		{Name: "type", Type: api.TypeString, Required: true, Description: "Shareholding breakdown as accepted by MintGenie"},
+	}
+)
+
+// V1ChartHandler handles GET and POST /v1/stock/chart
+func V1ChartHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, ChartParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	ticker, err := lookupTicker(r.Context(), v.Get("ticker"))
+	if err != nil {
+		apierror.Handle(w, r, err, "Error looking up ticker")
+		return
+	}
+
+	chart, err := FetchStockChart(r.Context(), v.Get("days"), ticker.ID, "bse")
+	if err != nil {
+		apierror.Handle(w, r, err, "Error fetching stock chart")
+		return
+	}
+	api.Write(w, r, chart, api.Meta{})
+}
+
+// V1PriceHandler handles GET and POST /v1/stock/price
+func V1PriceHandler(w http.ResponseWriter, r *http.Request) {
+	ticker, ok := parseTicker(w, r)
+	if !ok {
+		return
+	}
+
+	price, err := FetchLivePriceV2(r.Context(), ticker.ID, "bse")
+	if err != nil {
+		apierror.Handle(w, r, err, "Error fetching live price")
+		return
+	}
+	api.Write(w, r, price, api.Meta{})
+}
+
+// V1LiveHandler handles GET and POST /v1/stock/live
+func V1LiveHandler(w http.ResponseWriter, r *http.Request) {
+	ticker, ok := parseTicker(w, r)
+	if !ok {
+		return
+	}
+
+	price, err := FetchLivePrice(r.Context(), ticker.ID, "bse")
+	if err != nil {
+		apierror.Handle(w, r, err, "Error fetching live price")
+		return
+	}
+	api.Write(w, r, price, api.Meta{})
+}
+
+// V1ForecastHandler handles GET and POST /v1/stock/forecast
+func V1ForecastHandler(w http.ResponseWriter, r *http.Request) {
+	ticker, ok := parseTicker(w, r)
+	if !ok {
+		return
+	}
+
+	forecast, err := FetchStockForecast(r.Context(), ticker.ID, "bse")
+	if err != nil {
+		apierror.Handle(w, r, err, "Error fetching stock forecast")
+		return
+	}
+	api.Write(w, r, forecast, api.Meta{})
+}
+
+// V1ShareholdingsHandler handles GET and POST /v1/stock/shareholdings
+func V1ShareholdingsHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, ShareholdingParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	ticker, err := lookupTicker(r.Context(), v.Get("ticker"))
+	if err != nil {
+		apierror.Handle(w, r, err, "Error looking up ticker")
+		return
+	}
+
+	shareholdings, err := FetchShareholdings(r.Context(), ticker.ID, v.Get("type"))
+	if err != nil {
+		apierror.Handle(w, r, err, "Error fetching shareholdings")
+		return
+	}
+	api.Write(w, r, shareholdings, api.Meta{})
+}
+
+// V1OverviewHandler handles GET and POST /v1/stock/overview
+func V1OverviewHandler(w http.ResponseWriter, r *http.Request) {
+	v, apiErr := api.Parse(r, TickerParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	data, err := FetchStockData(r.Context(), v.Get("ticker"))
+	if err != nil {
+		apierror.Handle(w, r, err, "Error scraping stock data")
+		return
+	}
+	api.Write(w, r, data, api.Meta{})
+}
+
+// parseTicker reads the ticker parameter and looks it up, writing the
+// response and returning false when either fails
+func parseTicker(w http.ResponseWriter, r *http.Request) (StockInfo, bool) {
+	v, apiErr := api.Parse(r, TickerParams)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return StockInfo{}, false
+	}
+
+	ticker, err := lookupTicker(r.Context(), v.Get("ticker"))
+	if err != nil {
+		apierror.Handle(w, r, err, "Error looking up ticker")
+		return StockInfo{}, false
+	}
+	return ticker, true
+}
+
+// lookupTicker returns the first MintGenie ticker matching query
+func lookupTicker(ctx context.Context, query string) (StockInfo, error) {
+	tickers, err := FetchStockTickerData(ctx, query)
+	if err != nil {
+		return StockInfo{}, err
+	}
+	if len(tickers) == 0 {
+		return StockInfo{}, apierror.New(http.StatusNotFound, apierror.CodeNotFound, "No ticker found for: "+query)
+	}
+	return tickers[0], nil
+}
@@ -0,0 +1,122 @@
+package throttle
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Limit configures the token bucket of an engine ("google") or a vertical
+// ("google:shopping"), and for engines also the circuit breaker. Zero fields
+// of a vertical are inherited from its engine.
+type Limit struct {
+	RatePerSecond float64 `json:"rate_per_second"`
+	Burst         int     `json:"burst"`
+	MaxWaitMs     int     `json:"max_wait_ms"` // How long a request may wait for a token before failing
+
+	// Circuit breaker, engines only
+	FailureThreshold     int `json:"failure_threshold,omitempty"`      // Failures within the window that open the circuit
+	FailureWindowSeconds int `json:"failure_window_seconds,omitempty"` // Window failures are counted in
+	OpenSeconds          int `json:"open_seconds,omitempty"`           // How long the circuit stays open
+}
+
+// MaxWait returns MaxWaitMs as a duration
+func (l Limit) MaxWait() time.Duration {
+	return time.Duration(l.MaxWaitMs) * time.Millisecond
+}
+
+// defaultLimit applies to engines missing from the limits file
+var defaultLimit = Limit{
+	RatePerSecond:        1,
+	Burst:                5,
+	MaxWaitMs:            3000,
+	FailureThreshold:     5,
+	FailureWindowSeconds: 60,
+	OpenSeconds:          120,
+}
+
+//go:embed limits.json
+var defaultLimits []byte
+
+// Limits maps engines and engine:vertical keys to their limits. It is loaded
+// from the embedded limits.json, or from the file named by LIMITS_FILE.
+var Limits = mustLoadLimits()
+
+func mustLoadLimits() map[string]Limit {
+	data := defaultLimits
+	if path := os.Getenv("LIMITS_FILE"); path != "" {
+		custom, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read LIMITS_FILE %s: %v", path, err)
+		}
+		data = custom
+	}
+
+	limits, err := parseLimits(data)
+	if err != nil {
+		log.Fatalf("Failed to load limits: %v", err)
+	}
+	return limits
+}
+
+func parseLimits(data []byte) (map[string]Limit, error) {
+	var limits map[string]Limit
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, fmt.Errorf("invalid limits JSON: %v", err)
+	}
+
+	for key, limit := range limits {
+		if limit.RatePerSecond < 0 || limit.Burst < 0 || limit.MaxWaitMs < 0 {
+			return nil, fmt.Errorf("limit %q must not be negative", key)
+		}
+		engine, _, isVertical := strings.Cut(key, ":")
+		if isVertical {
+			if limit.FailureThreshold != 0 || limit.OpenSeconds != 0 || limit.FailureWindowSeconds != 0 {
+				return nil, fmt.Errorf("limit %q: circuit breakers are configured per engine", key)
+			}
+			continue
+		}
+		if limit.RatePerSecond == 0 || limit.Burst == 0 {
+			return nil, fmt.Errorf("limit %q must set rate_per_second and burst", engine)
+		}
+		// A zero threshold would open the circuit on the first failure, and a
+		// zero window or open time would make the breaker do nothing
+		if limit.FailureThreshold <= 0 || limit.FailureWindowSeconds <= 0 || limit.OpenSeconds <= 0 {
+			return nil, fmt.Errorf("limit %q must set failure_threshold, failure_window_seconds and open_seconds to positive values", engine)
+		}
+	}
+	return limits, nil
+}
+
+// engineLimit returns the limit for an engine, falling back to defaultLimit
+func engineLimit(engine string) Limit {
+	if limit, ok := Limits[engine]; ok {
+		return limit
+	}
+	return defaultLimit
+}
+
+// verticalLimit returns the limit configured for engine:vertical with unset
+// fields taken from the engine, and false when the vertical has no entry
+func verticalLimit(engine, vertical string) (Limit, bool) {
+	limit, ok := Limits[engine+":"+vertical]
+	if !ok {
+		return Limit{}, false
+	}
+
+	base := engineLimit(engine)
+	if limit.RatePerSecond == 0 {
+		limit.RatePerSecond = base.RatePerSecond
+	}
+	if limit.Burst == 0 {
+		limit.Burst = base.Burst
+	}
+	if limit.MaxWaitMs == 0 {
+		limit.MaxWaitMs = base.MaxWaitMs
+	}
+	return limit, true
+}
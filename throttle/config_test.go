@@ -0,0 +1,52 @@
+package throttle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	const engine = `"rate_per_second": 1, "burst": 5, "max_wait_ms": 1000`
+	const breaker = `"failure_threshold": 5, "failure_window_seconds": 60, "open_seconds": 120`
+
+	valid := []string{
+		`{}`,
+		`{"google": {` + engine + `, ` + breaker + `}}`,
+		`{"google": {` + engine + `, ` + breaker + `}, "google:shopping": {"rate_per_second": 0.5}}`,
+	}
+	for _, data := range valid {
+		if _, err := parseLimits([]byte(data)); err != nil {
+			t.Errorf("parseLimits(%s): %v", data, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"not JSON":              `[`,
+		"negative rate":         `{"google:images": {"rate_per_second": -1}}`,
+		"missing burst":         `{"google": {"rate_per_second": 1, ` + breaker + `}}`,
+		"missing breaker":       `{"google": {` + engine + `}}`,
+		"zero threshold":        `{"google": {` + engine + `, "failure_threshold": 0, "failure_window_seconds": 60, "open_seconds": 120}}`,
+		"negative window":       `{"google": {` + engine + `, "failure_threshold": 5, "failure_window_seconds": -60, "open_seconds": 120}}`,
+		"zero open time":        `{"google": {` + engine + `, "failure_threshold": 5, "failure_window_seconds": 60, "open_seconds": 0}}`,
+		"breaker on a vertical": `{"google:shopping": {"rate_per_second": 1, "open_seconds": 10}}`,
+	}
+	for name, data := range invalid {
+		if _, err := parseLimits([]byte(data)); err == nil {
+			t.Errorf("%s: parseLimits(%s) succeeded, want an error", name, data)
+		}
+	}
+}
+
+func TestEmbeddedLimitsAreValid(t *testing.T) {
+	limits, err := parseLimits(defaultLimits)
+	if err != nil {
+		t.Fatalf("limits.json: %v", err)
+	}
+	for key := range limits {
+		if engine, _, isVertical := strings.Cut(key, ":"); isVertical {
+			if _, ok := limits[engine]; !ok {
+				t.Errorf("limits.json sets %s without its engine %s", key, engine)
+			}
+		}
+	}
+}
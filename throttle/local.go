@@ -0,0 +1,120 @@
+package throttle
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// localStore is the in-process fallback used while Redis is unreachable. It
+// follows the same rules as the Redis scripts, but per instance.
+type localStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	breakers map[string]*breaker
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type breaker struct {
+	failures       int
+	windowEnd      time.Time
+	openUntil      time.Time
+	probationUntil time.Time
+}
+
+func newLocalStore() *localStore {
+	return &localStore{
+		buckets:  make(map[string]*bucket),
+		breakers: make(map[string]*breaker),
+	}
+}
+
+func (s *localStore) take(key string, limit Limit) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.RatePerSecond)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, nil
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / limit.RatePerSecond * float64(time.Second))), nil
+}
+
+func (s *localStore) refund(key string, limit Limit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A bucket that was never taken from is already full
+	if b, ok := s.buckets[key]; ok {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+1)
+	}
+	return nil
+}
+
+func (s *localStore) breaker(engine string) *breaker {
+	b, ok := s.breakers[engine]
+	if !ok {
+		b = &breaker{}
+		s.breakers[engine] = b
+	}
+	return b
+}
+
+func (s *localStore) openRemaining(engine string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if remaining := time.Until(s.breaker(engine).openUntil); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+func (s *localStore) recordFailure(engine string, limit Limit) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b := s.breaker(engine)
+	if now.Before(b.openUntil) {
+		return false, nil
+	}
+
+	if now.After(b.windowEnd) {
+		b.failures = 0
+		b.windowEnd = now.Add(time.Duration(limit.FailureWindowSeconds) * time.Second)
+	}
+	b.failures++
+
+	if b.failures >= limit.FailureThreshold || now.Before(b.probationUntil) {
+		open := time.Duration(limit.OpenSeconds) * time.Second
+		b.failures = 0
+		b.openUntil = now.Add(open)
+		b.probationUntil = now.Add(2 * open)
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *localStore) recordSuccess(engine string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.breaker(engine)
+	b.failures = 0
+	b.probationUntil = time.Time{}
+	return nil
+}
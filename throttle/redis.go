@@ -0,0 +1,134 @@
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"googlescrapper/cache"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisTimeout bounds every limiter call so a slow Redis can't stall requests
+const redisTimeout = 200 * time.Millisecond
+
+// takeScript refills the bucket from the time elapsed since the last call and
+// takes one token. It returns 0 when a token was taken, or the milliseconds
+// until one is available. Redis' clock is used so every instance agrees.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`)
+
+// refundScript gives back a token taken by takeScript, up to the burst.
+// Buckets that don't exist are full already.
+var refundScript = redis.NewScript(`
+local tokens = tonumber(redis.call('HGET', KEYS[1], 'tokens'))
+if tokens then
+	redis.call('HSET', KEYS[1], 'tokens', tostring(math.min(tonumber(ARGV[1]), tokens + 1)))
+end
+return 0
+`)
+
+// failureScript counts a failure and opens the circuit once the threshold is
+// reached within the window, or on the first failure after the circuit
+// closed again (half-open). It returns 1 when it opened the circuit.
+// KEYS: failures, open, probation. ARGV: threshold, window ms, open ms.
+var failureScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+
+local failures = redis.call('INCR', KEYS[1])
+if failures == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+
+if failures >= tonumber(ARGV[1]) or redis.call('EXISTS', KEYS[3]) == 1 then
+	redis.call('DEL', KEYS[1])
+	redis.call('SET', KEYS[2], '1', 'PX', ARGV[3])
+	redis.call('SET', KEYS[3], '1', 'PX', tonumber(ARGV[3]) * 2)
+	return 1
+end
+return 0
+`)
+
+// redisStore keeps the limiter state in Redis on the shared cache client
+type redisStore struct{}
+
+func bucketKey(key string) string       { return "throttle:bucket:" + key }
+func failuresKey(engine string) string  { return "throttle:breaker:" + engine + ":failures" }
+func openKey(engine string) string      { return "throttle:breaker:" + engine + ":open" }
+func probationKey(engine string) string { return "throttle:breaker:" + engine + ":probation" }
+
+func (redisStore) take(key string, limit Limit) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	waitMs, err := takeScript.Run(ctx, cache.RedisClient, []string{bucketKey(key)}, limit.RatePerSecond, limit.Burst).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(waitMs) * time.Millisecond, nil
+}
+
+func (redisStore) refund(key string, limit Limit) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	return refundScript.Run(ctx, cache.RedisClient, []string{bucketKey(key)}, limit.Burst).Err()
+}
+
+func (redisStore) openRemaining(engine string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	ttl, err := cache.RedisClient.PTTL(ctx, openKey(engine)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// PTTL is negative when the key doesn't exist
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (redisStore) recordFailure(engine string, limit Limit) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	keys := []string{failuresKey(engine), openKey(engine), probationKey(engine)}
+	window := time.Duration(limit.FailureWindowSeconds) * time.Second
+	open := time.Duration(limit.OpenSeconds) * time.Second
+	opened, err := failureScript.Run(ctx, cache.RedisClient, keys, limit.FailureThreshold, window.Milliseconds(), open.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return opened == 1, nil
+}
+
+func (redisStore) recordSuccess(engine string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	return cache.RedisClient.Del(ctx, failuresKey(engine), probationKey(engine)).Err()
+}
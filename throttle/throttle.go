@@ -0,0 +1,205 @@
+// Package throttle keeps upstream traffic within per-engine and per-vertical
+// token-bucket limits and stops it with a circuit breaker after repeated
+// blocks or timeouts. State is kept in Redis so every instance shares it,
+// with an in-process fallback while Redis is unreachable.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"googlescrapper/fetch"
+)
+
+// Engines with their own limits
+const (
+	EngineGoogle    = "google"
+	EngineBing      = "bing"
+	EngineMintGenie = "mintgenie"
+	EngineLiveMint  = "livemint"
+)
+
+// Acquire waits for a token for the engine and vertical and checks the
+// engine's circuit breaker. When the vertical has its own limit a token is
+// taken from both its bucket and the engine's. It fails with
+// fetch.ErrThrottled when no token frees up within the limit's max wait and
+// with fetch.ErrCircuitOpen while the circuit is open.
+//
+// On success the returned done func must be called with the result of the
+// upstream request so the circuit breaker can count failures. Failures after
+// ctx is done are not counted.
+func Acquire(ctx context.Context, engine, vertical string) (func(error), error) {
+	limit := engineLimit(engine)
+
+	if remaining := openRemaining(engine); remaining > 0 {
+		return nil, &fetch.UpstreamError{Upstream: engine, Err: fetch.ErrCircuitOpen, RetryAfter: remaining}
+	}
+
+	vKey := engine + ":" + vertical
+	vLimit, hasVertical := verticalLimit(engine, vertical)
+	if hasVertical {
+		if err := wait(ctx, engine, vKey, vLimit); err != nil {
+			return nil, err
+		}
+	}
+	if err := wait(ctx, engine, engine, limit); err != nil {
+		if hasVertical {
+			// The request never went out, so the vertical gets its token back
+			refund(vKey, vLimit)
+		}
+		return nil, err
+	}
+
+	return func(err error) {
+		switch {
+		case err == nil:
+			recordSuccess(engine)
+		case ctx.Err() != nil:
+			// The caller went away or ran out of time, not the upstream's fault
+		case tripsBreaker(err):
+			if recordFailure(engine, limit) {
+				log.Printf("Circuit breaker for %s opened for %ds: %v", engine, limit.OpenSeconds, err)
+			}
+		}
+	}, nil
+}
+
+// wait blocks until the bucket has a token, or fails when that would take
+// longer than the limit's max wait
+func wait(ctx context.Context, engine, key string, limit Limit) error {
+	deadline := time.Now().Add(limit.MaxWait())
+	for {
+		delay := take(key, limit)
+		if delay == 0 {
+			return nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return &fetch.UpstreamError{Upstream: engine, Err: fetch.ErrThrottled, RetryAfter: delay}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// tripsBreaker reports whether an error counts towards opening the circuit:
+// blocks, captchas, rate limits, timeouts and upstream server errors
+func tripsBreaker(err error) bool {
+	if fetch.Unsent(err) {
+		return false
+	}
+	if _, _, blocked := fetch.ErrorStatus(err); blocked {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var statusErr *fetch.StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode >= 500
+}
+
+// store holds bucket and breaker state
+type store interface {
+	take(key string, limit Limit) (time.Duration, error)
+	refund(key string, limit Limit) error
+	openRemaining(engine string) (time.Duration, error)
+	recordFailure(engine string, limit Limit) (bool, error)
+	recordSuccess(engine string) error
+}
+
+// redisRetryInterval is how long the local fallback is used after a Redis
+// error before Redis is tried again
+const redisRetryInterval = 10 * time.Second
+
+var (
+	shared store = redisStore{}
+	local        = newLocalStore()
+
+	redisDownUntil time.Time
+	redisDownMu    sync.Mutex
+)
+
+// redisAvailable reports whether Redis should be tried for the next call
+func redisAvailable() bool {
+	redisDownMu.Lock()
+	defer redisDownMu.Unlock()
+	return time.Now().After(redisDownUntil)
+}
+
+// fallback records the outcome of a Redis call and reports whether to use the
+// local store instead. Switches to and from the fallback are logged once.
+func fallback(err error) bool {
+	redisDownMu.Lock()
+	defer redisDownMu.Unlock()
+
+	wasDown := !redisDownUntil.IsZero()
+	switch {
+	case err != nil:
+		if !wasDown {
+			log.Printf("Rate limiter falling back to local state, Redis unavailable: %v", err)
+		}
+		redisDownUntil = time.Now().Add(redisRetryInterval)
+		return true
+	case wasDown:
+		log.Printf("Rate limiter using Redis again")
+		redisDownUntil = time.Time{}
+	}
+	return false
+}
+
+func take(key string, limit Limit) time.Duration {
+	if redisAvailable() {
+		if delay, err := shared.take(key, limit); !fallback(err) {
+			return delay
+		}
+	}
+	delay, _ := local.take(key, limit)
+	return delay
+}
+
+func refund(key string, limit Limit) {
+	if redisAvailable() && !fallback(shared.refund(key, limit)) {
+		return
+	}
+	local.refund(key, limit)
+}
+
+func openRemaining(engine string) time.Duration {
+	if redisAvailable() {
+		if remaining, err := shared.openRemaining(engine); !fallback(err) {
+			return remaining
+		}
+	}
+	remaining, _ := local.openRemaining(engine)
+	return remaining
+}
+
+func recordFailure(engine string, limit Limit) bool {
+	if redisAvailable() {
+		if opened, err := shared.recordFailure(engine, limit); !fallback(err) {
+			return opened
+		}
+	}
+	opened, _ := local.recordFailure(engine, limit)
+	return opened
+}
+
+func recordSuccess(engine string) {
+	if redisAvailable() && !fallback(shared.recordSuccess(engine)) {
+		return
+	}
+	local.recordSuccess(engine)
+}
@@ -0,0 +1,90 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"googlescrapper/fetch"
+)
+
+// useLocalStore runs the test against a fresh local store with the given
+// limits, without trying Redis
+func useLocalStore(t *testing.T, limits map[string]Limit) {
+	t.Helper()
+	savedLimits, savedLocal := Limits, local
+	redisDownMu.Lock()
+	savedDownUntil := redisDownUntil
+	redisDownUntil = time.Now().Add(time.Hour)
+	redisDownMu.Unlock()
+
+	Limits, local = limits, newLocalStore()
+	t.Cleanup(func() {
+		Limits, local = savedLimits, savedLocal
+		redisDownMu.Lock()
+		redisDownUntil = savedDownUntil
+		redisDownMu.Unlock()
+	})
+}
+
+func TestAcquireRefundsVerticalWhenEngineThrottles(t *testing.T) {
+	useLocalStore(t, map[string]Limit{
+		"google":          {RatePerSecond: 0.001, Burst: 1, MaxWaitMs: 1, FailureThreshold: 5, FailureWindowSeconds: 60, OpenSeconds: 60},
+		"google:shopping": {RatePerSecond: 0.001, Burst: 2},
+	})
+
+	// Uses the only engine token
+	if _, err := Acquire(context.Background(), "google", "search"); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	// The engine is out of tokens, so neither request may spend a shopping token
+	for i := 0; i < 2; i++ {
+		_, err := Acquire(context.Background(), "google", "shopping")
+		if !errors.Is(err, fetch.ErrThrottled) {
+			t.Fatalf("Acquire = %v, want ErrThrottled", err)
+		}
+	}
+	if tokens := local.buckets["google:shopping"].tokens; tokens < 2 {
+		t.Errorf("shopping bucket has %.2f tokens after throttled requests, want 2", tokens)
+	}
+}
+
+func TestLocalRefundCapsAtBurst(t *testing.T) {
+	s := newLocalStore()
+	limit := Limit{RatePerSecond: 0.001, Burst: 2}
+
+	s.take("k", limit)
+	s.refund("k", limit)
+	s.refund("k", limit)
+	if tokens := s.buckets["k"].tokens; tokens > 2 {
+		t.Errorf("refunds filled the bucket to %.2f tokens, past the burst of 2", tokens)
+	}
+
+	// Refunding a bucket that was never taken from doesn't create it
+	s.refund("other", limit)
+	if _, ok := s.buckets["other"]; ok {
+		t.Error("refund created a bucket")
+	}
+}
+
+func TestTripsBreaker(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&fetch.UpstreamError{Upstream: "google", Err: fetch.ErrCaptcha}, true},
+		{&fetch.StatusError{StatusCode: 502}, true},
+		{&fetch.StatusError{StatusCode: 404}, false},
+		{context.DeadlineExceeded, true},
+		{&fetch.UpstreamError{Upstream: "google", Err: fetch.ErrThrottled}, false},
+		{fetch.ErrNoProxy, false},
+		{errors.New("parse error"), false},
+	}
+	for _, tc := range tests {
+		if got := tripsBreaker(tc.err); got != tc.want {
+			t.Errorf("tripsBreaker(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -1,13 +1,133 @@
 package utils
 
-import "fmt"
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
 
-func CreateUULE(lat, lon float64) string {
-	return fmt.Sprintf("w+CAIQICI%s", EncodeCoordinates(lat, lon))
+// CanonicalLocation is a Google Ads geotarget with its approximate centre
+type CanonicalLocation struct {
+	Name        string  `json:"name"` // Canonical name, e.g. "Mumbai,Maharashtra,India"
+	CountryCode string  `json:"country_code"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
 }
 
-// encodeCoordinates creates a simple encoding of the coordinates
-func EncodeCoordinates(lat, lon float64) string {
-	// Basic encoding - in practice, Google uses a more sophisticated algorithm
-	return fmt.Sprintf("%f:%f", lat, lon)
+// SnapRadiusKm is how far coordinates may be from a canonical location and
+// still be snapped to it
+const SnapRadiusKm = 50.0
+
+// uuleKeys is indexed by the length of the canonical name to give the UULE
+// length key character
+const uuleKeys = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+//go:embed locations.csv
+var locationsCSV string
+
+// canonicalLocations is the parsed contents of locations.csv
+var canonicalLocations = mustParseLocations(locationsCSV)
+
+func mustParseLocations(data string) []CanonicalLocation {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse locations.csv: %v", err))
+	}
+
+	var locations []CanonicalLocation
+	for _, record := range records[1:] { // Skip the header
+		lat, latErr := strconv.ParseFloat(record[2], 64)
+		lon, lonErr := strconv.ParseFloat(record[3], 64)
+		if latErr != nil || lonErr != nil {
+			panic(fmt.Sprintf("invalid coordinates for %q in locations.csv", record[0]))
+		}
+		locations = append(locations, CanonicalLocation{
+			Name:        record[0],
+			CountryCode: record[1],
+			Latitude:    lat,
+			Longitude:   lon,
+		})
+	}
+	return locations
+}
+
+// Locations returns every known canonical location
+func Locations() []CanonicalLocation {
+	return canonicalLocations
+}
+
+// LookupLocation finds a canonical location by its full canonical name or by
+// its city name, ignoring case. Ambiguous city names match the first entry.
+func LookupLocation(name string) (CanonicalLocation, bool) {
+	name = strings.TrimSpace(name)
+	for _, loc := range canonicalLocations {
+		if strings.EqualFold(loc.Name, name) {
+			return loc, true
+		}
+	}
+	for _, loc := range canonicalLocations {
+		if strings.EqualFold(strings.SplitN(loc.Name, ",", 2)[0], name) {
+			return loc, true
+		}
+	}
+	return CanonicalLocation{}, false
+}
+
+// NearestLocation returns the canonical location closest to the given
+// coordinates and its distance in kilometres
+func NearestLocation(lat, lon float64) (CanonicalLocation, float64) {
+	var nearest CanonicalLocation
+	best := math.Inf(1)
+	for _, loc := range canonicalLocations {
+		if d := haversineKm(lat, lon, loc.Latitude, loc.Longitude); d < best {
+			nearest, best = loc, d
+		}
+	}
+	return nearest, best
+}
+
+// haversineKm returns the great-circle distance between two points
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// UULE encodes a canonical location name for Google's uule parameter: the
+// "w+CAIQICI" prefix, a key character for the name's length, then the name in
+// base64
+func UULE(canonicalName string) (string, error) {
+	if canonicalName == "" || len(canonicalName) >= len(uuleKeys) {
+		return "", fmt.Errorf("canonical name must be 1-%d bytes", len(uuleKeys)-1)
+	}
+	key := uuleKeys[len(canonicalName)]
+	return "w+CAIQICI" + string(key) + base64.StdEncoding.EncodeToString([]byte(canonicalName)), nil
+}
+
+// CoordinateUULE encodes coordinates for Google's uule parameter using the
+// "a+" form, which describes a device location instead of a named place
+func CoordinateUULE(lat, lon float64) string {
+	text := fmt.Sprintf("role: CURRENT_LOCATION\nproducer: DEVICE_LOCATION\nradius: 65000\nlatlng <\n  latitude_e7: %d\n  longitude_e7: %d\n>",
+		int64(math.Round(lat*1e7)), int64(math.Round(lon*1e7)))
+	return "a+" + base64.StdEncoding.EncodeToString([]byte(text))
+}
+
+// UULEForCoordinates uses the canonical name of a location within
+// SnapRadiusKm of the coordinates, falling back to the "a+" form
+func UULEForCoordinates(lat, lon float64) string {
+	if loc, distance := NearestLocation(lat, lon); distance <= SnapRadiusKm {
+		if uule, err := UULE(loc.Name); err == nil {
+			return uule
+		}
+	}
+	return CoordinateUULE(lat, lon)
 }
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestUULE(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"New York,New York,United States", "w+CAIQICIfTmV3IFlvcmssTmV3IFlvcmssVW5pdGVkIFN0YXRlcw=="},
+		{"Mumbai,Maharashtra,India", "w+CAIQICIYTXVtYmFpLE1haGFyYXNodHJhLEluZGlh"},
+		{"London,England,United Kingdom", "w+CAIQICIdTG9uZG9uLEVuZ2xhbmQsVW5pdGVkIEtpbmdkb20="},
+		{"a", "w+CAIQICIBYQ=="},
+	}
+	for _, tc := range tests {
+		got, err := UULE(tc.name)
+		if err != nil {
+			t.Errorf("UULE(%q): %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("UULE(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestUULEInvalidLength(t *testing.T) {
+	for _, name := range []string{"", strings.Repeat("x", len(uuleKeys))} {
+		if got, err := UULE(name); err == nil {
+			t.Errorf("UULE of a %d byte name = %q, want an error", len(name), got)
+		}
+	}
+}
+
+func TestCoordinateUULE(t *testing.T) {
+	uule := CoordinateUULE(48.8566, 2.3522)
+	if !strings.HasPrefix(uule, "a+") {
+		t.Fatalf("CoordinateUULE = %q, want the a+ form", uule)
+	}
+	text, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uule, "a+"))
+	if err != nil {
+		t.Fatalf("decoding %q: %v", uule, err)
+	}
+	for _, want := range []string{"latitude_e7: 488566000", "longitude_e7: 23522000", "role: CURRENT_LOCATION"} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("CoordinateUULE text %q doesn't contain %q", text, want)
+		}
+	}
+}
+
+func TestUULEForCoordinates(t *testing.T) {
+	// A few kilometres from the centre of Manhattan snaps to New York
+	want, _ := UULE("New York,New York,United States")
+	if got := UULEForCoordinates(40.75, -73.99); got != want {
+		t.Errorf("UULEForCoordinates near New York = %q, want %q", got, want)
+	}
+
+	// The middle of the Atlantic is nowhere near a canonical location
+	if got := UULEForCoordinates(30, -40); !strings.HasPrefix(got, "a+") {
+		t.Errorf("UULEForCoordinates mid-Atlantic = %q, want the a+ form", got)
+	}
+}
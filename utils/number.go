@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var countRe = regexp.MustCompile(`(\d[\d,.\s]*)\s*([KkMm])?`)
+
+// ParseCount parses review or result counts such as "(1,234)", "1.2K" or "3M"
+func ParseCount(text string) int {
+	match := countRe.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+
+	number := strings.ReplaceAll(match[1], " ", "")
+	multiplier := 1.0
+	switch strings.ToUpper(match[2]) {
+	case "K":
+		multiplier = 1e3
+	case "M":
+		multiplier = 1e6
+	}
+
+	if multiplier == 1 {
+		// Plain counts only use separators for grouping
+		number = strings.NewReplacer(",", "", ".", "").Replace(number)
+	} else {
+		// Abbreviated counts may use either separator as the decimal point
+		number = strings.ReplaceAll(number, ",", ".")
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimRight(number, "."), 64)
+	if err != nil {
+		return 0
+	}
+	return int(value * multiplier)
+}
+
+var ratingRe = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+
+// ParseRating parses a star rating such as "4.5" or "4,5 out of 5"
+func ParseRating(text string) float64 {
+	match := ratingRe.FindString(text)
+	if match == "" {
+		return 0
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(match, ",", "."), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
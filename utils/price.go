@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Price is a monetary amount parsed from a displayed price string
+type Price struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency,omitempty"` // ISO 4217 code
+	Raw      string  `json:"raw"`
+}
+
+// currencySymbols maps price prefixes and suffixes to ISO 4217 codes. Multi
+// character symbols come first so "R$" isn't read as "$".
+var currencySymbols = []struct {
+	symbol string
+	code   string
+}{
+	{"US$", "USD"},
+	{"CA$", "CAD"},
+	{"AU$", "AUD"},
+	{"HK$", "HKD"},
+	{"NZ$", "NZD"},
+	{"MX$", "MXN"},
+	{"R$", "BRL"},
+	{"A$", "AUD"},
+	{"C$", "CAD"},
+	{"S$", "SGD"},
+	{"Rs.", "INR"},
+	{"Rs", "INR"},
+	{"zł", "PLN"},
+	{"Kč", "CZK"},
+	{"CHF", "CHF"},
+	{"₹", "INR"},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"¥", "JPY"},
+	{"₩", "KRW"},
+	{"₽", "RUB"},
+	{"₺", "TRY"},
+	{"₫", "VND"},
+	{"₱", "PHP"},
+	{"฿", "THB"},
+	{"₪", "ILS"},
+	{"$", "USD"},
+}
+
+var (
+	isoCodeRe = regexp.MustCompile(`\b(USD|EUR|GBP|INR|JPY|CAD|AUD|BRL|MXN|CHF|SEK|NOK|DKK|PLN|CZK|HUF|RUB|TRY|ZAR|SGD|HKD|NZD|KRW|CNY|IDR|MYR|PHP|THB|VND|AED|SAR|ILS)\b`)
+	amountRe  = regexp.MustCompile(`\d[\d.,\s\x{00a0}\x{202f}']*`)
+)
+
+// ParsePrice parses strings such as "₹1,23,456.00", "$1,299.99", "1.299,99 €"
+// or "USD 12" into a Price. It returns nil when no amount can be found.
+func ParsePrice(raw string) *Price {
+	raw = strings.TrimSpace(raw)
+	number := strings.TrimSpace(amountRe.FindString(raw))
+	if number == "" {
+		return nil
+	}
+
+	amount, ok := parseAmount(number)
+	if !ok {
+		return nil
+	}
+
+	return &Price{
+		Amount:   amount,
+		Currency: detectCurrency(raw),
+		Raw:      raw,
+	}
+}
+
+// detectCurrency returns the ISO code for the currency used in a price string
+func detectCurrency(raw string) string {
+	if code := isoCodeRe.FindString(raw); code != "" {
+		return code
+	}
+	for _, c := range currencySymbols {
+		if strings.Contains(raw, c.symbol) {
+			return c.code
+		}
+	}
+	return ""
+}
+
+// parseAmount works out which separator is the decimal point. A trailing
+// separator followed by one or two digits is decimal; everything else groups
+// thousands (including the Indian lakh grouping "1,23,456").
+func parseAmount(number string) (float64, bool) {
+	number = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "'", "").Replace(number)
+	number = strings.TrimRight(number, ".,")
+
+	decimal := -1
+	if last := strings.LastIndexAny(number, ".,"); last != -1 {
+		if digits := len(number) - last - 1; digits > 0 && digits <= 2 {
+			decimal = last
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range number {
+		switch {
+		case i == decimal:
+			b.WriteRune('.')
+		case r == '.' || r == ',':
+			// Thousands separator
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	value, err := strconv.ParseFloat(b.String(), 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
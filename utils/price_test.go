@@ -0,0 +1,46 @@
+package utils
+
+import "testing"
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		raw      string
+		amount   float64
+		currency string
+	}{
+		{"$1,299.99", 1299.99, "USD"},
+		{"US$ 25", 25, "USD"},
+		{"₹1,23,456.00", 123456, "INR"},
+		{"Rs. 499", 499, "INR"},
+		{"1.299,99 €", 1299.99, "EUR"},
+		{"£12.5", 12.5, "GBP"},
+		{"R$ 1.234,56", 1234.56, "BRL"},
+		{"CA$19.99", 19.99, "CAD"},
+		{"USD 12", 12, "USD"},
+		{"12 EUR", 12, "EUR"},
+		{"CHF 1'234.50", 1234.5, "CHF"},
+		{"1 234,5 zł", 1234.5, "PLN"},
+		{"¥1,000", 1000, "JPY"},
+		{"1,000", 1000, ""},
+		{"  $5.  ", 5, "USD"},
+	}
+
+	for _, tc := range tests {
+		got := ParsePrice(tc.raw)
+		if got == nil {
+			t.Errorf("ParsePrice(%q) = nil, want %v %s", tc.raw, tc.amount, tc.currency)
+			continue
+		}
+		if got.Amount != tc.amount || got.Currency != tc.currency {
+			t.Errorf("ParsePrice(%q) = %v %q, want %v %q", tc.raw, got.Amount, got.Currency, tc.amount, tc.currency)
+		}
+	}
+}
+
+func TestParsePriceNoAmount(t *testing.T) {
+	for _, raw := range []string{"", "   ", "Free", "$", "Price unavailable"} {
+		if got := ParsePrice(raw); got != nil {
+			t.Errorf("ParsePrice(%q) = %+v, want nil", raw, *got)
+		}
+	}
+}